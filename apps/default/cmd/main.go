@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"net/http"
+	"time"
 
 	//nolint:gosec // G108: Profiling endpoint deliberately exposed for monitoring and debugging purposes
 	_ "net/http/pprof"
@@ -19,6 +20,7 @@ import (
 	"github.com/pitabwire/frame/datastore"
 	"github.com/pitabwire/frame/security"
 	securityconnect "github.com/pitabwire/frame/security/interceptors/connect"
+	"github.com/pitabwire/frame/security/interceptors/httptor"
 	"github.com/pitabwire/util"
 )
 
@@ -36,7 +38,7 @@ func main() {
 		cfg.ServiceName = "service_ledger"
 	}
 
-	_, service := frame.NewServiceWithContext(
+	ctx, service := frame.NewServiceWithContext(
 		ctx,
 		frame.WithConfig(&cfg),
 		frame.WithRegisterServerOauth2Client(),
@@ -56,13 +58,46 @@ func main() {
 	ledgerRepo := repository.NewLedgerRepository(ctx, dbPool, workMan)
 	accountRepo := repository.NewAccountRepository(ctx, dbPool, workMan)
 	transactionRepo := repository.NewTransactionRepository(ctx, dbPool, workMan, accountRepo)
+	periodRepo := repository.NewPeriodRepository(ctx, dbPool, workMan)
+	fxRateRepo := repository.NewFXRateRepository(ctx, dbPool, workMan)
+	templateRepo := repository.NewPostingTemplateRepository(ctx, dbPool, workMan)
 
-	ledgerBusiness := business.NewLedgerBusiness(workMan, ledgerRepo)
+	ledgerBusiness := business.NewLedgerBusiness(workMan, ledgerRepo, accountRepo, cfg.GetLedgerChildTypes())
 	accountBusiness := business.NewAccountBusiness(workMan, ledgerRepo, accountRepo)
-	transactionBusiness := business.NewTransactionBusiness(workMan, accountRepo, transactionRepo)
+	transactionBusiness := business.NewTransactionBusiness(
+		workMan, accountRepo, transactionRepo, cfg.GetAdjustmentRole(), cfg.GetFXPositionAccounts(), cfg.GetHoldTTL())
+	statementBusiness := business.NewStatementBusiness(accountRepo, transactionRepo)
+	fxRateBusiness := business.NewFXRateBusiness(fxRateRepo)
+	reportBusiness := business.NewReportBusiness(ledgerRepo, accountRepo, fxRateBusiness)
+	chartBusiness := business.NewChartBusiness(ledgerRepo, accountRepo, cfg.GetLedgerChildTypes())
+	periodBusiness := business.NewPeriodBusiness(periodRepo, cfg.GetPeriodAdminRole())
+	yearEndBusiness := business.NewYearEndBusiness(accountRepo, transactionBusiness, cfg.GetPeriodAdminRole())
+	revaluationBusiness := business.NewRevaluationBusiness(business.RevaluationConfig{
+		BaseCurrency: cfg.GetFXBaseCurrency(),
+		GainLedgerID: cfg.GetFXGainLedger(),
+		LossLedgerID: cfg.GetFXLossLedger(),
+	}, ledgerRepo, accountRepo, fxRateRepo, fxRateBusiness, transactionBusiness)
+	templateBusiness := business.NewTemplateBusiness(templateRepo, transactionBusiness)
 
 	// Create handler with injected business layer
 	ledgerServer := handlers.NewLedgerServer(ledgerBusiness, accountBusiness, transactionBusiness)
+	statementServer := handlers.NewStatementServer(statementBusiness)
+
+	// Plain HTTP endpoints, served by path next to the connect services
+	httpHandlers := map[string]http.Handler{
+		handlers.StatementExportPath: handlers.NewStatementExportHandler(statementBusiness),
+		handlers.ReportsPath:         handlers.NewReportsHandler(reportBusiness),
+		handlers.LedgerTreePath:      handlers.NewLedgerTreeHandler(ledgerBusiness),
+		handlers.ChartApplyPath:      handlers.NewChartHandler(chartBusiness),
+		handlers.PeriodsPath:         handlers.NewPeriodsHandler(periodBusiness, yearEndBusiness),
+		handlers.ExchangePath:        handlers.NewExchangeHandler(transactionBusiness),
+		handlers.FXPath:              handlers.NewFXHandler(fxRateBusiness, revaluationBusiness),
+		handlers.HoldsPath:           handlers.NewHoldsHandler(transactionBusiness),
+		handlers.AccountBalancesPath: handlers.NewAccountBalancesHandler(accountBusiness),
+		handlers.SettlementsPath:     handlers.NewSettlementsHandler(transactionBusiness),
+		handlers.ReversalsPath:       handlers.NewReversalsHandler(transactionBusiness),
+		handlers.TemplatesPath:       handlers.NewTemplatesHandler(templateBusiness),
+	}
 
 	// Handle database migration if requested
 	if handleDatabaseMigration(ctx, dbManager, cfg, log) {
@@ -70,12 +105,46 @@ func main() {
 	}
 
 	// Setup Connect server with injected dependencies
-	connectHandler := setupConnectServer(ctx, service.SecurityManager(), ledgerServer)
+	connectHandler := setupConnectServer(ctx, service.SecurityManager(), ledgerServer, statementServer, httpHandlers)
 
 	// Setup HTTP handlers
 	serviceOptions := []frame.Option{frame.WithHTTPHandler(connectHandler)}
 	service.Init(ctx, serviceOptions...)
 
+	balanceVerifier := business.NewBalanceVerifier(accountRepo, cfg.GetBalanceVerificationBatchSize())
+	err = business.RunPeriodically(ctx, workMan, "balance_verification", cfg.GetBalanceVerificationInterval(),
+		func(ctx context.Context) error {
+			_, verifyErr := balanceVerifier.VerifyBalances(ctx)
+			return verifyErr
+		})
+	if err != nil {
+		log.WithError(err).Fatal("main -- Could not schedule balance verification")
+	}
+
+	err = business.RunPeriodically(ctx, workMan, "balance_view_refresh", cfg.GetBalanceViewRefreshInterval(),
+		accountRepo.RefreshBalancesView)
+	if err != nil {
+		log.WithError(err).Fatal("main -- Could not schedule balance view refresh")
+	}
+
+	err = business.RunPeriodically(ctx, workMan, "fx_revaluation", cfg.GetFXRevaluationInterval(),
+		func(ctx context.Context) error {
+			_, revalueErr := revaluationBusiness.Revalue(ctx, time.Now())
+			return revalueErr
+		})
+	if err != nil {
+		log.WithError(err).Fatal("main -- Could not schedule FX revaluation")
+	}
+
+	err = business.RunPeriodically(ctx, workMan, "hold_expiry", cfg.GetHoldExpiryInterval(),
+		func(ctx context.Context) error {
+			_, expireErr := transactionBusiness.ExpireHolds(ctx, time.Now())
+			return expireErr
+		})
+	if err != nil {
+		log.WithError(err).Fatal("main -- Could not schedule hold expiry")
+	}
+
 	// Startup service
 	err = service.Run(ctx, "")
 	if err != nil {
@@ -105,6 +174,8 @@ func setupConnectServer(
 	ctx context.Context,
 	securityMan security.Manager,
 	implementation ledgerv1connect.LedgerServiceHandler,
+	statementServer *handlers.StatementServer,
+	httpHandlers map[string]http.Handler,
 ) http.Handler {
 	otelInterceptor, err := otelconnect.NewInterceptor()
 	if err != nil {
@@ -116,8 +187,19 @@ func setupConnectServer(
 	authenticator := securityMan.GetAuthenticator(ctx)
 	authInterceptor := securityconnect.NewAuthInterceptor(authenticator)
 
-	_, serverHandler := ledgerv1connect.NewLedgerServiceHandler(
-		implementation, connect.WithInterceptors(authInterceptor, otelInterceptor, validateInterceptor))
+	interceptors := connect.WithInterceptors(authInterceptor, otelInterceptor, validateInterceptor)
+
+	mux := http.NewServeMux()
+
+	ledgerPath, ledgerHandler := ledgerv1connect.NewLedgerServiceHandler(implementation, interceptors)
+	mux.Handle(ledgerPath, ledgerHandler)
+
+	statementPath, statementHandler := handlers.NewStatementServiceHandler(statementServer, interceptors)
+	mux.Handle(statementPath, statementHandler)
+
+	for path, handler := range httpHandlers {
+		mux.Handle(path, httptor.AuthenticationMiddleware(handler, authenticator))
+	}
 
-	return serverHandler
+	return mux
 }
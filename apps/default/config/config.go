@@ -1,9 +1,169 @@
 package config
 
 import (
+	"context"
+	"strings"
+	"time"
+
 	"github.com/pitabwire/frame/config"
+	"github.com/pitabwire/util"
+)
+
+const (
+	defaultBalanceVerificationInterval  = 15 * time.Minute
+	defaultBalanceVerificationBatchSize = 500
+	defaultBalanceViewRefreshInterval   = 5 * time.Minute
+	defaultAdjustmentRole               = "ledger_adjustment"
+	defaultPeriodAdminRole              = "ledger_period_admin"
+	defaultFXRevaluationInterval        = 24 * time.Hour
+	defaultHoldTTL                      = 7 * 24 * time.Hour
+	defaultHoldExpiryInterval           = time.Minute
 )
 
 type LedgerConfig struct {
 	config.ConfigurationDefault
+
+	BalanceVerificationInterval  string `envDefault:"" env:"BALANCE_VERIFICATION_INTERVAL"   yaml:"balance_verification_interval"`
+	BalanceVerificationBatchSize int    `envDefault:"" env:"BALANCE_VERIFICATION_BATCH_SIZE" yaml:"balance_verification_batch_size"`
+	BalanceViewRefreshInterval   string `envDefault:"" env:"BALANCE_VIEW_REFRESH_INTERVAL"   yaml:"balance_view_refresh_interval"`
+	LedgerChildTypes             string `envDefault:"" env:"LEDGER_CHILD_TYPES"              yaml:"ledger_child_types"`
+	AdjustmentRole               string `envDefault:"" env:"LEDGER_ADJUSTMENT_ROLE"          yaml:"adjustment_role"`
+	PeriodAdminRole              string `envDefault:"" env:"LEDGER_PERIOD_ADMIN_ROLE"        yaml:"period_admin_role"`
+	FXPositionAccounts           string `envDefault:"" env:"FX_POSITION_ACCOUNTS"            yaml:"fx_position_accounts"`
+	FXBaseCurrency               string `envDefault:"" env:"FX_BASE_CURRENCY"                yaml:"fx_base_currency"`
+	FXGainLedger                 string `envDefault:"" env:"FX_GAIN_LEDGER"                  yaml:"fx_gain_ledger"`
+	FXLossLedger                 string `envDefault:"" env:"FX_LOSS_LEDGER"                  yaml:"fx_loss_ledger"`
+	FXRevaluationInterval        string `envDefault:"" env:"FX_REVALUATION_INTERVAL"         yaml:"fx_revaluation_interval"`
+	HoldTTL                      string `envDefault:"" env:"HOLD_TTL"                        yaml:"hold_ttl"`
+	HoldExpiryInterval           string `envDefault:"" env:"HOLD_EXPIRY_INTERVAL"            yaml:"hold_expiry_interval"`
+}
+
+// GetBalanceVerificationInterval returns how often a window of account balances is verified against their entries.
+// A zero interval disables the verification job.
+func (c *LedgerConfig) GetBalanceVerificationInterval() time.Duration {
+	return parseDuration(
+		"BALANCE_VERIFICATION_INTERVAL", c.BalanceVerificationInterval, defaultBalanceVerificationInterval)
+}
+
+// GetBalanceVerificationBatchSize returns the number of accounts verified on each run.
+func (c *LedgerConfig) GetBalanceVerificationBatchSize() int {
+	if c.BalanceVerificationBatchSize > 0 {
+		return c.BalanceVerificationBatchSize
+	}
+
+	return defaultBalanceVerificationBatchSize
+}
+
+// GetBalanceViewRefreshInterval returns how often account_balances_view is refreshed.
+// A zero interval disables the refresh.
+func (c *LedgerConfig) GetBalanceViewRefreshInterval() time.Duration {
+	return parseDuration("BALANCE_VIEW_REFRESH_INTERVAL", c.BalanceViewRefreshInterval, defaultBalanceViewRefreshInterval)
+}
+
+// GetLedgerChildTypes returns the ledger types allowed under each parent ledger type besides the parent's own,
+// read from comma separated PARENT:CHILD pairs such as "CAPITAL:INCOME,CAPITAL:EXPENSE". Either side may be
+// the wildcard "*", so "*:*" lets any ledger be filed under any other.
+func (c *LedgerConfig) GetLedgerChildTypes() map[string][]string {
+	childTypes := make(map[string][]string)
+	for _, pair := range strings.Split(c.LedgerChildTypes, ",") {
+		parentType, childType, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || parentType == "" || childType == "" {
+			continue
+		}
+
+		parentType = strings.ToUpper(strings.TrimSpace(parentType))
+		childTypes[parentType] = append(childTypes[parentType], strings.ToUpper(strings.TrimSpace(childType)))
+	}
+
+	return childTypes
+}
+
+// GetAdjustmentRole returns the role a caller needs to post into a closed accounting period.
+func (c *LedgerConfig) GetAdjustmentRole() string {
+	if c.AdjustmentRole != "" {
+		return c.AdjustmentRole
+	}
+
+	return defaultAdjustmentRole
+}
+
+// GetPeriodAdminRole returns the role a caller needs to close accounting periods.
+func (c *LedgerConfig) GetPeriodAdminRole() string {
+	if c.PeriodAdminRole != "" {
+		return c.PeriodAdminRole
+	}
+
+	return defaultPeriodAdminRole
+}
+
+// GetFXPositionAccounts returns the account that holds the ledger's open position in each currency, read
+// from comma separated CURRENCY:ACCOUNT pairs such as "KES:fx-position-kes,USD:fx-position-usd".
+// Exchanges post the amounts bought and sold in a currency against its position account.
+func (c *LedgerConfig) GetFXPositionAccounts() map[string]string {
+	positionAccounts := make(map[string]string)
+	for _, pair := range strings.Split(c.FXPositionAccounts, ",") {
+		currencyCode, accountID, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || currencyCode == "" || accountID == "" {
+			continue
+		}
+
+		positionAccounts[strings.ToUpper(strings.TrimSpace(currencyCode))] = strings.TrimSpace(accountID)
+	}
+
+	return positionAccounts
+}
+
+// GetFXBaseCurrency returns the currency foreign currency accounts are revalued into.
+func (c *LedgerConfig) GetFXBaseCurrency() string {
+	return strings.ToUpper(strings.TrimSpace(c.FXBaseCurrency))
+}
+
+// GetFXGainLedger returns the INCOME ledger unrealised exchange gains are posted to.
+func (c *LedgerConfig) GetFXGainLedger() string {
+	return strings.TrimSpace(c.FXGainLedger)
+}
+
+// GetFXLossLedger returns the EXPENSE ledger unrealised exchange losses are posted to.
+func (c *LedgerConfig) GetFXLossLedger() string {
+	return strings.TrimSpace(c.FXLossLedger)
+}
+
+// GetFXRevaluationInterval returns how often foreign currency accounts are revalued into the base currency.
+// Revaluation only runs when a base currency and the gain and loss ledgers are configured.
+func (c *LedgerConfig) GetFXRevaluationInterval() time.Duration {
+	if c.GetFXBaseCurrency() == "" || c.GetFXGainLedger() == "" || c.GetFXLossLedger() == "" {
+		return 0
+	}
+
+	return parseDuration("FX_REVALUATION_INTERVAL", c.FXRevaluationInterval, defaultFXRevaluationInterval)
+}
+
+// GetHoldTTL returns how long a reservation holds funds when it does not set its own expiry.
+// A zero TTL keeps such holds until they are captured or released.
+func (c *LedgerConfig) GetHoldTTL() time.Duration {
+	return parseDuration("HOLD_TTL", c.HoldTTL, defaultHoldTTL)
+}
+
+// GetHoldExpiryInterval returns how often holds past their expiry are released.
+// A zero interval disables the expiry job.
+func (c *LedgerConfig) GetHoldExpiryInterval() time.Duration {
+	return parseDuration("HOLD_EXPIRY_INTERVAL", c.HoldExpiryInterval, defaultHoldExpiryInterval)
+}
+
+// parseDuration reads the duration set for the setting name, or returns fallback when it is unset.
+// A value that does not parse is logged and also falls back, rather than stopping the service.
+func parseDuration(name string, value string, fallback time.Duration) time.Duration {
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		util.Log(context.Background()).WithError(err).WithField("setting", name).
+			WithField("value", value).WithField("default", fallback.String()).
+			Warn("invalid duration setting, using the default")
+		return fallback
+	}
+
+	return duration
 }
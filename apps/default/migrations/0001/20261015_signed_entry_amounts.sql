@@ -0,0 +1,10 @@
+-- CreateTransaction used to apply entry signage before Transact applied it a second time,
+-- so entries posted through the API were stored unsigned. Entries are now stored in the
+-- natural sign of their account, negate the amounts the old path left positive.
+UPDATE transaction_entries e
+SET amount = -e.amount
+FROM accounts a
+WHERE a.id = e.account_id
+  AND e.amount > 0
+  AND ((e.credit AND a.ledger_type IN ('ASSET', 'EXPENSE'))
+    OR (NOT e.credit AND a.ledger_type IN ('LIABILITY', 'INCOME', 'CAPITAL')));
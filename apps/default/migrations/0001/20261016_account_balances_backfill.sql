@@ -0,0 +1,33 @@
+-- Number existing entries per account in posting order so new postings continue the sequence
+UPDATE transaction_entries e
+SET sequence = numbered.seq
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY account_id ORDER BY created_at, id) AS seq
+    FROM transaction_entries
+) numbered
+WHERE e.id = numbered.id AND COALESCE(e.sequence, 0) = 0;
+
+CREATE INDEX IF NOT EXISTS idx_transaction_entries_account_sequence ON transaction_entries (account_id, sequence);
+
+-- Seed the maintained balances from the full entry history
+INSERT INTO account_balances (
+    id, currency, balance, uncleared_balance, reserved_balance, last_entry_sequence,
+    created_at, modified_at, version, tenant_id, partition_id, access_id)
+SELECT
+    a.id,
+    a.currency,
+    COALESCE(SUM(CASE WHEN t.transaction_type IN ('NORMAL', 'REVERSAL') AND t.cleared_at IS NOT NULL AND t.cleared_at != '0001-01-01 00:00:00' THEN e.amount ELSE 0 END), 0),
+    COALESCE(SUM(CASE WHEN t.transaction_type IN ('NORMAL', 'REVERSAL') AND (t.cleared_at IS NULL OR t.cleared_at = '0001-01-01 00:00:00') THEN e.amount ELSE 0 END), 0),
+    COALESCE(SUM(CASE WHEN t.transaction_type = 'RESERVATION' THEN e.amount ELSE 0 END), 0),
+    COALESCE(MAX(e.sequence), 0),
+    NOW(),
+    NOW(),
+    1,
+    a.tenant_id,
+    a.partition_id,
+    a.access_id
+FROM accounts a
+JOIN transaction_entries e ON e.account_id = a.id
+JOIN transactions t ON t.id = e.transaction_id AND t.currency = a.currency
+GROUP BY a.id, a.currency, a.tenant_id, a.partition_id, a.access_id
+ON CONFLICT (id) DO NOTHING;
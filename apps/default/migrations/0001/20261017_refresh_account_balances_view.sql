@@ -0,0 +1,62 @@
+-- Rebuild account_balances_view so it can be refreshed concurrently by the service scheduler.
+-- Transactions store an unset cleared_at as the zero timestamp, so treat it the same as NULL.
+DROP MATERIALIZED VIEW IF EXISTS account_balances_view;
+
+CREATE MATERIALIZED VIEW account_balances_view AS
+WITH balance_summary AS (
+    SELECT
+        e.account_id,
+        t.currency,
+        COALESCE(SUM(CASE WHEN t.transaction_type IN ('NORMAL', 'REVERSAL') AND t.cleared_at IS NOT NULL AND t.cleared_at != '0001-01-01 00:00:00' THEN e.amount ELSE 0 END), 0) AS balance,
+        COALESCE(SUM(CASE WHEN t.transaction_type IN ('NORMAL', 'REVERSAL') AND (t.cleared_at IS NULL OR t.cleared_at = '0001-01-01 00:00:00') THEN e.amount ELSE 0 END), 0) AS uncleared_balance,
+        COALESCE(SUM(CASE WHEN t.transaction_type = 'RESERVATION' THEN e.amount ELSE 0 END), 0) AS reserved_balance
+    FROM transaction_entries e
+    JOIN transactions t ON e.transaction_id = t.id
+    GROUP BY e.account_id, t.currency
+)
+SELECT
+    a.id as account_id,
+    a.currency,
+    a.data,
+    COALESCE(bs.balance, 0) AS balance,
+    COALESCE(bs.uncleared_balance, 0) AS uncleared_balance,
+    COALESCE(bs.reserved_balance, 0) AS reserved_balance,
+    a.ledger_id,
+    a.ledger_type,
+    a.created_at,
+    a.modified_at,
+    a.version,
+    a.tenant_id,
+    a.partition_id,
+    a.access_id,
+    a.deleted_at
+FROM accounts a
+         LEFT JOIN balance_summary bs ON a.id = bs.account_id AND a.currency = bs.currency
+    WITH DATA;
+
+-- REFRESH ... CONCURRENTLY requires a unique index on the view.
+CREATE UNIQUE INDEX idx_account_balances_view_account_id ON account_balances_view (account_id);
+CREATE INDEX idx_account_balances_view_account_id_currency ON account_balances_view (account_id, currency);
+
+ALTER TABLE account_balances_view_refresh_log ALTER COLUMN last_refresh TYPE TIMESTAMPTZ;
+
+DROP FUNCTION IF EXISTS refresh_account_balances_view();
+
+-- Refreshes the view and logs when it happened. Several service instances may run the scheduler,
+-- so a refresh already in progress elsewhere is skipped and NULL returned.
+CREATE FUNCTION refresh_account_balances_view() RETURNS TIMESTAMPTZ AS $$
+DECLARE
+    refreshed_at TIMESTAMPTZ;
+BEGIN
+    IF NOT pg_try_advisory_xact_lock(hashtext('refresh_account_balances_view')) THEN
+        RETURN NULL;
+    END IF;
+
+    REFRESH MATERIALIZED VIEW CONCURRENTLY account_balances_view;
+
+    refreshed_at := clock_timestamp();
+    INSERT INTO account_balances_view_refresh_log (last_refresh) VALUES (refreshed_at);
+
+    RETURN refreshed_at;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,27 @@
+-- Open a hold for every reservation posted before holds were tracked, so it can be captured or released.
+-- Such holds keep their funds until released, they are never expired.
+INSERT INTO holds (
+    id, account_id, currency, amount, credit, captured, released, status, expires_at,
+    created_at, modified_at, version, tenant_id, partition_id, access_id)
+SELECT
+    t.id,
+    e.account_id,
+    t.currency,
+    ABS(e.amount),
+    e.credit,
+    0,
+    0,
+    'OPEN',
+    NULL,
+    NOW(),
+    NOW(),
+    1,
+    t.tenant_id,
+    t.partition_id,
+    t.access_id
+FROM transactions t
+JOIN transaction_entries e ON e.transaction_id = t.id
+WHERE t.transaction_type = 'RESERVATION'
+  AND COALESCE(t.hold_id, '') = ''
+  AND t.deleted_at IS NULL
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,9 @@
+-- Link every reversal posted before reversals were linked to the transaction it reverses, which named it
+-- <id>_REVERSAL, so that what remains reversible of that transaction counts it.
+UPDATE transactions r
+SET reversal_of = o.id
+FROM transactions o
+WHERE r.transaction_type = 'REVERSAL'
+  AND COALESCE(r.reversal_of, '') = ''
+  AND r.id = o.id || '_REVERSAL'
+  AND r.deleted_at IS NULL;
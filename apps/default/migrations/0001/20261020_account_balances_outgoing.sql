@@ -0,0 +1,16 @@
+-- Seed the outgoing parts of the maintained uncleared and reserved balances from the entry history: pending
+-- entries taking funds off an account, reservations taking funds off it and the hold entries unwinding those.
+UPDATE account_balances ab
+SET uncleared_outgoing = c.uncleared_outgoing,
+    reserved_outgoing = c.reserved_outgoing
+FROM (
+    SELECT
+        e.account_id,
+        COALESCE(SUM(CASE WHEN t.transaction_type IN ('NORMAL', 'REVERSAL') AND (t.cleared_at IS NULL OR t.cleared_at = '0001-01-01 00:00:00') AND e.amount < 0 THEN e.amount ELSE 0 END), 0) AS uncleared_outgoing,
+        COALESCE(SUM(CASE WHEN t.transaction_type = 'RESERVATION' AND (e.amount < 0) = (COALESCE(t.hold_id, '') = '') THEN e.amount ELSE 0 END), 0) AS reserved_outgoing
+    FROM transaction_entries e
+    JOIN transactions t ON t.id = e.transaction_id
+    JOIN accounts a ON a.id = e.account_id AND a.currency = t.currency
+    GROUP BY e.account_id
+) c
+WHERE c.account_id = ab.id;
@@ -2,12 +2,15 @@ package business
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	commonv1 "buf.build/gen/go/antinvestor/common/protocolbuffers/go/common/v1"
 	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
 	"github.com/antinvestor/service-ledger/apps/default/service/models"
 	"github.com/antinvestor/service-ledger/apps/default/service/repository"
 	"github.com/pitabwire/frame/data"
+	"github.com/pitabwire/frame/security"
 	"github.com/pitabwire/frame/workerpool"
 	"github.com/shopspring/decimal"
 	"golang.org/x/text/currency"
@@ -20,7 +23,12 @@ type AccountBusiness interface {
 		consumer func(ctx context.Context, batch []*ledgerv1.Account) error) error
 	GetAccount(ctx context.Context, id string) (*ledgerv1.Account, error)
 	UpdateAccount(ctx context.Context, req *ledgerv1.UpdateAccountRequest) (*ledgerv1.Account, error)
-	DeleteAccount(ctx context.Context, id string) error
+	DeleteAccount(ctx context.Context, id string, reason string) error
+	UpdateAccountStatus(ctx context.Context, id, status, actor, reason string) (*ledgerv1.Account, error)
+	ListAccountStatusChanges(ctx context.Context, id string) ([]*models.AccountStatusChange, error)
+	SetBalanceFloor(ctx context.Context, id string, floor decimal.NullDecimal) (*ledgerv1.Account, error)
+	GetAccountBalanceAt(ctx context.Context, id string, at time.Time, byClearedAt bool) (*ledgerv1.Account, error)
+	GetAccountBalances(ctx context.Context, id string) (*models.Account, error)
 }
 
 // accountBusiness implements the AccountBusiness interface.
@@ -177,12 +185,197 @@ func (b *accountBusiness) UpdateAccount(
 	return existingAccount.ToAPI(), nil
 }
 
-// DeleteAccount deletes an account by ID.
-func (b *accountBusiness) DeleteAccount(_ context.Context, id string) error {
+// DeleteAccount closes an account by ID.
+// Closure is only allowed once the cleared, uncleared and reserved balances are all zero, as read
+// under the account's posting lock; the account is kept for history but no further entries can be posted to it.
+func (b *accountBusiness) DeleteAccount(ctx context.Context, id string, reason string) error {
 	if id == "" {
 		return ErrAccountIDRequired
 	}
 
-	// Delete through repository
-	return nil // Implementation depends on repository interface
+	if reason == "" {
+		return ErrAccountClosureReason
+	}
+
+	account, err := b.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if account == nil {
+		return ErrAccountNotFound
+	}
+
+	if account.IsClosed() {
+		return nil
+	}
+
+	return b.accountRepo.Close(ctx, id, reason, func(locked *models.Account) error {
+		if !locked.HasZeroBalances() {
+			return fmt.Errorf("%w: account %s balance=%s uncleared=%s reserved=%s", ErrAccountHasBalance, locked.ID,
+				locked.Balance.Decimal, locked.UnClearedBalance.Decimal, locked.ReservedBalance.Decimal)
+		}
+		return nil
+	})
+}
+
+// UpdateAccountStatus moves an account to a new status and records who made the change and why.
+// When no actor is supplied the profile of the authenticated caller is used.
+func (b *accountBusiness) UpdateAccountStatus(
+	ctx context.Context,
+	id, status, actor, reason string,
+) (*ledgerv1.Account, error) {
+	if id == "" {
+		return nil, ErrAccountIDRequired
+	}
+
+	if !models.IsValidAccountStatus(status) {
+		return nil, fmt.Errorf("%w: %s", ErrAccountStatusInvalid, status)
+	}
+
+	if reason == "" {
+		return nil, ErrAccountStatusReason
+	}
+
+	if actor == "" {
+		if claims := security.ClaimsFromContext(ctx); claims != nil {
+			actor = claims.GetProfileID()
+		}
+	}
+
+	if actor == "" {
+		return nil, ErrAccountStatusActor
+	}
+
+	account, err := b.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	if account.IsClosed() {
+		return nil, fmt.Errorf("%w: %s", ErrAccountIsClosed, account.ID)
+	}
+
+	if account.CurrentStatus() == status {
+		return account.ToAPI(), nil
+	}
+
+	change := &models.AccountStatusChange{
+		AccountID:  account.ID,
+		FromStatus: account.CurrentStatus(),
+		ToStatus:   status,
+		Actor:      actor,
+		Reason:     reason,
+	}
+	change.GenID(ctx)
+
+	account.Status = status
+	err = b.accountRepo.UpdateStatus(ctx, account, change)
+	if err != nil {
+		return nil, err
+	}
+
+	return account.ToAPI(), nil
+}
+
+// ListAccountStatusChanges returns the recorded status history of an account for review.
+func (b *accountBusiness) ListAccountStatusChanges(
+	ctx context.Context,
+	id string,
+) ([]*models.AccountStatusChange, error) {
+	if id == "" {
+		return nil, ErrAccountIDRequired
+	}
+
+	return b.accountRepo.ListStatusChanges(ctx, id)
+}
+
+// SetBalanceFloor sets the lowest balance the account may be debited to, e.g. zero for a wallet that may
+// never go negative or -500 for a 500 overdraft. An invalid floor falls back to the ledger's floor.
+func (b *accountBusiness) SetBalanceFloor(
+	ctx context.Context,
+	id string,
+	floor decimal.NullDecimal,
+) (*ledgerv1.Account, error) {
+	if id == "" {
+		return nil, ErrAccountIDRequired
+	}
+
+	account, err := b.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	account.BalanceFloor = floor
+	_, err = b.accountRepo.Update(ctx, account, "balance_floor", "modified_at", "version")
+	if err != nil {
+		return nil, err
+	}
+
+	return account.ToAPI(), nil
+}
+
+// GetAccountBalances returns the account with its current balances, from which its available balance follows.
+func (b *accountBusiness) GetAccountBalances(ctx context.Context, id string) (*models.Account, error) {
+	if id == "" {
+		return nil, ErrAccountIDRequired
+	}
+
+	account, err := b.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	return account, nil
+}
+
+// GetAccountBalanceAt returns the account with the balances it held at the instant at, computed from the
+// transactions transacted up to then. With byClearedAt only transactions cleared by at count towards the
+// cleared balance, otherwise their current clearing state is used.
+func (b *accountBusiness) GetAccountBalanceAt(
+	ctx context.Context,
+	id string,
+	at time.Time,
+	byClearedAt bool,
+) (*ledgerv1.Account, error) {
+	if id == "" {
+		return nil, ErrAccountIDRequired
+	}
+
+	account, err := b.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	balances, err := b.accountRepo.AggregateBalancesAt(ctx, at, byClearedAt, id)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, ok := balances[id]
+	if !ok {
+		balance = &models.AccountBalance{}
+	}
+
+	account.Balance = decimal.NewNullDecimal(balance.Balance)
+	account.UnClearedBalance = decimal.NewNullDecimal(balance.UnClearedBalance)
+	account.ReservedBalance = decimal.NewNullDecimal(balance.ReservedBalance)
+
+	return account.ToAPI(), nil
 }
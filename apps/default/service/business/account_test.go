@@ -3,13 +3,17 @@ package business_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
 	"github.com/antinvestor/service-ledger/apps/default/service/models"
 	"github.com/antinvestor/service-ledger/apps/default/tests"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
 	_ "github.com/lib/pq"
 	"github.com/pitabwire/frame/data"
 	"github.com/pitabwire/frame/frametests/definition"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -194,3 +198,138 @@ func (as *AccountBusinessSuite) TestUpdateAccount() {
 		assert.Equal(t, "Test category", updatedAccount.GetData().GetFields()["category"].GetStringValue())
 	})
 }
+
+func (as *AccountBusinessSuite) TestDeleteAccount() {
+	as.WithTestDependencies(as.T(), func(t *testing.T, dep *definition.DependencyOption) {
+		ctx, _, resources := as.CreateService(t, dep)
+		as.setupFixtures(ctx, resources)
+
+		accountBusiness := resources.AccountBusiness
+
+		for _, id := range []string{"closing-account", "counter-account"} {
+			_, err := accountBusiness.CreateAccount(ctx, &ledgerv1.CreateAccountRequest{
+				Id:       id,
+				LedgerId: as.ledger.ID,
+				Currency: "USD",
+			})
+			require.NoError(t, err, "Error creating account %s", id)
+		}
+
+		err := accountBusiness.DeleteAccount(ctx, "closing-account", "")
+		require.ErrorIs(t, err, business.ErrAccountClosureReason, "Closure should require a reason")
+
+		err = accountBusiness.DeleteAccount(ctx, "closing-account", "customer request")
+		require.NoError(t, err, "Error closing account")
+
+		closedAccount, err := resources.AccountRepository.GetByID(ctx, "closing-account")
+		require.NoError(t, err, "Closed account should still be retrievable")
+		assert.True(t, closedAccount.IsClosed(), "Account should be marked closed")
+		assert.Equal(t, "customer request", closedAccount.ClosedReason)
+
+		timeNow := time.Now().UTC()
+		_, err = resources.TransactionBusiness.Transact(ctx, &models.Transaction{
+			BaseModel:       data.BaseModel{ID: "closed-account-transaction"},
+			Currency:        "USD",
+			TransactionType: ledgerv1.TransactionType_NORMAL.String(),
+			TransactedAt:    timeNow,
+			ClearedAt:       timeNow,
+			Entries: []*models.TransactionEntry{
+				{AccountID: "closing-account", Amount: decimal.NewNullDecimal(decimal.NewFromInt(10))},
+				{AccountID: "counter-account", Amount: decimal.NewNullDecimal(decimal.NewFromInt(10)), Credit: true},
+			},
+		})
+		require.ErrorIs(t, err, apperrors.ErrAccountClosed, "Posting to a closed account should be rejected")
+	})
+}
+
+func (as *AccountBusinessSuite) TestDeleteAccountWithBalance() {
+	as.WithTestDependencies(as.T(), func(t *testing.T, dep *definition.DependencyOption) {
+		ctx, _, resources := as.CreateService(t, dep)
+		as.setupFixtures(ctx, resources)
+
+		accountBusiness := resources.AccountBusiness
+
+		for _, id := range []string{"funded-account", "funding-account"} {
+			_, err := accountBusiness.CreateAccount(ctx, &ledgerv1.CreateAccountRequest{
+				Id:       id,
+				LedgerId: as.ledger.ID,
+				Currency: "USD",
+			})
+			require.NoError(t, err, "Error creating account %s", id)
+		}
+
+		_, err := resources.TransactionBusiness.Transact(ctx, &models.Transaction{
+			BaseModel:       data.BaseModel{ID: "uncleared-funding"},
+			Currency:        "USD",
+			TransactionType: ledgerv1.TransactionType_NORMAL.String(),
+			Entries: []*models.TransactionEntry{
+				{AccountID: "funded-account", Amount: decimal.NewNullDecimal(decimal.NewFromInt(10))},
+				{AccountID: "funding-account", Amount: decimal.NewNullDecimal(decimal.NewFromInt(10)), Credit: true},
+			},
+		})
+		require.NoError(t, err, "Error funding account")
+
+		err = accountBusiness.DeleteAccount(ctx, "funded-account", "customer request")
+		require.ErrorIs(t, err, business.ErrAccountHasBalance, "Account with uncleared funds should not close")
+	})
+}
+
+func (as *AccountBusinessSuite) TestUpdateAccountStatus() {
+	as.WithTestDependencies(as.T(), func(t *testing.T, dep *definition.DependencyOption) {
+		ctx, _, resources := as.CreateService(t, dep)
+		as.setupFixtures(ctx, resources)
+
+		accountBusiness := resources.AccountBusiness
+
+		for _, id := range []string{"blocked-account", "other-account"} {
+			_, err := accountBusiness.CreateAccount(ctx, &ledgerv1.CreateAccountRequest{
+				Id:       id,
+				LedgerId: as.ledger.ID,
+				Currency: "USD",
+			})
+			require.NoError(t, err, "Error creating account %s", id)
+		}
+
+		_, err := accountBusiness.UpdateAccountStatus(ctx, "blocked-account", "SUSPENDED", "compliance", "fraud")
+		require.ErrorIs(t, err, business.ErrAccountStatusInvalid, "Unknown statuses should be rejected")
+
+		_, err = accountBusiness.UpdateAccountStatus(
+			ctx, "blocked-account", models.AccountStatusDebitBlocked, "compliance", "fraud investigation")
+		require.NoError(t, err, "Error blocking debits")
+
+		transfer := func(id string, debitAccount, creditAccount string) error {
+			_, txnErr := resources.TransactionBusiness.Transact(ctx, &models.Transaction{
+				BaseModel:       data.BaseModel{ID: id},
+				Currency:        "USD",
+				TransactionType: ledgerv1.TransactionType_NORMAL.String(),
+				Entries: []*models.TransactionEntry{
+					{AccountID: debitAccount, Amount: decimal.NewNullDecimal(decimal.NewFromInt(5))},
+					{AccountID: creditAccount, Amount: decimal.NewNullDecimal(decimal.NewFromInt(5)), Credit: true},
+				},
+			})
+			return txnErr
+		}
+
+		err = transfer("blocked-debit", "blocked-account", "other-account")
+		require.ErrorIs(t, err, apperrors.ErrAccountDebitBlocked, "Debit-blocked account should not be debited")
+
+		err = transfer("allowed-credit", "other-account", "blocked-account")
+		require.NoError(t, err, "Debit-blocked account should still accept credits")
+
+		_, err = accountBusiness.UpdateAccountStatus(
+			ctx, "blocked-account", models.AccountStatusFrozen, "compliance", "escalated")
+		require.NoError(t, err, "Error freezing account")
+
+		err = transfer("frozen-credit", "other-account", "blocked-account")
+		require.ErrorIs(t, err, apperrors.ErrAccountCreditBlocked, "Frozen account should not accept credits")
+
+		changes, err := accountBusiness.ListAccountStatusChanges(ctx, "blocked-account")
+		require.NoError(t, err, "Error listing status changes")
+		require.Len(t, changes, 2, "Both status changes should be recorded")
+		assert.Equal(t, models.AccountStatusActive, changes[0].FromStatus)
+		assert.Equal(t, models.AccountStatusDebitBlocked, changes[0].ToStatus)
+		assert.Equal(t, models.AccountStatusFrozen, changes[1].ToStatus)
+		assert.Equal(t, "compliance", changes[1].Actor)
+		assert.Equal(t, "escalated", changes[1].Reason)
+	})
+}
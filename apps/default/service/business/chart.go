@@ -0,0 +1,368 @@
+package business
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/apps/default/service/repository"
+	"github.com/pitabwire/frame/data"
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/currency"
+	"gopkg.in/yaml.v3"
+)
+
+// ChartTemplate declares a ledger tree with the system accounts every tenant or product starts with.
+// Templates are written in YAML or JSON, e.g.
+//
+//	name: wallet
+//	ledgers:
+//	  - id: assets
+//	    type: ASSET
+//	    children:
+//	      - id: settlement
+//	        type: ASSET
+//	        accounts:
+//	          - id: settlement-ugx
+//	            currency: UGX
+type ChartTemplate struct {
+	Name    string         `json:"name"    yaml:"name"`
+	Ledgers []*ChartLedger `json:"ledgers" yaml:"ledgers"`
+}
+
+// ChartLedger is a ledger of a chart template with its accounts and child ledgers.
+type ChartLedger struct {
+	ID       string          `json:"id"                 yaml:"id"`
+	Type     string          `json:"type"               yaml:"type"`
+	Data     map[string]any  `json:"data,omitempty"     yaml:"data,omitempty"`
+	Accounts []*ChartAccount `json:"accounts,omitempty" yaml:"accounts,omitempty"`
+	Children []*ChartLedger  `json:"children,omitempty" yaml:"children,omitempty"`
+}
+
+// ChartAccount is an account of a chart template, held in the ledger it is declared under.
+type ChartAccount struct {
+	ID       string         `json:"id"             yaml:"id"`
+	Currency string         `json:"currency"       yaml:"currency"`
+	Data     map[string]any `json:"data,omitempty" yaml:"data,omitempty"`
+}
+
+// Kinds of chart entries.
+const (
+	ChartKindLedger  = "LEDGER"
+	ChartKindAccount = "ACCOUNT"
+)
+
+// Chart change actions. Missing entries are created, existing ones that differ from the template are
+// reported and left untouched so that a template can never rewrite a live chart of accounts.
+const (
+	ChartActionCreate   = "CREATE"
+	ChartActionMismatch = "MISMATCH"
+)
+
+// ChartChange is an entry of the template that is missing or differs from what exists.
+type ChartChange struct {
+	Kind        string             `json:"kind"`
+	ID          string             `json:"id"`
+	Action      string             `json:"action"`
+	Differences []*ChartDifference `json:"differences,omitempty"`
+}
+
+// ChartDifference is a field whose existing value differs from the template.
+type ChartDifference struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// ChartResult lists the changes found while applying a template. Applied is false on a dry run,
+// in which case the entries marked CREATE were not created. A chart matching its template has no changes.
+type ChartResult struct {
+	Template string         `json:"template"`
+	Applied  bool           `json:"applied"`
+	Changes  []*ChartChange `json:"changes"`
+}
+
+// ParseChartTemplate reads a chart template from YAML or JSON content.
+func ParseChartTemplate(content []byte) (*ChartTemplate, error) {
+	template := new(ChartTemplate)
+	err := yaml.Unmarshal(content, template)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrChartTemplateInvalid, err)
+	}
+
+	return template, nil
+}
+
+// ChartBusiness provisions ledger trees and system accounts from chart templates.
+type ChartBusiness interface {
+	ApplyChartTemplate(ctx context.Context, template *ChartTemplate, prefix string, dryRun bool) (*ChartResult, error)
+}
+
+// chartBusiness implements the ChartBusiness interface.
+type chartBusiness struct {
+	ledgerRepo  repository.LedgerRepository
+	accountRepo repository.AccountRepository
+	typeRule    LedgerTypeRule
+}
+
+// NewChartBusiness creates a new chart business instance.
+// Template ledgers are held to the same type rule as ledgers created through the API.
+func NewChartBusiness(
+	ledgerRepo repository.LedgerRepository,
+	accountRepo repository.AccountRepository,
+	typeRule LedgerTypeRule,
+) ChartBusiness {
+	return &chartBusiness{
+		ledgerRepo:  ledgerRepo,
+		accountRepo: accountRepo,
+		typeRule:    typeRule,
+	}
+}
+
+// ApplyChartTemplate creates the ledgers and accounts of template that do not exist yet, parents before
+// their children, and reports those that exist with different values. Every id is prefixed with prefix
+// so one template can provision many tenants or products. Applying a template again changes nothing.
+func (b *chartBusiness) ApplyChartTemplate(
+	ctx context.Context,
+	template *ChartTemplate,
+	prefix string,
+	dryRun bool,
+) (*ChartResult, error) {
+	err := b.validateTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ChartResult{
+		Template: template.Name,
+		Applied:  !dryRun,
+	}
+
+	for _, ledger := range template.Ledgers {
+		err = b.applyLedger(ctx, result, ledger, nil, prefix, dryRun)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+func (b *chartBusiness) applyLedger(
+	ctx context.Context,
+	result *ChartResult,
+	ledger *ChartLedger,
+	parent *ChartLedger,
+	prefix string,
+	dryRun bool,
+) error {
+	expected := &models.Ledger{
+		Type: strings.ToUpper(ledger.Type),
+		Data: ledger.Data,
+	}
+	expected.ID = prefix + ledger.ID
+	if parent != nil {
+		expected.ParentID = prefix + parent.ID
+	}
+
+	// Accounts are held in the ledger as stored, whose type wins over the template's on a mismatch.
+	held := expected
+	existing, err := b.ledgerRepo.GetByID(ctx, expected.ID)
+	switch {
+	case err == nil:
+		held = existing
+		differences := chartDifferences(expected.Data, existing.Data,
+			&ChartDifference{Field: "type", Expected: expected.Type, Actual: existing.Type},
+			&ChartDifference{Field: "parent_id", Expected: expected.ParentID, Actual: existing.ParentID})
+		result.addChange(ChartKindLedger, expected.ID, differences)
+	case data.ErrorIsNoRows(err):
+		result.Changes = append(result.Changes,
+			&ChartChange{Kind: ChartKindLedger, ID: expected.ID, Action: ChartActionCreate})
+		if !dryRun {
+			err = b.createLedger(ctx, expected)
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return err
+	}
+
+	for _, account := range ledger.Accounts {
+		err = b.applyAccount(ctx, result, account, held, prefix, dryRun)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, child := range ledger.Children {
+		err = b.applyLedger(ctx, result, child, ledger, prefix, dryRun)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *chartBusiness) createLedger(ctx context.Context, expected *models.Ledger) error {
+	ledger := &models.Ledger{
+		Type:     expected.Type,
+		ParentID: expected.ParentID,
+		Data:     expected.Data,
+	}
+	ledger.GenID(ctx)
+	ledger.ID = expected.ID
+
+	return b.ledgerRepo.Create(ctx, ledger)
+}
+
+func (b *chartBusiness) applyAccount(
+	ctx context.Context,
+	result *ChartResult,
+	account *ChartAccount,
+	ledger *models.Ledger,
+	prefix string,
+	dryRun bool,
+) error {
+	accountID := prefix + account.ID
+	currencyUnit := currency.MustParseISO(account.Currency)
+
+	existing, err := b.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		differences := chartDifferences(account.Data, existing.Data,
+			&ChartDifference{Field: "ledger_id", Expected: ledger.ID, Actual: existing.LedgerID},
+			&ChartDifference{Field: "currency", Expected: currencyUnit.String(), Actual: existing.Currency})
+		result.addChange(ChartKindAccount, accountID, differences)
+		return nil
+	}
+
+	result.Changes = append(result.Changes,
+		&ChartChange{Kind: ChartKindAccount, ID: accountID, Action: ChartActionCreate})
+	if dryRun {
+		return nil
+	}
+
+	accountModel := &models.Account{
+		LedgerID:   ledger.ID,
+		LedgerType: ledger.Type,
+		Currency:   currencyUnit.String(),
+		Balance:    decimal.NewNullDecimal(decimal.Zero),
+		Data:       account.Data,
+	}
+	accountModel.GenID(ctx)
+	accountModel.ID = accountID
+
+	return b.accountRepo.Create(ctx, accountModel)
+}
+
+// validateTemplate rejects templates that could only be applied in part: missing or repeated ids,
+// unknown ledger types or currencies and child ledgers the type rule does not allow.
+func (b *chartBusiness) validateTemplate(template *ChartTemplate) error {
+	if template == nil || len(template.Ledgers) == 0 {
+		return fmt.Errorf("%w: no ledgers declared", ErrChartTemplateInvalid)
+	}
+
+	seen := map[string]bool{}
+	var validate func(ledger *ChartLedger, parentType string) error
+	validate = func(ledger *ChartLedger, parentType string) error {
+		ledgerType := strings.ToUpper(ledger.Type)
+		if ledger.ID == "" || seen[ChartKindLedger+ledger.ID] {
+			return fmt.Errorf("%w: ledger id %q is empty or repeated", ErrChartTemplateInvalid, ledger.ID)
+		}
+		seen[ChartKindLedger+ledger.ID] = true
+
+		if _, ok := ledgerv1.LedgerType_value[ledgerType]; !ok {
+			return fmt.Errorf("%w: ledger %s has unknown type %q", ErrChartTemplateInvalid, ledger.ID, ledger.Type)
+		}
+
+		if parentType != "" && !b.typeRule.Allows(parentType, ledgerType) {
+			return fmt.Errorf("%w: %s ledger %s is not allowed under a %s ledger",
+				ErrChartTemplateInvalid, ledgerType, ledger.ID, parentType)
+		}
+
+		for _, account := range ledger.Accounts {
+			if account.ID == "" || seen[ChartKindAccount+account.ID] {
+				return fmt.Errorf("%w: account id %q is empty or repeated", ErrChartTemplateInvalid, account.ID)
+			}
+			seen[ChartKindAccount+account.ID] = true
+
+			_, err := currency.ParseISO(account.Currency)
+			if err != nil {
+				return fmt.Errorf("%w: account %s has unknown currency %q",
+					ErrChartTemplateInvalid, account.ID, account.Currency)
+			}
+		}
+
+		for _, child := range ledger.Children {
+			err := validate(child, ledgerType)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for _, ledger := range template.Ledgers {
+		err := validate(ledger, "")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addChange records a mismatch for an existing entry when any of its differences are real.
+func (r *ChartResult) addChange(kind, id string, differences []*ChartDifference) {
+	if len(differences) == 0 {
+		return
+	}
+
+	r.Changes = append(r.Changes, &ChartChange{
+		Kind:        kind,
+		ID:          id,
+		Action:      ChartActionMismatch,
+		Differences: differences,
+	})
+}
+
+// chartDifferences returns the fields whose values differ, including the template data keys whose
+// existing value is missing or different. Data keys only set on the existing entry are ignored.
+func chartDifferences(expectedData, actualData map[string]any, fields ...*ChartDifference) []*ChartDifference {
+	var differences []*ChartDifference
+	for _, field := range fields {
+		if field.Expected != field.Actual {
+			differences = append(differences, field)
+		}
+	}
+
+	keys := make([]string, 0, len(expectedData))
+	for key := range expectedData {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		actual, ok := actualData[key]
+		if ok && fmt.Sprint(expectedData[key]) == fmt.Sprint(actual) {
+			continue
+		}
+
+		difference := &ChartDifference{Field: "data." + key, Expected: fmt.Sprint(expectedData[key])}
+		if ok {
+			difference.Actual = fmt.Sprint(actual)
+		}
+		differences = append(differences, difference)
+	}
+
+	return differences
+}
@@ -0,0 +1,96 @@
+package business_test
+
+import (
+	"testing"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/pitabwire/frame/frametests/definition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const walletChartTemplate = `
+name: wallet
+ledgers:
+  - id: assets
+    type: ASSET
+    children:
+      - id: settlement
+        type: ASSET
+        accounts:
+          - id: settlement-ugx
+            currency: UGX
+  - id: income
+    type: INCOME
+    data:
+      name: Income
+    accounts:
+      - id: fees-income-ugx
+        currency: ugx
+`
+
+func (ls *LedgerBusinessSuite) TestApplyChartTemplate() {
+	ls.WithTestDependencies(ls.T(), func(t *testing.T, dep *definition.DependencyOption) {
+		ctx, _, resources := ls.CreateService(t, dep)
+
+		chartBusiness := resources.ChartBusiness
+
+		template, err := business.ParseChartTemplate([]byte(walletChartTemplate))
+		require.NoError(t, err)
+
+		result, err := chartBusiness.ApplyChartTemplate(ctx, template, "tenant-a-", true)
+		require.NoError(t, err)
+		assert.False(t, result.Applied)
+		require.Len(t, result.Changes, 5)
+		for _, change := range result.Changes {
+			assert.Equal(t, business.ChartActionCreate, change.Action)
+		}
+
+		_, err = resources.LedgerBusiness.GetLedger(ctx, "tenant-a-assets")
+		require.Error(t, err, "A dry run should not create ledgers")
+
+		result, err = chartBusiness.ApplyChartTemplate(ctx, template, "tenant-a-", false)
+		require.NoError(t, err)
+		assert.True(t, result.Applied)
+		assert.Len(t, result.Changes, 5)
+
+		settlement, err := resources.LedgerBusiness.GetLedger(ctx, "tenant-a-settlement")
+		require.NoError(t, err)
+		assert.Equal(t, "tenant-a-assets", settlement.GetParent())
+
+		account, err := resources.AccountBusiness.GetAccount(ctx, "tenant-a-fees-income-ugx")
+		require.NoError(t, err)
+		assert.Equal(t, "tenant-a-income", account.GetLedger())
+		assert.Equal(t, "UGX", account.GetBalance().GetCurrencyCode())
+
+		result, err = chartBusiness.ApplyChartTemplate(ctx, template, "tenant-a-", false)
+		require.NoError(t, err)
+		assert.Empty(t, result.Changes, "Applying a template twice should change nothing")
+
+		template.Ledgers[1].Accounts[0].Currency = "USD"
+		template.Ledgers[1].Data["name"] = "Fee income"
+		result, err = chartBusiness.ApplyChartTemplate(ctx, template, "tenant-a-", true)
+		require.NoError(t, err)
+		require.Len(t, result.Changes, 2)
+		assert.Equal(t, business.ChartActionMismatch, result.Changes[0].Action)
+		assert.Equal(t, "tenant-a-income", result.Changes[0].ID)
+		assert.Equal(t, "data.name", result.Changes[0].Differences[0].Field)
+		assert.Equal(t, "tenant-a-fees-income-ugx", result.Changes[1].ID)
+		assert.Equal(t, &business.ChartDifference{Field: "currency", Expected: "USD", Actual: "UGX"},
+			result.Changes[1].Differences[0])
+
+		template.Ledgers[1].Type = "EXPENSE"
+		template.Ledgers[1].Accounts = append(template.Ledgers[1].Accounts,
+			&business.ChartAccount{ID: "fees-income-kes", Currency: "KES"})
+		_, err = chartBusiness.ApplyChartTemplate(ctx, template, "tenant-a-", false)
+		require.NoError(t, err)
+
+		added, err := resources.AccountRepository.GetByID(ctx, "tenant-a-fees-income-kes")
+		require.NoError(t, err)
+		assert.Equal(t, "INCOME", added.LedgerType, "New accounts should take the type of the stored ledger")
+
+		template.Ledgers[0].Children[0].Type = "INCOME"
+		_, err = chartBusiness.ApplyChartTemplate(ctx, template, "tenant-b-", false)
+		require.ErrorIs(t, err, business.ErrChartTemplateInvalid, "Templates must follow the ledger type rule")
+	})
+}
@@ -8,6 +8,9 @@ var (
 	ErrLedgerReferenceRequired = errors.New("ledger reference is required")
 	ErrLedgerIDRequired        = errors.New("ledger ID is required")
 	ErrInvalidLedgerType       = errors.New("invalid ledger type returned from repository")
+	ErrLedgerHasChildren       = errors.New("ledger has child ledgers")
+	ErrLedgerHasActiveAccounts = errors.New("ledger has accounts with non-zero balances")
+	ErrFundsPolicyInvalid      = errors.New("ledger funds policy is invalid")
 
 	// Account errors.
 	ErrAccountReferenceRequired = errors.New("account reference is required")
@@ -16,6 +19,12 @@ var (
 	ErrAccountCurrencyInvalid   = errors.New("account currency is invalid")
 	ErrAccountNotFound          = errors.New("account not found")
 	ErrInvalidAccountType       = errors.New("invalid account type returned from repository")
+	ErrAccountHasBalance        = errors.New("account has non-zero balances")
+	ErrAccountClosureReason     = errors.New("account closure reason is required")
+	ErrAccountStatusInvalid     = errors.New("account status is invalid")
+	ErrAccountStatusReason      = errors.New("account status change reason is required")
+	ErrAccountStatusActor       = errors.New("account status change actor is required")
+	ErrAccountIsClosed          = errors.New("account is closed")
 
 	// Transaction errors.
 	ErrTransactionReferenceRequired      = errors.New("transaction reference is required")
@@ -29,6 +38,53 @@ var (
 	ErrTransactionAccountsDifferCurrency = errors.New("transaction accounts have different currencies")
 	ErrInvalidTransactionType            = errors.New("invalid transaction type returned from repository")
 
+	// Reversal errors.
+	ErrReversalInvalid = errors.New("reversal is invalid")
+
+	// Settlement errors.
+	ErrSettlementActionInvalid = errors.New("settlement action must be CLEAR or FAIL")
+	ErrSettlementEmpty         = errors.New("settlement needs transaction ids or a query")
+	ErrSettlementTooLarge      = errors.New("settlement batch is too large")
+
+	// Exchange errors.
+	ErrExchangeRateInvalid       = errors.New("exchange rate must be positive")
+	ErrExchangeCurrenciesInvalid = errors.New("exchange must sell one currency and buy another")
+	ErrExchangeRateMismatch      = errors.New("exchange amounts do not match the rate")
+	ErrExchangePositionMissing   = errors.New("no FX position account is configured for the currency")
+
+	// FX rate and revaluation errors.
+	ErrFXRateInvalid        = errors.New("exchange rate is invalid")
+	ErrFXRateImportInvalid  = errors.New("exchange rate import is invalid")
+	ErrFXRevaluationConfig  = errors.New("FX revaluation needs a base currency and gain and loss ledgers")
+	ErrFXRevaluationLedgers = errors.New("FX gain and loss ledgers must be INCOME and EXPENSE ledgers")
+
+	// Hold errors.
+	ErrHoldCaptureInvalid = errors.New("hold capture is invalid")
+
+	// Statement errors.
+	ErrStatementPeriodInvalid = errors.New("statement period must end after it starts")
+
+	// Report errors.
+	ErrReportCurrencyInvalid = errors.New("report currency is invalid")
+	ErrReportPeriodInvalid   = errors.New("report period is invalid")
+
+	// Posting template errors.
+	ErrPostingTemplateInvalid  = errors.New("posting template is invalid")
+	ErrPostingTemplateNotFound = errors.New("posting template not found")
+	ErrPostingTemplateParams   = errors.New("posting template parameters are invalid")
+
+	// Chart of accounts errors.
+	ErrChartTemplateInvalid = errors.New("chart of accounts template is invalid")
+
+	// Accounting period errors.
+	ErrPeriodNameInvalid = errors.New("accounting period name is invalid")
+	ErrPeriodNotEnded    = errors.New("accounting period has not ended")
+	ErrPeriodForbidden   = errors.New("closing accounting periods or years needs the period admin role")
+
+	// Year-end close errors.
+	ErrYearEndCurrencyInvalid = errors.New("year-end close currency is invalid")
+	ErrYearEndRetainedInvalid = errors.New("retained earnings account must be a CAPITAL account in the close currency")
+
 	// General errors.
 	ErrInvalidSearchResult = errors.New("invalid search result type from repository")
 )
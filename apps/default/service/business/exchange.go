@@ -0,0 +1,170 @@
+package business
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/pitabwire/frame/data"
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/currency"
+)
+
+// ExchangeRequest converts an amount of SourceCurrency into another currency at Rate. Entries may be held in
+// either currency; the entries of each currency need not balance on their own, since the ledger balances
+// each currency against its FX position account. Entry amounts are positive, as for Transact.
+type ExchangeRequest struct {
+	ID             string
+	SourceCurrency string
+	Rate           decimal.Decimal
+	RateSource     string
+	TransactedAt   time.Time
+	ClearedAt      time.Time
+	Data           data.JSONMap
+	Entries        []*models.TransactionEntry
+}
+
+// exchangeLegID names the leg of an exchange that is posted in a currency, e.g. fx-42_USD.
+func exchangeLegID(exchangeID, currencyCode string) string {
+	return exchangeID + "_" + currencyCode
+}
+
+// Exchange posts a currency conversion as one NORMAL transaction per currency, source leg first, each balanced
+// through the FX position account of its currency. The target amount must equal the source amount at the
+// rate, rounded to the target currency's minor unit. Both legs are posted together or not at all, and
+// repeating an exchange returns the legs already posted.
+func (b *transactionBusiness) Exchange(ctx context.Context, request *ExchangeRequest) ([]*models.Transaction, error) {
+	if request.ID == "" {
+		return nil, ErrTransactionIDRequired
+	}
+
+	if !request.Rate.IsPositive() {
+		return nil, fmt.Errorf("%w: %s", ErrExchangeRateInvalid, request.Rate)
+	}
+
+	if request.TransactedAt.IsZero() {
+		request.TransactedAt = time.Now()
+	}
+
+	legs, err := b.exchangeLegs(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.TransactLinked(ctx, legs)
+}
+
+// exchangeLegs splits the entries of an exchange by currency and adds to each currency the position
+// entry that balances it, after checking the two amounts agree with the rate.
+func (b *transactionBusiness) exchangeLegs(
+	ctx context.Context,
+	request *ExchangeRequest,
+) ([]*models.Transaction, error) {
+	accountIDs := make([]string, 0, len(request.Entries))
+	for _, entry := range request.Entries {
+		accountIDs = append(accountIDs, entry.AccountID)
+	}
+
+	accounts, err := b.accountRepo.ListByID(ctx, accountIDs...)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceCurrency := strings.ToUpper(request.SourceCurrency)
+	currencies := []string{sourceCurrency}
+	groups := map[string][]*models.TransactionEntry{}
+	nets := map[string]decimal.Decimal{}
+	for _, entry := range request.Entries {
+		account, ok := accounts[entry.AccountID]
+		if !ok {
+			return nil, apperrors.ErrAccountNotFound.Extend(
+				fmt.Sprintf("Account %s was not found in the system", entry.AccountID),
+			)
+		}
+
+		if _, seen := groups[account.Currency]; !seen && account.Currency != sourceCurrency {
+			currencies = append(currencies, account.Currency)
+		}
+		groups[account.Currency] = append(groups[account.Currency], entry)
+
+		if entry.Credit {
+			nets[account.Currency] = nets[account.Currency].Sub(entry.Amount.Decimal)
+		} else {
+			nets[account.Currency] = nets[account.Currency].Add(entry.Amount.Decimal)
+		}
+	}
+
+	if len(currencies) != 2 || len(groups[sourceCurrency]) == 0 ||
+		nets[currencies[0]].Sign()*nets[currencies[1]].Sign() >= 0 {
+		return nil, fmt.Errorf("%w: source %s, currencies %v",
+			ErrExchangeCurrenciesInvalid, sourceCurrency, currencies)
+	}
+
+	details := models.Exchange{
+		ID:             request.ID,
+		Rate:           decimal.NewNullDecimal(request.Rate),
+		RateSource:     request.RateSource,
+		SourceCurrency: sourceCurrency,
+		SourceAmount:   decimal.NewNullDecimal(nets[sourceCurrency].Abs()),
+		TargetCurrency: currencies[1],
+		TargetAmount:   decimal.NewNullDecimal(nets[currencies[1]].Abs()),
+	}
+
+	err = checkExchangeRate(details)
+	if err != nil {
+		return nil, err
+	}
+
+	legs := make([]*models.Transaction, 0, len(currencies))
+	for _, currencyCode := range currencies {
+		positionAccountID, ok := b.fxPositions[currencyCode]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrExchangePositionMissing, currencyCode)
+		}
+
+		net := nets[currencyCode]
+		entries := make([]*models.TransactionEntry, 0, len(groups[currencyCode])+1)
+		entries = append(entries, groups[currencyCode]...)
+		entries = append(entries, &models.TransactionEntry{
+			AccountID: positionAccountID,
+			Amount:    decimal.NewNullDecimal(net.Abs()),
+			Credit:    net.IsPositive(),
+		})
+
+		legs = append(legs, &models.Transaction{
+			BaseModel:       data.BaseModel{ID: exchangeLegID(request.ID, currencyCode)},
+			Currency:        currencyCode,
+			TransactionType: ledgerv1.TransactionType_NORMAL.String(),
+			TransactedAt:    request.TransactedAt,
+			ClearedAt:       request.ClearedAt,
+			Data:            request.Data,
+			Exchange:        details,
+			Entries:         entries,
+		})
+	}
+
+	return legs, nil
+}
+
+// checkExchangeRate requires the target amount to be the source amount at the rate, rounded to the
+// minor unit of the target currency.
+func checkExchangeRate(details models.Exchange) error {
+	targetUnit, err := currency.ParseISO(details.TargetCurrency)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrExchangeCurrenciesInvalid, details.TargetCurrency)
+	}
+
+	scale, _ := currency.Standard.Rounding(targetUnit)
+	expected := details.SourceAmount.Decimal.Mul(details.Rate.Decimal).Round(int32(scale))
+	if !expected.Equal(details.TargetAmount.Decimal) {
+		return fmt.Errorf("%w: %s %s at %s is %s %s, not %s", ErrExchangeRateMismatch,
+			details.SourceAmount.Decimal, details.SourceCurrency, details.Rate.Decimal,
+			expected, details.TargetCurrency, details.TargetAmount.Decimal)
+	}
+
+	return nil
+}
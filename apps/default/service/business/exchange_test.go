@@ -0,0 +1,101 @@
+package business_test
+
+import (
+	"testing"
+
+	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/pitabwire/frame/frametests/definition"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exchangeRequest(id, rate string, entries ...*models.TransactionEntry) *business.ExchangeRequest {
+	return &business.ExchangeRequest{
+		ID:             id,
+		SourceCurrency: "UGX",
+		Rate:           decimal.RequireFromString(rate),
+		RateSource:     "test-desk",
+		Entries:        entries,
+	}
+}
+
+func exchangeEntry(accountID, amount string, credit bool) *models.TransactionEntry {
+	return &models.TransactionEntry{
+		AccountID: accountID,
+		Amount:    decimal.NewNullDecimal(decimal.RequireFromString(amount)),
+		Credit:    credit,
+	}
+}
+
+func (ts *TransactionsModelSuite) TestExchange() {
+	ts.T().Setenv("FX_POSITION_ACCOUNTS", "UGX:fx-ugx,USD:fx-usd")
+
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, depOpt *definition.DependencyOption) {
+		ctx, _, res := ts.CreateService(t, depOpt)
+		ts.setupFixtures(ctx, res)
+
+		_, err := res.LedgerBusiness.CreateLedger(ctx, &ledgerv1.CreateLedgerRequest{
+			Id:   "test-ledger-wallets",
+			Type: ledgerv1.LedgerType_LIABILITY,
+		})
+		require.NoError(t, err)
+
+		for _, account := range []struct{ id, ledger, currency string }{
+			{id: "w-ugx", ledger: "test-ledger-wallets", currency: "UGX"},
+			{id: "w-usd", ledger: "test-ledger-wallets", currency: "USD"},
+			{id: "fx-ugx", ledger: ts.ledger.ID, currency: "UGX"},
+			{id: "fx-usd", ledger: ts.ledger.ID, currency: "USD"},
+		} {
+			_, err = res.AccountBusiness.CreateAccount(ctx, &ledgerv1.CreateAccountRequest{
+				Id:       account.id,
+				LedgerId: account.ledger,
+				Currency: account.currency,
+			})
+			require.NoError(t, err)
+		}
+
+		_, err = res.TransactionBusiness.Transact(ctx, transfer("fx-funding", "a1", "w-ugx", 10000))
+		require.NoError(t, err)
+
+		request := exchangeRequest("fx-1", "0.00027027",
+			exchangeEntry("w-ugx", "3700", false), exchangeEntry("w-usd", "1.00", true))
+		legs, err := res.TransactionBusiness.Exchange(ctx, request)
+		require.NoError(t, err)
+		require.Len(t, legs, 2)
+		assert.Equal(t, "fx-1_UGX", legs[0].GetID())
+		assert.Equal(t, "fx-1_USD", legs[1].GetID())
+		assert.Equal(t, "test-desk", legs[1].Exchange.RateSource)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(3700)),
+			utility.CleanDecimal(legs[1].Exchange.SourceAmount.Decimal))
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(1)),
+			utility.CleanDecimal(legs[1].Exchange.TargetAmount.Decimal))
+
+		accounts, err := res.AccountRepository.ListByID(ctx, "w-ugx", "w-usd", "fx-ugx", "fx-usd")
+		require.NoError(t, err)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(6300)),
+			utility.CleanDecimal(accounts["w-ugx"].Balance.Decimal))
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(1)),
+			utility.CleanDecimal(accounts["w-usd"].Balance.Decimal))
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(-3700)),
+			utility.CleanDecimal(accounts["fx-ugx"].Balance.Decimal), "UGX bought sits in the UGX position")
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(1)),
+			utility.CleanDecimal(accounts["fx-usd"].Balance.Decimal), "USD sold is drawn from the USD position")
+
+		repeated, err := res.TransactionBusiness.Exchange(ctx, exchangeRequest("fx-1", "0.00027027",
+			exchangeEntry("w-ugx", "3700", false), exchangeEntry("w-usd", "1.00", true)))
+		require.NoError(t, err, "Repeating an exchange should be idempotent")
+		require.Len(t, repeated, 2)
+
+		_, err = res.TransactionBusiness.Exchange(ctx, exchangeRequest("fx-2", "0.0003",
+			exchangeEntry("w-ugx", "3700", false), exchangeEntry("w-usd", "1.00", true)))
+		require.ErrorIs(t, err, business.ErrExchangeRateMismatch)
+
+		_, err = res.TransactionBusiness.Exchange(ctx, exchangeRequest("fx-3", "1",
+			exchangeEntry("w-ugx", "100", false), exchangeEntry("a1", "100", true)))
+		require.ErrorIs(t, err, business.ErrExchangeCurrenciesInvalid)
+	})
+}
@@ -0,0 +1,246 @@
+package business
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Formula node kinds.
+const (
+	formulaNumber = iota
+	formulaVariable
+	formulaBinary
+	formulaNegate
+	formulaPercent
+	formulaCall
+)
+
+// formulaFunctions are the functions a formula may call, with the number of arguments they take.
+var formulaFunctions = map[string]int{"min": 2, "max": 2}
+
+var errFormulaDivisionByZero = errors.New("division by zero")
+
+// formulaNode is a parsed amount formula. Formulas are decimal arithmetic over named values with
+// + - * / and parentheses, a % suffix taking a percentage, e.g. "amount * 1.5%", and min and max.
+type formulaNode struct {
+	kind  int
+	op    byte
+	value decimal.Decimal
+	name  string
+	args  []*formulaNode
+}
+
+// parseFormula parses an amount formula.
+func parseFormula(input string) (*formulaNode, error) {
+	parser := &formulaParser{input: input}
+	node, err := parser.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	parser.skipSpace()
+	if parser.pos < len(parser.input) {
+		return nil, fmt.Errorf("unexpected %q in %q", parser.input[parser.pos:], input)
+	}
+
+	return node, nil
+}
+
+// eval works the formula out, looking the values it names up with lookup.
+func (n *formulaNode) eval(lookup func(name string) (decimal.Decimal, error)) (decimal.Decimal, error) {
+	switch n.kind {
+	case formulaNumber:
+		return n.value, nil
+	case formulaVariable:
+		return lookup(n.name)
+	}
+
+	args := make([]decimal.Decimal, 0, len(n.args))
+	for _, arg := range n.args {
+		value, err := arg.eval(lookup)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		args = append(args, value)
+	}
+
+	switch n.kind {
+	case formulaNegate:
+		return args[0].Neg(), nil
+	case formulaPercent:
+		return args[0].Div(decimal.NewFromInt(100)), nil
+	case formulaCall:
+		if n.name == "min" {
+			return decimal.Min(args[0], args[1]), nil
+		}
+		return decimal.Max(args[0], args[1]), nil
+	}
+
+	switch n.op {
+	case '+':
+		return args[0].Add(args[1]), nil
+	case '-':
+		return args[0].Sub(args[1]), nil
+	case '*':
+		return args[0].Mul(args[1]), nil
+	default:
+		if args[1].IsZero() {
+			return decimal.Zero, errFormulaDivisionByZero
+		}
+		return args[0].Div(args[1]), nil
+	}
+}
+
+// formulaParser parses formulas by recursive descent, one precedence level per method.
+type formulaParser struct {
+	input string
+	pos   int
+}
+
+func (p *formulaParser) expression() (*formulaNode, error) {
+	return p.binary(p.term, '+', '-')
+}
+
+func (p *formulaParser) term() (*formulaNode, error) {
+	return p.binary(p.unary, '*', '/')
+}
+
+func (p *formulaParser) binary(operand func() (*formulaNode, error), ops ...byte) (*formulaNode, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return left, nil
+		}
+
+		right, rightErr := operand()
+		if rightErr != nil {
+			return nil, rightErr
+		}
+		left = &formulaNode{kind: formulaBinary, op: op, args: []*formulaNode{left, right}}
+	}
+}
+
+func (p *formulaParser) unary() (*formulaNode, error) {
+	if _, ok := p.accept('-'); ok {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &formulaNode{kind: formulaNegate, args: []*formulaNode{operand}}, nil
+	}
+
+	operand, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := p.accept('%'); ok {
+		return &formulaNode{kind: formulaPercent, args: []*formulaNode{operand}}, nil
+	}
+	return operand, nil
+}
+
+func (p *formulaParser) primary() (*formulaNode, error) {
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return nil, fmt.Errorf("unexpected end of %q", p.input)
+	}
+
+	if _, ok := p.accept('('); ok {
+		node, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		if _, closed := p.accept(')'); !closed {
+			return nil, fmt.Errorf("missing ) in %q", p.input)
+		}
+		return node, nil
+	}
+
+	start := p.pos
+	switch char := p.input[p.pos]; {
+	case isFormulaDigit(char):
+		for p.pos < len(p.input) && (isFormulaDigit(p.input[p.pos]) || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		value, err := decimal.NewFromString(p.input[start:p.pos])
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q in %q", p.input[start:p.pos], p.input)
+		}
+		return &formulaNode{kind: formulaNumber, value: value}, nil
+
+	case isFormulaLetter(char):
+		for p.pos < len(p.input) && (isFormulaLetter(p.input[p.pos]) || isFormulaDigit(p.input[p.pos])) {
+			p.pos++
+		}
+		name := p.input[start:p.pos]
+		if _, ok := p.accept('('); !ok {
+			return &formulaNode{kind: formulaVariable, name: name}, nil
+		}
+		return p.call(strings.ToLower(name))
+
+	default:
+		return nil, fmt.Errorf("unexpected %q in %q", p.input[p.pos:], p.input)
+	}
+}
+
+func (p *formulaParser) call(name string) (*formulaNode, error) {
+	arity, ok := formulaFunctions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s in %q", name, p.input)
+	}
+
+	node := &formulaNode{kind: formulaCall, name: name}
+	for {
+		arg, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		node.args = append(node.args, arg)
+
+		if _, more := p.accept(','); !more {
+			break
+		}
+	}
+
+	if _, closed := p.accept(')'); !closed {
+		return nil, fmt.Errorf("missing ) in %q", p.input)
+	}
+	if len(node.args) != arity {
+		return nil, fmt.Errorf("%s takes %d arguments in %q", name, arity, p.input)
+	}
+
+	return node, nil
+}
+
+// accept consumes the next character when it is one of chars.
+func (p *formulaParser) accept(chars ...byte) (byte, bool) {
+	p.skipSpace()
+	if p.pos < len(p.input) && strings.IndexByte(string(chars), p.input[p.pos]) >= 0 {
+		p.pos++
+		return p.input[p.pos-1], true
+	}
+	return 0, false
+}
+
+func (p *formulaParser) skipSpace() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func isFormulaDigit(char byte) bool {
+	return char >= '0' && char <= '9'
+}
+
+func isFormulaLetter(char byte) bool {
+	return char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char == '_'
+}
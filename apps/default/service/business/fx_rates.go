@@ -0,0 +1,200 @@
+package business
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/apps/default/service/repository"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/pitabwire/frame/data"
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/currency"
+)
+
+// FX rate CSV columns. The header row names the columns, in any order; source is optional.
+const (
+	FXRateColumnBase        = "base_currency"
+	FXRateColumnQuote       = "quote_currency"
+	FXRateColumnRate        = "rate"
+	FXRateColumnEffectiveAt = "effective_at"
+	FXRateColumnSource      = "source"
+)
+
+// fxInverseRatePrecision is the number of decimal places kept when a rate is derived from its inverse pair.
+const fxInverseRatePrecision = 12
+
+// FXRateBusiness stores dated exchange rates and converts amounts with them.
+type FXRateBusiness interface {
+	SaveRates(ctx context.Context, rates []*models.FXRate) error
+	ImportRatesCSV(ctx context.Context, reader io.Reader, source string) (int, error)
+	Rate(ctx context.Context, baseCurrency, quoteCurrency string, at time.Time) (decimal.Decimal, error)
+}
+
+// fxRateBusiness implements the FXRateBusiness interface.
+type fxRateBusiness struct {
+	fxRateRepo repository.FXRateRepository
+}
+
+// NewFXRateBusiness creates a new FX rate business instance.
+func NewFXRateBusiness(fxRateRepo repository.FXRateRepository) FXRateBusiness {
+	return &fxRateBusiness{
+		fxRateRepo: fxRateRepo,
+	}
+}
+
+// SaveRates validates and stores rates. A rate saved again for the same pair and instant replaces the earlier one.
+func (b *fxRateBusiness) SaveRates(ctx context.Context, rates []*models.FXRate) error {
+	for _, rate := range rates {
+		err := normaliseFXRate(rate)
+		if err != nil {
+			return err
+		}
+		rate.GenID(ctx)
+	}
+
+	return b.fxRateRepo.SaveRates(ctx, rates)
+}
+
+// ImportRatesCSV stores the rates of a CSV file with a header row, returning how many were imported.
+// Rows without a source column are recorded with source. The file is rejected as a whole on any invalid row.
+func (b *fxRateBusiness) ImportRatesCSV(ctx context.Context, reader io.Reader, source string) (int, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("%w: reading header: %v", ErrFXRateImportInvalid, err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{FXRateColumnBase, FXRateColumnQuote, FXRateColumnRate, FXRateColumnEffectiveAt} {
+		if _, ok := columns[required]; !ok {
+			return 0, fmt.Errorf("%w: missing column %s", ErrFXRateImportInvalid, required)
+		}
+	}
+
+	var rates []*models.FXRate
+	for line := 2; ; line++ {
+		record, readErr := csvReader.Read()
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			return 0, fmt.Errorf("%w: line %d: %v", ErrFXRateImportInvalid, line, readErr)
+		}
+
+		rate, parseErr := parseFXRateRecord(record, columns, source)
+		if parseErr != nil {
+			return 0, fmt.Errorf("%w: line %d: %v", ErrFXRateImportInvalid, line, parseErr)
+		}
+		rates = append(rates, rate)
+	}
+
+	err = b.SaveRates(ctx, rates)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(rates), nil
+}
+
+// Rate returns how much of quoteCurrency one unit of baseCurrency buys at the given instant. When only the
+// inverse pair is rated its reciprocal is used, and a currency always converts to itself at one.
+func (b *fxRateBusiness) Rate(
+	ctx context.Context,
+	baseCurrency, quoteCurrency string,
+	at time.Time,
+) (decimal.Decimal, error) {
+	baseCurrency, quoteCurrency = strings.ToUpper(baseCurrency), strings.ToUpper(quoteCurrency)
+	if baseCurrency == quoteCurrency {
+		return decimal.NewFromInt(1), nil
+	}
+
+	rate, err := b.fxRateRepo.RateAt(ctx, baseCurrency, quoteCurrency, at)
+	if err == nil {
+		return rate.Rate, nil
+	}
+	if !data.ErrorIsNoRows(err) {
+		return decimal.Zero, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	inverse, err := b.fxRateRepo.RateAt(ctx, quoteCurrency, baseCurrency, at)
+	if err == nil {
+		return decimal.NewFromInt(1).DivRound(inverse.Rate, fxInverseRatePrecision), nil
+	}
+	if !data.ErrorIsNoRows(err) {
+		return decimal.Zero, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return decimal.Zero, apperrors.ErrFXRateNotFound.Extend(
+		fmt.Sprintf("%s/%s at %s", baseCurrency, quoteCurrency, at.Format(time.RFC3339)))
+}
+
+func parseFXRateRecord(record []string, columns map[string]int, source string) (*models.FXRate, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	value, err := decimal.NewFromString(field(FXRateColumnRate))
+	if err != nil {
+		return nil, fmt.Errorf("rate %q: %w", field(FXRateColumnRate), err)
+	}
+
+	effectiveAt, err := time.Parse(time.RFC3339, field(FXRateColumnEffectiveAt))
+	if err != nil {
+		return nil, fmt.Errorf("effective_at %q: %w", field(FXRateColumnEffectiveAt), err)
+	}
+
+	rate := &models.FXRate{
+		BaseCurrency:  field(FXRateColumnBase),
+		QuoteCurrency: field(FXRateColumnQuote),
+		EffectiveAt:   effectiveAt,
+		Rate:          value,
+		Source:        source,
+	}
+	if recordSource := field(FXRateColumnSource); recordSource != "" {
+		rate.Source = recordSource
+	}
+
+	return rate, nil
+}
+
+// normaliseFXRate checks a rate and stores its currencies as ISO codes and its instant in UTC.
+func normaliseFXRate(rate *models.FXRate) error {
+	for _, code := range []*string{&rate.BaseCurrency, &rate.QuoteCurrency} {
+		unit, err := currency.ParseISO(*code)
+		if err != nil {
+			return fmt.Errorf("%w: unknown currency %q", ErrFXRateInvalid, *code)
+		}
+		*code = unit.String()
+	}
+
+	if rate.BaseCurrency == rate.QuoteCurrency {
+		return fmt.Errorf("%w: %s is quoted against itself", ErrFXRateInvalid, rate.BaseCurrency)
+	}
+
+	if !rate.Rate.IsPositive() {
+		return fmt.Errorf("%w: %s/%s rate %s is not positive",
+			ErrFXRateInvalid, rate.BaseCurrency, rate.QuoteCurrency, rate.Rate)
+	}
+
+	if rate.EffectiveAt.IsZero() {
+		return fmt.Errorf("%w: %s/%s has no effective time", ErrFXRateInvalid, rate.BaseCurrency, rate.QuoteCurrency)
+	}
+	rate.EffectiveAt = rate.EffectiveAt.UTC()
+
+	return nil
+}
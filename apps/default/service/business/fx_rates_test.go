@@ -0,0 +1,225 @@
+package business_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/pitabwire/frame/frametests/definition"
+	"github.com/pitabwire/frame/security"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fxRate(base, quote, rate string, effectiveAt time.Time) *models.FXRate {
+	return &models.FXRate{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		EffectiveAt:   effectiveAt,
+		Rate:          decimal.RequireFromString(rate),
+	}
+}
+
+func (ts *TransactionsModelSuite) TestFXRates() {
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, depOpt *definition.DependencyOption) {
+		ctx, _, res := ts.CreateService(t, depOpt)
+
+		fxRates := res.FXRateBusiness
+		now := time.Now().UTC().Truncate(time.Second)
+
+		err := fxRates.SaveRates(ctx, []*models.FXRate{
+			fxRate("usd", "ugx", "3700", now.Add(-2*time.Hour)),
+			fxRate("USD", "UGX", "3750", now.Add(-time.Hour)),
+		})
+		require.NoError(t, err)
+
+		rate, err := fxRates.Rate(ctx, "USD", "UGX", now)
+		require.NoError(t, err)
+		assert.Equal(t, "3750", utility.CleanDecimal(rate).String(), "The latest rate in effect should apply")
+
+		rate, err = fxRates.Rate(ctx, "USD", "UGX", now.Add(-90*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, "3700", utility.CleanDecimal(rate).String())
+
+		rate, err = fxRates.Rate(ctx, "UGX", "USD", now)
+		require.NoError(t, err)
+		assert.Equal(t, "0.000266666667", rate.String(), "The inverse pair should be used when only it is rated")
+
+		_, err = fxRates.Rate(ctx, "USD", "UGX", now.Add(-3*time.Hour))
+		require.ErrorIs(t, err, apperrors.ErrFXRateNotFound)
+
+		csvRates := "effective_at,base_currency,quote_currency,rate\n" +
+			now.Add(-time.Hour).Format(time.RFC3339) + ",EUR,UGX,4100.5\n" +
+			now.Add(-time.Hour).Format(time.RFC3339) + ",USD,UGX,3760\n"
+		imported, err := fxRates.ImportRatesCSV(ctx, strings.NewReader(csvRates), "central-bank")
+		require.NoError(t, err)
+		assert.Equal(t, 2, imported)
+
+		rate, err = fxRates.Rate(ctx, "EUR", "UGX", now)
+		require.NoError(t, err)
+		assert.Equal(t, "4100.5", utility.CleanDecimal(rate).String())
+
+		rate, err = fxRates.Rate(ctx, "USD", "UGX", now)
+		require.NoError(t, err)
+		assert.Equal(t, "3760", utility.CleanDecimal(rate).String(), "Importing a rate again should replace it")
+
+		_, err = fxRates.ImportRatesCSV(ctx, strings.NewReader("base_currency,quote_currency,rate\nUSD,UGX,1\n"), "")
+		require.ErrorIs(t, err, business.ErrFXRateImportInvalid)
+
+		err = fxRates.SaveRates(ctx, []*models.FXRate{fxRate("USD", "UGX", "-1", now)})
+		require.ErrorIs(t, err, business.ErrFXRateInvalid)
+	})
+}
+
+func (ts *TransactionsModelSuite) TestRevaluation() {
+	ts.T().Setenv("FX_BASE_CURRENCY", "UGX")
+	ts.T().Setenv("FX_GAIN_LEDGER", "test-ledger-income")
+	ts.T().Setenv("FX_LOSS_LEDGER", "test-ledger-expense")
+
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, depOpt *definition.DependencyOption) {
+		ctx, _, res := ts.CreateService(t, depOpt)
+		ts.setupFixtures(ctx, res)
+
+		for _, ledger := range []struct {
+			id         string
+			ledgerType ledgerv1.LedgerType
+		}{
+			{id: "test-ledger-expense", ledgerType: ledgerv1.LedgerType_EXPENSE},
+			{id: "test-ledger-capital", ledgerType: ledgerv1.LedgerType_CAPITAL},
+		} {
+			_, err := res.LedgerBusiness.CreateLedger(ctx, &ledgerv1.CreateLedgerRequest{
+				Id:   ledger.id,
+				Type: ledger.ledgerType,
+			})
+			require.NoError(t, err)
+		}
+
+		// The loan is held by a tenant, whose revaluation postings should stay with it although the job runs
+		// without claims.
+		tenantCtx := (&security.AuthenticationClaims{TenantID: "fx-tenant", PartitionID: "fx-partition"}).
+			ClaimsToContext(ctx)
+		_, err := res.LedgerBusiness.CreateLedger(tenantCtx, &ledgerv1.CreateLedgerRequest{
+			Id:   "test-ledger-liability",
+			Type: ledgerv1.LedgerType_LIABILITY,
+		})
+		require.NoError(t, err)
+
+		for _, account := range []struct{ id, ledger string }{
+			{id: "usd-bank", ledger: ts.ledger.ID},
+			{id: "usd-capital", ledger: "test-ledger-capital"},
+			{id: "usd-loan", ledger: "test-ledger-liability"},
+		} {
+			_, err := res.AccountBusiness.CreateAccount(ctx, &ledgerv1.CreateAccountRequest{
+				Id:       account.id,
+				LedgerId: account.ledger,
+				Currency: "USD",
+			})
+			require.NoError(t, err)
+		}
+
+		// The rate rising makes a gain on the bank account and a loss on the loan, in different ledgers.
+		for _, funding := range []*models.Transaction{
+			transfer("usd-funding", "usd-bank", "usd-capital", 100),
+			transfer("usd-borrowing", "usd-bank", "usd-loan", 50),
+		} {
+			funding.Currency = "USD"
+			_, err := res.TransactionBusiness.Transact(ctx, funding)
+			require.NoError(t, err)
+		}
+
+		now := time.Now().UTC()
+		err = res.FXRateBusiness.SaveRates(ctx, []*models.FXRate{
+			fxRate("USD", "UGX", "3700", now.Add(-time.Hour)),
+			fxRate("USD", "UGX", "3800", now.Add(time.Hour)),
+		})
+		require.NoError(t, err)
+
+		baseline, err := res.RevaluationBusiness.Revalue(ctx, now)
+		require.NoError(t, err)
+		assert.Empty(t, baseline.Transactions, "The first revaluation should only record the carrying value")
+		assert.Empty(t, baseline.Lines)
+
+		revaluedAt := now.Add(2 * time.Hour)
+		result, err := res.RevaluationBusiness.Revalue(ctx, revaluedAt)
+		require.NoError(t, err)
+		require.Len(t, result.Lines, 2)
+		assert.Equal(t, "usd-bank", result.Lines[0].AccountID)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(15000)), utility.CleanDecimal(result.Lines[0].Gain))
+		assert.Equal(t, "usd-loan", result.Lines[1].AccountID)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(-5000)), utility.CleanDecimal(result.Lines[1].Gain))
+		require.Len(t, result.Transactions, 2, "Each ledger should be revalued in a transaction of its own")
+		for _, transaction := range result.Transactions {
+			require.Len(t, transaction.Entries, 2)
+			assert.True(t, transaction.IsTrueDrCr())
+		}
+		assert.Empty(t, result.Transactions[0].TenantID)
+		assert.Equal(t, "fx-tenant", result.Transactions[1].TenantID, "The loan's ledger is revalued second")
+		assert.Equal(t, "fx-partition", result.Transactions[1].PartitionID)
+
+		loanRevaluation, err := res.AccountRepository.GetByID(ctx, "test-ledger-liability_FX_UGX")
+		require.NoError(t, err)
+		assert.Equal(t, "fx-tenant", loanRevaluation.TenantID)
+
+		accounts, err := res.AccountRepository.ListByID(ctx, "test-ledger-asset_FX_UGX", "test-ledger-income_UGX",
+			"test-ledger-liability_FX_UGX", "test-ledger-expense_UGX")
+		require.NoError(t, err)
+		for accountID, balance := range map[string]int64{
+			"test-ledger-asset_FX_UGX":     15000,
+			"test-ledger-income_UGX":       15000,
+			"test-ledger-liability_FX_UGX": 5000,
+			"test-ledger-expense_UGX":      5000,
+		} {
+			require.Contains(t, accounts, accountID)
+			assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(balance)),
+				utility.CleanDecimal(accounts[accountID].Balance.Decimal), accountID)
+		}
+
+		again, err := res.RevaluationBusiness.Revalue(ctx, revaluedAt)
+		require.NoError(t, err)
+		assert.Empty(t, again.Transactions, "Revaluing the same instant twice should post nothing")
+
+		report, err := res.ReportBusiness.TrialBalance(ctx, "UGX", revaluedAt, business.ConvertCurrencies())
+		require.NoError(t, err)
+		assert.True(t, report.Balanced)
+		assert.Equal(t, "3800", utility.CleanDecimal(report.Rates["USD"]).String())
+		capital := findReportLine(report.Lines, "test-ledger-capital")
+		require.NotNil(t, capital)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(380000)), utility.CleanDecimal(capital.Balance))
+
+		_, err = res.ReportBusiness.TrialBalance(ctx, "EUR", revaluedAt, business.ConvertCurrencies())
+		require.ErrorIs(t, err, apperrors.ErrFXRateNotFound)
+
+		// Runs at different instants started together, as by the job on two replicas, take turns, so only the
+		// first posts the gain on the new rate and the second finds nothing left to revalue.
+		err = res.FXRateBusiness.SaveRates(ctx, []*models.FXRate{fxRate("USD", "UGX", "3900", now.Add(150*time.Minute))})
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		runs := make([]*business.Revaluation, 2)
+		runErrs := make([]error, 2)
+		for i, runAt := range []time.Time{now.Add(3 * time.Hour), now.Add(4 * time.Hour)} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				runs[i], runErrs[i] = res.RevaluationBusiness.Revalue(ctx, runAt)
+			}()
+		}
+		wg.Wait()
+		require.NoError(t, runErrs[0])
+		require.NoError(t, runErrs[1])
+		assert.Len(t, append(runs[0].Transactions, runs[1].Transactions...), 2,
+			"The gain of the new rate should be posted by one run only")
+
+		accounts, err = res.AccountRepository.ListByID(ctx, "test-ledger-income_UGX")
+		require.NoError(t, err)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(30000)),
+			utility.CleanDecimal(accounts["test-ledger-income_UGX"].Balance.Decimal))
+	})
+}
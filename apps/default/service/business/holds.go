@@ -0,0 +1,313 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/pitabwire/frame/data"
+	"github.com/pitabwire/util"
+	"github.com/shopspring/decimal"
+)
+
+// HoldExpiresAtDataKey sets the expiry of a reservation in its data as an RFC3339 time,
+// overriding the default hold TTL.
+const HoldExpiresAtDataKey = "hold_expires_at"
+
+// holdExpiryBatchSize bounds the holds released by one run of the expiry job.
+const holdExpiryBatchSize = 500
+
+// Suffixes of the transactions that settle a hold, e.g. hold-42_RELEASE. The reservation entry that
+// takes a captured amount off the hold is named after its capture, e.g. hold-42_CAPTURE_HOLD.
+const (
+	holdCaptureSuffix = "_CAPTURE"
+	holdReleaseSuffix = "_RELEASE"
+	holdExpirySuffix  = "_EXPIRY"
+	holdEntrySuffix   = "_HOLD"
+)
+
+// CaptureRequest converts reserved funds into a posting. The captured amount moves from the held account
+// to AccountID in the direction of the hold. A zero Amount captures all that remains of the hold, and an
+// empty ID names the capture <hold>_CAPTURE, so holds captured in parts need an ID for each capture.
+type CaptureRequest struct {
+	ID        string
+	HoldID    string
+	AccountID string
+	Amount    decimal.Decimal
+	Data      data.JSONMap
+}
+
+// isHoldSettlement reports whether a transaction is the reservation entry that releases or captures a hold.
+// Such entries only unwind a reservation, so they are not held to account status.
+func isHoldSettlement(transaction *models.Transaction) bool {
+	return transaction.HoldID != "" &&
+		transaction.TransactionType == ledgerv1.TransactionType_RESERVATION.String()
+}
+
+// setHoldExpiry sets when a new reservation's hold expires, from its data or else the default hold TTL.
+func (b *transactionBusiness) setHoldExpiry(transaction *models.Transaction) error {
+	if transaction.TransactionType != ledgerv1.TransactionType_RESERVATION.String() ||
+		transaction.HoldID != "" || transaction.HoldExpiresAt != nil {
+		return nil
+	}
+
+	if value, ok := transaction.Data[HoldExpiresAtDataKey]; ok {
+		expiresAt, err := time.Parse(time.RFC3339, fmt.Sprint(value))
+		if err != nil {
+			return apperrors.ErrHoldExpiryInvalid.Extend(fmt.Sprintf("%s=%v", HoldExpiresAtDataKey, value))
+		}
+		expiresAt = expiresAt.UTC()
+		transaction.HoldExpiresAt = &expiresAt
+		return nil
+	}
+
+	if b.holdTTL > 0 {
+		expiresAt := transaction.TransactedAt.Add(b.holdTTL).UTC()
+		transaction.HoldExpiresAt = &expiresAt
+	}
+
+	return nil
+}
+
+// GetHold returns the hold placed by the reservation with the given id.
+func (b *transactionBusiness) GetHold(ctx context.Context, id string) (*models.Hold, error) {
+	if id == "" {
+		return nil, ErrTransactionIDRequired
+	}
+
+	return b.transactionRepo.GetHold(ctx, id)
+}
+
+// CaptureHold posts a NORMAL transaction linked to the hold and takes the captured amount off what it
+// reserves, both or neither. The hold stays open until nothing of it remains reserved. A capture of part
+// of the hold needs an ID, and capturing again with the same ID, account and amount returns the capture
+// already posted.
+func (b *transactionBusiness) CaptureHold(
+	ctx context.Context,
+	request *CaptureRequest,
+) (*models.Hold, *models.Transaction, error) {
+	if request.HoldID == "" {
+		return nil, nil, ErrTransactionIDRequired
+	}
+
+	if request.AccountID == "" || request.Amount.IsNegative() {
+		return nil, nil, fmt.Errorf("%w: capture of hold %s needs an account and a positive amount",
+			ErrHoldCaptureInvalid, request.HoldID)
+	}
+
+	// Partial captures are told apart by their ids, the capture of what remains is named after the hold.
+	captureID := request.ID
+	if captureID == "" {
+		if !request.Amount.IsZero() {
+			return nil, nil, fmt.Errorf("%w: partial capture of hold %s needs an id",
+				ErrHoldCaptureInvalid, request.HoldID)
+		}
+		captureID = request.HoldID + holdCaptureSuffix
+	}
+
+	var capture *models.Transaction
+	settle := func(hold *models.Hold) ([]*models.Transaction, error) {
+		amount := request.Amount
+		if amount.IsZero() {
+			amount = hold.Remaining()
+		}
+
+		err := checkHoldSettleable(hold, amount)
+		if err != nil {
+			return nil, err
+		}
+
+		hold.Captured = hold.Captured.Add(amount)
+		if hold.Remaining().IsZero() {
+			hold.Status = models.HoldStatusCaptured
+		}
+
+		capture = &models.Transaction{
+			BaseModel:       data.BaseModel{ID: captureID},
+			Currency:        hold.Currency,
+			TransactionType: ledgerv1.TransactionType_NORMAL.String(),
+			HoldID:          hold.GetID(),
+			Data:            request.Data,
+			Entries: []*models.TransactionEntry{
+				{AccountID: hold.AccountID, Amount: decimal.NewNullDecimal(amount), Credit: hold.Credit},
+				{AccountID: request.AccountID, Amount: decimal.NewNullDecimal(amount), Credit: !hold.Credit},
+			},
+		}
+
+		return []*models.Transaction{capture, holdRelease(hold, captureID+holdEntrySuffix, amount)}, nil
+	}
+
+	hold, err := b.settleHold(ctx, request.HoldID, settle)
+	if err == nil {
+		return hold, capture, nil
+	}
+
+	// A capture retried after it was posted finds the hold already settled or its own id taken.
+	existing, getErr := b.transactionRepo.GetByID(ctx, captureID)
+	if getErr != nil || !isCaptureOf(existing, request) {
+		return nil, nil, postingError(err)
+	}
+
+	hold, err = b.transactionRepo.GetHold(ctx, request.HoldID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return hold, existing, nil
+}
+
+// isCaptureOf reports whether transaction is the capture request asks for, paying the same account the
+// same amount out of the same hold, so that only a retry of the request is answered with it.
+func isCaptureOf(transaction *models.Transaction, request *CaptureRequest) bool {
+	if transaction.HoldID != request.HoldID {
+		return false
+	}
+
+	for _, entry := range transaction.Entries {
+		if entry.AccountID == request.AccountID {
+			return request.Amount.IsZero() || entry.Amount.Decimal.Abs().Equal(request.Amount)
+		}
+	}
+
+	return false
+}
+
+// ReleaseHold cancels what remains of an open hold. Releasing a released hold returns it unchanged.
+func (b *transactionBusiness) ReleaseHold(ctx context.Context, id string) (*models.Hold, error) {
+	if id == "" {
+		return nil, ErrTransactionIDRequired
+	}
+
+	hold, err := b.releaseHold(ctx, id, models.HoldStatusReleased, id+holdReleaseSuffix)
+	if errors.Is(err, apperrors.ErrHoldNotOpen) {
+		released, getErr := b.transactionRepo.GetHold(ctx, id)
+		if getErr == nil && released.Status == models.HoldStatusReleased {
+			return released, nil
+		}
+	}
+
+	return hold, err
+}
+
+// ExpireHolds releases the open holds whose expiry is not after at, up to a batch per run, and returns
+// how many it released. A hold that fails to release is left for the next run.
+func (b *transactionBusiness) ExpireHolds(ctx context.Context, at time.Time) (int, error) {
+	holds, err := b.transactionRepo.ListExpiredHolds(ctx, at, holdExpiryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	var errs []error
+	for _, hold := range holds {
+		_, err = b.releaseHold(ctx, hold.GetID(), models.HoldStatusExpired, hold.GetID()+holdExpirySuffix)
+		switch {
+		case err == nil:
+			expired++
+		case errors.Is(err, apperrors.ErrHoldNotOpen):
+			// Captured or released since it was listed.
+		default:
+			util.Log(ctx).WithError(err).WithField("hold_id", hold.GetID()).Error("could not expire hold")
+			errs = append(errs, err)
+		}
+	}
+
+	return expired, errors.Join(errs...)
+}
+
+// releaseHold releases what remains of an open hold, leaving it in status.
+func (b *transactionBusiness) releaseHold(
+	ctx context.Context,
+	id, status, releaseID string,
+) (*models.Hold, error) {
+	settle := func(hold *models.Hold) ([]*models.Transaction, error) {
+		amount := hold.Remaining()
+		err := checkHoldSettleable(hold, amount)
+		if err != nil {
+			return nil, err
+		}
+
+		hold.Released = hold.Released.Add(amount)
+		hold.Status = status
+
+		return []*models.Transaction{holdRelease(hold, releaseID, amount)}, nil
+	}
+
+	hold, err := b.settleHold(ctx, id, settle)
+	if err != nil {
+		return nil, postingError(err)
+	}
+
+	return hold, nil
+}
+
+// settleHold settles a hold with the transactions settle builds from it under the hold's lock. They are
+// dated now, carry the tenancy of the hold and are validated, signed and checked as any other posting.
+func (b *transactionBusiness) settleHold(
+	ctx context.Context,
+	id string,
+	settle func(hold *models.Hold) ([]*models.Transaction, error),
+) (*models.Hold, error) {
+	var transactions []*models.Transaction
+	build := func(hold *models.Hold) ([]*models.Transaction, error) {
+		var err error
+		transactions, err = settle(hold)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		for _, transaction := range transactions {
+			transaction.TransactedAt = now
+			transaction.ClearedAt = now
+			transaction.GenID(ctx)
+			transaction.CopyPartitionInfo(&hold.BaseModel)
+
+			accounts, validateErr := b.Validate(ctx, transaction)
+			if validateErr != nil {
+				return nil, validateErr
+			}
+
+			b.processTransactionEntriesWithAccounts(transaction, accounts)
+		}
+
+		return transactions, nil
+	}
+
+	check := func(accounts map[string]*models.Account, closedPeriod string) error {
+		return b.postingCheck(ctx, transactions...)(accounts, closedPeriod)
+	}
+
+	return b.transactionRepo.SettleHold(ctx, id, build, check)
+}
+
+// checkHoldSettleable requires a hold to be open with at least amount still reserved.
+func checkHoldSettleable(hold *models.Hold, amount decimal.Decimal) error {
+	if hold.Status != models.HoldStatusOpen {
+		return apperrors.ErrHoldNotOpen.Extend(fmt.Sprintf("hold %s is %s", hold.GetID(), hold.Status))
+	}
+
+	if !amount.IsPositive() || amount.GreaterThan(hold.Remaining()) {
+		return apperrors.ErrHoldAmountExceeded.Extend(
+			fmt.Sprintf("hold %s has %s remaining, %s requested", hold.GetID(), hold.Remaining(), amount))
+	}
+
+	return nil
+}
+
+// holdRelease builds the reservation entry that takes amount off a hold, in the opposite direction to it.
+func holdRelease(hold *models.Hold, id string, amount decimal.Decimal) *models.Transaction {
+	return &models.Transaction{
+		BaseModel:       data.BaseModel{ID: id},
+		Currency:        hold.Currency,
+		TransactionType: ledgerv1.TransactionType_RESERVATION.String(),
+		HoldID:          hold.GetID(),
+		Entries: []*models.TransactionEntry{
+			{AccountID: hold.AccountID, Amount: decimal.NewNullDecimal(amount), Credit: !hold.Credit},
+		},
+	}
+}
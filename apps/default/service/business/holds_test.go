@@ -0,0 +1,135 @@
+package business_test
+
+import (
+	"testing"
+	"time"
+
+	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/pitabwire/frame/data"
+	"github.com/pitabwire/frame/frametests/definition"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func reservation(id, accountID string, amount int64, txnData data.JSONMap) *models.Transaction {
+	return &models.Transaction{
+		BaseModel:       data.BaseModel{ID: id},
+		Currency:        "UGX",
+		TransactionType: ledgerv1.TransactionType_RESERVATION.String(),
+		Data:            txnData,
+		Entries: []*models.TransactionEntry{
+			{AccountID: accountID, Amount: decimal.NewNullDecimal(decimal.NewFromInt(amount)), Credit: true},
+		},
+	}
+}
+
+func (ts *TransactionsModelSuite) TestHoldLifecycle() {
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, depOpt *definition.DependencyOption) {
+		ctx, _, res := ts.CreateService(t, depOpt)
+		ts.setupFixtures(ctx, res)
+
+		txnBusiness := res.TransactionBusiness
+
+		_, err := txnBusiness.Transact(ctx, transfer("hold-funding", "a1", "a2", 1000))
+		require.NoError(t, err)
+
+		_, err = txnBusiness.Transact(ctx, reservation("hold-1", "a1", 300, nil))
+		require.NoError(t, err)
+
+		hold, err := txnBusiness.GetHold(ctx, "hold-1")
+		require.NoError(t, err)
+		assert.Equal(t, models.HoldStatusOpen, hold.Status)
+		require.NotNil(t, hold.ExpiresAt, "Holds should expire after the default TTL")
+
+		capture := &business.CaptureRequest{
+			ID:        "hold-1-capture-1",
+			HoldID:    "hold-1",
+			AccountID: "a4",
+			Amount:    decimal.NewFromInt(100),
+		}
+		hold, captured, err := txnBusiness.CaptureHold(ctx, capture)
+		require.NoError(t, err)
+		assert.Equal(t, "hold-1", captured.HoldID)
+		assert.Equal(t, models.HoldStatusOpen, hold.Status, "A partial capture should leave the rest held")
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(200)), utility.CleanDecimal(hold.Remaining()))
+
+		accounts, err := res.AccountRepository.ListByID(ctx, "a1", "a4")
+		require.NoError(t, err)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(900)),
+			utility.CleanDecimal(accounts["a1"].Balance.Decimal))
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(-200)),
+			utility.CleanDecimal(accounts["a1"].ReservedBalance.Decimal))
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(100)),
+			utility.CleanDecimal(accounts["a4"].Balance.Decimal))
+
+		hold, again, err := txnBusiness.CaptureHold(ctx, capture)
+		require.NoError(t, err, "Repeating a capture should return the one already posted")
+		assert.Equal(t, captured.GetID(), again.GetID())
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(200)), utility.CleanDecimal(hold.Remaining()))
+
+		_, _, err = txnBusiness.CaptureHold(ctx, &business.CaptureRequest{
+			ID:        "hold-1-capture-1",
+			HoldID:    "hold-1",
+			AccountID: "a4",
+			Amount:    decimal.NewFromInt(50),
+		})
+		require.Error(t, err, "A different capture reusing an id should not be taken for a retry")
+
+		_, _, err = txnBusiness.CaptureHold(ctx, &business.CaptureRequest{
+			HoldID:    "hold-1",
+			AccountID: "a4",
+			Amount:    decimal.NewFromInt(50),
+		})
+		require.ErrorIs(t, err, business.ErrHoldCaptureInvalid, "Partial captures need an id")
+
+		_, _, err = txnBusiness.CaptureHold(ctx, &business.CaptureRequest{
+			ID:        "hold-1-capture-2",
+			HoldID:    "hold-1",
+			AccountID: "a4",
+			Amount:    decimal.NewFromInt(500),
+		})
+		require.ErrorIs(t, err, apperrors.ErrHoldAmountExceeded)
+
+		hold, err = txnBusiness.ReleaseHold(ctx, "hold-1")
+		require.NoError(t, err)
+		assert.Equal(t, models.HoldStatusReleased, hold.Status)
+		assert.True(t, hold.Remaining().IsZero())
+
+		hold, err = txnBusiness.ReleaseHold(ctx, "hold-1")
+		require.NoError(t, err, "Releasing a released hold should change nothing")
+		assert.Equal(t, models.HoldStatusReleased, hold.Status)
+
+		_, _, err = txnBusiness.CaptureHold(ctx, &business.CaptureRequest{
+			ID:        "hold-1-capture-3",
+			HoldID:    "hold-1",
+			AccountID: "a4",
+		})
+		require.ErrorIs(t, err, apperrors.ErrHoldNotOpen)
+
+		expiresAt := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+		_, err = txnBusiness.Transact(ctx,
+			reservation("hold-2", "a1", 50, data.JSONMap{business.HoldExpiresAtDataKey: expiresAt}))
+		require.NoError(t, err)
+
+		expired, err := txnBusiness.ExpireHolds(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 1, expired)
+
+		hold, err = txnBusiness.GetHold(ctx, "hold-2")
+		require.NoError(t, err)
+		assert.Equal(t, models.HoldStatusExpired, hold.Status)
+
+		accounts, err = res.AccountRepository.ListByID(ctx, "a1")
+		require.NoError(t, err)
+		assert.True(t, accounts["a1"].ReservedBalance.Decimal.IsZero(), "Settled holds should reserve nothing")
+
+		_, err = txnBusiness.Transact(ctx,
+			reservation("hold-3", "a1", 50, data.JSONMap{business.HoldExpiresAtDataKey: "tomorrow"}))
+		require.ErrorIs(t, err, apperrors.ErrHoldExpiryInvalid)
+	})
+}
@@ -2,13 +2,18 @@ package business
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"slices"
 
 	commonv1 "buf.build/gen/go/antinvestor/common/protocolbuffers/go/common/v1"
 	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
 	"github.com/antinvestor/service-ledger/apps/default/service/models"
 	"github.com/antinvestor/service-ledger/apps/default/service/repository"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
 	"github.com/pitabwire/frame/data"
 	"github.com/pitabwire/frame/workerpool"
+	"github.com/shopspring/decimal"
 )
 
 // LedgerBusiness defines the business interface for ledger operations.
@@ -18,20 +23,55 @@ type LedgerBusiness interface {
 		consumer func(ctx context.Context, batch []*ledgerv1.Ledger) error) error
 	GetLedger(ctx context.Context, id string) (*ledgerv1.Ledger, error)
 	UpdateLedger(ctx context.Context, req *ledgerv1.UpdateLedgerRequest) (*ledgerv1.Ledger, error)
-	DeleteLedger(ctx context.Context, id string) error
+	DeleteLedger(ctx context.Context, id string, cascade bool) error
+	SetBalanceFloor(ctx context.Context, id string, floor decimal.NullDecimal) (*ledgerv1.Ledger, error)
+	SetFundsPolicy(ctx context.Context, id string, policy string) (*ledgerv1.Ledger, error)
+	GetLedgerTree(ctx context.Context, rootID string, depth int) (*models.LedgerTreeNode, error)
+	MoveLedger(ctx context.Context, id string, parentID string) (*ledgerv1.Ledger, error)
 }
 
+// LedgerTypeRule lists, per parent ledger type, the child ledger types allowed besides the parent's own.
+// The wildcard "*" stands for any type on either side.
+type LedgerTypeRule map[string][]string
+
+// Allows reports whether a ledger of childType may be filed under a ledger of parentType.
+func (r LedgerTypeRule) Allows(parentType, childType string) bool {
+	if parentType == childType {
+		return true
+	}
+
+	for _, key := range []string{parentType, ledgerTypeWildcard} {
+		if slices.Contains(r[key], childType) || slices.Contains(r[key], ledgerTypeWildcard) {
+			return true
+		}
+	}
+
+	return false
+}
+
+const ledgerTypeWildcard = "*"
+
 // ledgerBusiness implements the LedgerBusiness interface.
 type ledgerBusiness struct {
-	workMan    workerpool.Manager
-	ledgerRepo repository.LedgerRepository
+	workMan     workerpool.Manager
+	ledgerRepo  repository.LedgerRepository
+	accountRepo repository.AccountRepository
+	typeRule    LedgerTypeRule
 }
 
 // NewLedgerBusiness creates a new ledger business instance.
-func NewLedgerBusiness(workMan workerpool.Manager, ledgerRepo repository.LedgerRepository) LedgerBusiness {
+// Child ledgers must share their parent's type unless typeRule allows otherwise.
+func NewLedgerBusiness(
+	workMan workerpool.Manager,
+	ledgerRepo repository.LedgerRepository,
+	accountRepo repository.AccountRepository,
+	typeRule LedgerTypeRule,
+) LedgerBusiness {
 	return &ledgerBusiness{
-		workMan:    workMan,
-		ledgerRepo: ledgerRepo,
+		workMan:     workMan,
+		ledgerRepo:  ledgerRepo,
+		accountRepo: accountRepo,
+		typeRule:    typeRule,
 	}
 }
 
@@ -57,8 +97,13 @@ func (b *ledgerBusiness) CreateLedger(
 		ledgerModel.ID = req.GetId()
 	}
 
+	err := b.validateParent(ctx, ledgerModel, ledgerModel.ParentID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create the ledger through repository
-	err := b.ledgerRepo.Create(ctx, ledgerModel)
+	err = b.ledgerRepo.Create(ctx, ledgerModel)
 	if err != nil {
 		return nil, err
 	}
@@ -119,6 +164,73 @@ func (b *ledgerBusiness) GetLedger(ctx context.Context, id string) (*ledgerv1.Le
 	return ledger.ToAPI(), nil
 }
 
+// GetLedgerTree returns the ledger rootID and its descendants down to depth levels, all levels when depth
+// is below one, with the child counts, account counts and per currency balances of every node.
+func (b *ledgerBusiness) GetLedgerTree(
+	ctx context.Context,
+	rootID string,
+	depth int,
+) (*models.LedgerTreeNode, error) {
+	if rootID == "" {
+		return nil, ErrLedgerIDRequired
+	}
+
+	return b.ledgerRepo.GetLedgerTree(ctx, rootID, depth)
+}
+
+// MoveLedger re-parents the ledger id together with its subtree under parentID,
+// or makes it a top level ledger when parentID is empty.
+func (b *ledgerBusiness) MoveLedger(ctx context.Context, id string, parentID string) (*ledgerv1.Ledger, error) {
+	if id == "" {
+		return nil, ErrLedgerIDRequired
+	}
+
+	ledger, err := b.ledgerRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.validateParent(ctx, ledger, parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.ledgerRepo.MoveLedger(ctx, id, parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger.ParentID = parentID
+	return ledger.ToAPI(), nil
+}
+
+// validateParent checks that parentID names an existing ledger other than ledger itself
+// and that the type rule allows ledger under it. An empty parentID is always valid.
+func (b *ledgerBusiness) validateParent(ctx context.Context, ledger *models.Ledger, parentID string) error {
+	if parentID == "" {
+		return nil
+	}
+
+	if parentID == ledger.ID {
+		return apperrors.ErrLedgerHierarchyCycle.Extend(fmt.Sprintf("%s under itself", ledger.ID))
+	}
+
+	parent, err := b.ledgerRepo.GetByID(ctx, parentID)
+	if err != nil {
+		if data.ErrorIsNoRows(err) {
+			return apperrors.ErrLedgerParentNotFound.Extend(parentID)
+		}
+		return err
+	}
+
+	if !b.typeRule.Allows(parent.Type, ledger.Type) {
+		return apperrors.ErrLedgerTypeMismatch.Extend(
+			fmt.Sprintf("%s ledger %s under %s ledger %s", ledger.Type, ledger.ID, parent.Type, parent.ID))
+	}
+
+	return nil
+}
+
 // UpdateLedger updates an existing ledger.
 func (b *ledgerBusiness) UpdateLedger(
 	ctx context.Context,
@@ -151,14 +263,77 @@ func (b *ledgerBusiness) UpdateLedger(
 	return existingLedger.ToAPI(), nil
 }
 
-// DeleteLedger deletes a ledger by ID.
-func (b *ledgerBusiness) DeleteLedger(_ context.Context, id string) error {
+// DeleteLedger soft deletes a ledger by ID.
+// A ledger with child ledgers is only removed when cascade is set, in which case the whole
+// subtree is archived. Every account held in the affected ledgers must have settled balances,
+// which is checked with the subtree and its accounts locked against postings.
+func (b *ledgerBusiness) DeleteLedger(ctx context.Context, id string, cascade bool) error {
 	if id == "" {
 		return ErrLedgerIDRequired
 	}
 
-	// Delete through repository (assuming repository has a Delete method)
-	// This might need to be implemented based on actual repository interface
-	// For now, we'll use Update with a deleted flag or similar
-	return nil // Implementation depends on repository interface
+	return b.ledgerRepo.Archive(ctx, id, func(ledgerIDs []string, accounts map[string]*models.Account) error {
+		if len(ledgerIDs) > 1 && !cascade {
+			return fmt.Errorf("%w: ledger %s has child ledgers", ErrLedgerHasChildren, id)
+		}
+
+		for _, accountID := range slices.Sorted(maps.Keys(accounts)) {
+			account := accounts[accountID]
+			if !account.HasZeroBalances() {
+				return fmt.Errorf("%w: account %s in ledger %s", ErrLedgerHasActiveAccounts, account.ID, account.LedgerID)
+			}
+		}
+
+		return nil
+	})
+}
+
+// SetBalanceFloor sets the default balance floor inherited by accounts in the ledger
+// that do not define their own. An invalid floor leaves those accounts unbounded.
+func (b *ledgerBusiness) SetBalanceFloor(
+	ctx context.Context,
+	id string,
+	floor decimal.NullDecimal,
+) (*ledgerv1.Ledger, error) {
+	if id == "" {
+		return nil, ErrLedgerIDRequired
+	}
+
+	ledger, err := b.ledgerRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger.BalanceFloor = floor
+	_, err = b.ledgerRepo.Update(ctx, ledger, "balance_floor", "modified_at", "version")
+	if err != nil {
+		return nil, err
+	}
+
+	return ledger.ToAPI(), nil
+}
+
+// SetFundsPolicy sets what the accounts in the ledger may spend, e.g. AVAILABLE for card accounts whose
+// authorisations must not be spent twice. It takes effect from the next posting.
+func (b *ledgerBusiness) SetFundsPolicy(ctx context.Context, id string, policy string) (*ledgerv1.Ledger, error) {
+	if id == "" {
+		return nil, ErrLedgerIDRequired
+	}
+
+	if !models.IsValidFundsPolicy(policy) {
+		return nil, ErrFundsPolicyInvalid
+	}
+
+	ledger, err := b.ledgerRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger.FundsPolicy = policy
+	_, err = b.ledgerRepo.Update(ctx, ledger, "funds_policy", "modified_at", "version")
+	if err != nil {
+		return nil, err
+	}
+
+	return ledger.ToAPI(), nil
 }
@@ -3,12 +3,18 @@ package business_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	commonv1 "buf.build/gen/go/antinvestor/common/protocolbuffers/go/common/v1"
 	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
 	"github.com/antinvestor/service-ledger/apps/default/tests"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
 	_ "github.com/lib/pq"
+	"github.com/pitabwire/frame/data"
 	"github.com/pitabwire/frame/frametests/definition"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -185,3 +191,185 @@ func (ls *LedgerBusinessSuite) TestSearchLedgers() {
 		assert.Len(t, foundLedgers, 1, "Should find 1 asset ledger")
 	})
 }
+
+func (ls *LedgerBusinessSuite) TestDeleteLedger() {
+	ls.WithTestDependencies(ls.T(), func(t *testing.T, dep *definition.DependencyOption) {
+		ctx, _, resources := ls.CreateService(t, dep)
+
+		ledgerBusiness := resources.LedgerBusiness
+
+		_, err := ledgerBusiness.CreateLedger(ctx, &ledgerv1.CreateLedgerRequest{
+			Id:   "delete-test-ledger",
+			Type: ledgerv1.LedgerType_ASSET,
+		})
+		require.NoError(t, err, "Error creating ledger")
+
+		_, err = resources.AccountBusiness.CreateAccount(ctx, &ledgerv1.CreateAccountRequest{
+			Id:       "delete-test-account",
+			LedgerId: "delete-test-ledger",
+			Currency: "USD",
+		})
+		require.NoError(t, err, "Error creating account")
+
+		err = ledgerBusiness.DeleteLedger(ctx, "delete-test-ledger", false)
+		require.NoError(t, err, "Error deleting ledger")
+
+		_, err = ledgerBusiness.GetLedger(ctx, "delete-test-ledger")
+		require.Error(t, err, "Deleted ledger should not be found")
+
+		_, err = resources.AccountBusiness.GetAccount(ctx, "delete-test-account")
+		require.Error(t, err, "Accounts of a deleted ledger should be archived")
+	})
+}
+
+func (ls *LedgerBusinessSuite) TestDeleteLedgerWithChildren() {
+	ls.WithTestDependencies(ls.T(), func(t *testing.T, dep *definition.DependencyOption) {
+		ctx, _, resources := ls.CreateService(t, dep)
+
+		ledgerBusiness := resources.LedgerBusiness
+
+		for _, req := range []*ledgerv1.CreateLedgerRequest{
+			{Id: "parent-ledger", Type: ledgerv1.LedgerType_LIABILITY},
+			{Id: "child-ledger", Type: ledgerv1.LedgerType_LIABILITY, ParentId: "parent-ledger"},
+			{Id: "grandchild-ledger", Type: ledgerv1.LedgerType_LIABILITY, ParentId: "child-ledger"},
+		} {
+			_, err := ledgerBusiness.CreateLedger(ctx, req)
+			require.NoError(t, err, "Error creating ledger %s", req.GetId())
+		}
+
+		err := ledgerBusiness.DeleteLedger(ctx, "parent-ledger", false)
+		require.ErrorIs(t, err, business.ErrLedgerHasChildren, "Ledger with children should not be deleted")
+
+		_, err = ledgerBusiness.GetLedger(ctx, "parent-ledger")
+		require.NoError(t, err, "Ledger should still exist after a refused delete")
+
+		err = ledgerBusiness.DeleteLedger(ctx, "parent-ledger", true)
+		require.NoError(t, err, "Cascade delete of an empty subtree should succeed")
+
+		for _, id := range []string{"parent-ledger", "child-ledger", "grandchild-ledger"} {
+			_, err = ledgerBusiness.GetLedger(ctx, id)
+			require.Error(t, err, "Ledger %s should be archived", id)
+		}
+	})
+}
+
+func (ls *LedgerBusinessSuite) TestDeleteLedgerWithFundedAccount() {
+	ls.WithTestDependencies(ls.T(), func(t *testing.T, dep *definition.DependencyOption) {
+		ctx, _, resources := ls.CreateService(t, dep)
+
+		ledgerBusiness := resources.LedgerBusiness
+		accountBusiness := resources.AccountBusiness
+
+		for _, req := range []*ledgerv1.CreateLedgerRequest{
+			{Id: "funded-ledger", Type: ledgerv1.LedgerType_ASSET},
+			{Id: "funded-child-ledger", Type: ledgerv1.LedgerType_ASSET, ParentId: "funded-ledger"},
+			{Id: "funding-ledger", Type: ledgerv1.LedgerType_INCOME},
+		} {
+			_, err := ledgerBusiness.CreateLedger(ctx, req)
+			require.NoError(t, err, "Error creating ledger %s", req.GetId())
+		}
+
+		for _, req := range []*ledgerv1.CreateAccountRequest{
+			{Id: "funded-account", LedgerId: "funded-child-ledger", Currency: "USD"},
+			{Id: "funding-account", LedgerId: "funding-ledger", Currency: "USD"},
+		} {
+			_, err := accountBusiness.CreateAccount(ctx, req)
+			require.NoError(t, err, "Error creating account %s", req.GetId())
+		}
+
+		timeNow := time.Now().UTC()
+		_, err := resources.TransactionBusiness.Transact(ctx, &models.Transaction{
+			BaseModel:       data.BaseModel{ID: "funding-transaction"},
+			Currency:        "USD",
+			TransactionType: ledgerv1.TransactionType_NORMAL.String(),
+			TransactedAt:    timeNow,
+			ClearedAt:       timeNow,
+			Entries: []*models.TransactionEntry{
+				{AccountID: "funded-account", Amount: decimal.NewNullDecimal(decimal.NewFromInt(10))},
+				{AccountID: "funding-account", Amount: decimal.NewNullDecimal(decimal.NewFromInt(10)), Credit: true},
+			},
+		})
+		require.NoError(t, err, "Error funding account")
+
+		err = ledgerBusiness.DeleteLedger(ctx, "funded-ledger", true)
+		require.ErrorIs(t, err, business.ErrLedgerHasActiveAccounts, "Subtree with funds should not be deleted")
+
+		_, err = ledgerBusiness.GetLedger(ctx, "funded-child-ledger")
+		require.NoError(t, err, "Child ledger should still exist after a refused delete")
+	})
+}
+
+func (ls *LedgerBusinessSuite) TestCreateLedgerValidatesParent() {
+	ls.WithTestDependencies(ls.T(), func(t *testing.T, dep *definition.DependencyOption) {
+		ctx, _, resources := ls.CreateService(t, dep)
+
+		ledgerBusiness := resources.LedgerBusiness
+
+		_, err := ledgerBusiness.CreateLedger(ctx, &ledgerv1.CreateLedgerRequest{
+			Id: "orphan-ledger", Type: ledgerv1.LedgerType_ASSET, ParentId: "typo-ledger",
+		})
+		require.ErrorIs(t, err, apperrors.ErrLedgerParentNotFound)
+
+		_, err = ledgerBusiness.CreateLedger(ctx, &ledgerv1.CreateLedgerRequest{
+			Id: "self-ledger", Type: ledgerv1.LedgerType_ASSET, ParentId: "self-ledger",
+		})
+		require.ErrorIs(t, err, apperrors.ErrLedgerHierarchyCycle)
+
+		_, err = ledgerBusiness.CreateLedger(ctx, &ledgerv1.CreateLedgerRequest{
+			Id: "assets-ledger", Type: ledgerv1.LedgerType_ASSET,
+		})
+		require.NoError(t, err)
+
+		_, err = ledgerBusiness.CreateLedger(ctx, &ledgerv1.CreateLedgerRequest{
+			Id: "misfiled-ledger", Type: ledgerv1.LedgerType_INCOME, ParentId: "assets-ledger",
+		})
+		require.ErrorIs(t, err, apperrors.ErrLedgerTypeMismatch)
+
+		_, err = ledgerBusiness.CreateLedger(ctx, &ledgerv1.CreateLedgerRequest{
+			Id: "bank-ledger", Type: ledgerv1.LedgerType_ASSET, ParentId: "assets-ledger",
+		})
+		require.NoError(t, err)
+	})
+}
+
+func (ls *LedgerBusinessSuite) TestMoveLedger() {
+	ls.WithTestDependencies(ls.T(), func(t *testing.T, dep *definition.DependencyOption) {
+		ctx, _, resources := ls.CreateService(t, dep)
+
+		ledgerBusiness := resources.LedgerBusiness
+
+		for _, req := range []*ledgerv1.CreateLedgerRequest{
+			{Id: "move-root", Type: ledgerv1.LedgerType_ASSET},
+			{Id: "move-child", Type: ledgerv1.LedgerType_ASSET, ParentId: "move-root"},
+			{Id: "move-grandchild", Type: ledgerv1.LedgerType_ASSET, ParentId: "move-child"},
+			{Id: "move-other", Type: ledgerv1.LedgerType_ASSET},
+			{Id: "move-income", Type: ledgerv1.LedgerType_INCOME},
+		} {
+			_, err := ledgerBusiness.CreateLedger(ctx, req)
+			require.NoError(t, err, "Error creating ledger %s", req.GetId())
+		}
+
+		_, err := ledgerBusiness.MoveLedger(ctx, "move-root", "move-grandchild")
+		require.ErrorIs(t, err, apperrors.ErrLedgerHierarchyCycle, "A ledger cannot move under its descendant")
+
+		_, err = ledgerBusiness.MoveLedger(ctx, "move-child", "move-income")
+		require.ErrorIs(t, err, apperrors.ErrLedgerTypeMismatch)
+
+		_, err = ledgerBusiness.MoveLedger(ctx, "move-child", "missing-ledger")
+		require.ErrorIs(t, err, apperrors.ErrLedgerParentNotFound)
+
+		moved, err := ledgerBusiness.MoveLedger(ctx, "move-child", "move-other")
+		require.NoError(t, err)
+		assert.Equal(t, "move-other", moved.GetParent())
+
+		tree, err := ledgerBusiness.GetLedgerTree(ctx, "move-other", 0)
+		require.NoError(t, err)
+		require.Len(t, tree.Children, 1)
+		require.Len(t, tree.Children[0].Children, 1, "The subtree should move with its root")
+		assert.Equal(t, "move-grandchild", tree.Children[0].Children[0].Ledger.ID)
+
+		moved, err = ledgerBusiness.MoveLedger(ctx, "move-child", "")
+		require.NoError(t, err)
+		assert.Empty(t, moved.GetParent())
+	})
+}
@@ -0,0 +1,99 @@
+package business
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/apps/default/service/repository"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/pitabwire/frame/data"
+	"github.com/pitabwire/frame/security"
+)
+
+// periodClosedBySystem records closes made without an authenticated profile, e.g. by scheduled jobs.
+const periodClosedBySystem = "system"
+
+// PeriodBusiness closes monthly accounting periods. Once a period is closed, transactions dated in it
+// are rejected unless posted as adjustments, and the balances of every account at its end are kept.
+type PeriodBusiness interface {
+	ClosePeriod(ctx context.Context, name string) (*models.AccountingPeriod, error)
+	GetPeriod(ctx context.Context, name string) (*models.AccountingPeriod, error)
+	ListPeriodBalances(ctx context.Context, name string) ([]*models.PeriodBalance, error)
+}
+
+// periodBusiness implements the PeriodBusiness interface.
+type periodBusiness struct {
+	periodRepo repository.PeriodRepository
+	adminRole  string
+}
+
+// NewPeriodBusiness creates a new period business instance.
+func NewPeriodBusiness(periodRepo repository.PeriodRepository, adminRole string) PeriodBusiness {
+	return &periodBusiness{
+		periodRepo: periodRepo,
+		adminRole:  adminRole,
+	}
+}
+
+// ClosePeriod closes the month named name, e.g. 2026-09, and snapshots the closing balances of all accounts.
+// Only callers holding the period admin role may close a period, only months that have ended can be
+// closed and a period cannot be closed twice.
+func (b *periodBusiness) ClosePeriod(ctx context.Context, name string) (*models.AccountingPeriod, error) {
+	if !hasRole(ctx, b.adminRole) {
+		return nil, fmt.Errorf("%w: %s", ErrPeriodForbidden, b.adminRole)
+	}
+
+	startsAt, err := time.Parse(models.PeriodNameLayout, name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrPeriodNameInvalid, name)
+	}
+
+	now := time.Now().UTC()
+	period := &models.AccountingPeriod{
+		Name:     name,
+		StartsAt: startsAt,
+		EndsAt:   startsAt.AddDate(0, 1, 0),
+		ClosedAt: now,
+		ClosedBy: periodClosedBySystem,
+	}
+	if period.EndsAt.After(now) {
+		return nil, fmt.Errorf("%w: %s ends at %s", ErrPeriodNotEnded, name, period.EndsAt.Format(time.RFC3339))
+	}
+
+	if claims := security.ClaimsFromContext(ctx); claims != nil && claims.GetProfileID() != "" {
+		period.ClosedBy = claims.GetProfileID()
+	}
+	period.GenID(ctx)
+
+	err = b.periodRepo.Close(ctx, period)
+	if err != nil {
+		return nil, err
+	}
+
+	return period, nil
+}
+
+// GetPeriod returns the closed period named name.
+func (b *periodBusiness) GetPeriod(ctx context.Context, name string) (*models.AccountingPeriod, error) {
+	period, err := b.periodRepo.GetByName(ctx, name)
+	if err != nil {
+		if data.ErrorIsNoRows(err) {
+			return nil, apperrors.ErrPeriodNotFound.Extend(name)
+		}
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return period, nil
+}
+
+// ListPeriodBalances returns the balances of all accounts snapshotted when the period named name closed.
+func (b *periodBusiness) ListPeriodBalances(ctx context.Context, name string) ([]*models.PeriodBalance, error) {
+	period, err := b.GetPeriod(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.periodRepo.ListBalances(ctx, period.GetID())
+}
@@ -0,0 +1,99 @@
+package business_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/pitabwire/frame/frametests/definition"
+	"github.com/pitabwire/frame/security"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (ts *TransactionsModelSuite) TestClosePeriod() {
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, depOpt *definition.DependencyOption) {
+		ctx, _, res := ts.CreateService(t, depOpt)
+		ts.setupFixtures(ctx, res)
+
+		now := time.Now().UTC()
+		lastMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+		periodName := models.PeriodName(lastMonth)
+
+		backDated := transfer("period-sale", "a1", "a2", 100)
+		backDated.TransactedAt = lastMonth.Add(24 * time.Hour)
+		backDated.ClearedAt = backDated.TransactedAt
+		_, err := res.TransactionBusiness.Transact(ctx, backDated)
+		require.NoError(t, err, "Open periods accept back dated postings")
+
+		_, err = res.PeriodBusiness.ClosePeriod(ctx, periodName)
+		require.ErrorIs(t, err, business.ErrPeriodForbidden, "Only period admins may close periods")
+
+		adminCtx := withRole(ctx, "ledger_period_admin")
+
+		_, err = res.PeriodBusiness.ClosePeriod(adminCtx, models.PeriodName(now))
+		require.ErrorIs(t, err, business.ErrPeriodNotEnded)
+
+		_, err = res.PeriodBusiness.ClosePeriod(adminCtx, "last-month")
+		require.ErrorIs(t, err, business.ErrPeriodNameInvalid)
+
+		period, err := res.PeriodBusiness.ClosePeriod(adminCtx, periodName)
+		require.NoError(t, err)
+		assert.Equal(t, lastMonth, period.StartsAt)
+		assert.Equal(t, lastMonth.AddDate(0, 1, 0), period.EndsAt)
+
+		_, err = res.PeriodBusiness.ClosePeriod(adminCtx, periodName)
+		require.ErrorIs(t, err, apperrors.ErrPeriodAlreadyClosed)
+
+		balances, err := res.PeriodBusiness.ListPeriodBalances(ctx, periodName)
+		require.NoError(t, err)
+		closing := map[string]decimal.Decimal{}
+		for _, balance := range balances {
+			closing[balance.AccountID] = balance.Balance
+		}
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(100)), utility.CleanDecimal(closing["a1"]))
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(100)), utility.CleanDecimal(closing["a2"]))
+
+		late := transfer("period-late-sale", "a1", "a2", 20)
+		late.TransactedAt = lastMonth.Add(48 * time.Hour)
+		_, err = res.TransactionBusiness.Transact(ctx, late)
+		require.ErrorIs(t, err, apperrors.ErrPeriodClosed, "Closed periods reject postings")
+
+		_, err = res.TransactionBusiness.Transact(ctx, transfer("period-current-sale", "a1", "a2", 20))
+		require.NoError(t, err, "Postings into open periods are unaffected")
+
+		adjustCtx := withRole(ctx, "ledger_adjustment")
+
+		late = transfer("period-late-sale", "a1", "a2", 20)
+		late.TransactedAt = lastMonth.Add(48 * time.Hour)
+		adjustment, err := res.TransactionBusiness.Transact(adjustCtx, late)
+		require.NoError(t, err, "Adjusters may post into closed periods")
+		assert.Equal(t, periodName, adjustment.AdjustedPeriod)
+
+		balances, err = res.PeriodBusiness.ListPeriodBalances(ctx, periodName)
+		require.NoError(t, err)
+		for _, balance := range balances {
+			if balance.AccountID == "a1" {
+				assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(100)), utility.CleanDecimal(balance.Balance),
+					"Adjustments leave the closing snapshot untouched")
+			}
+		}
+	})
+}
+
+// withRole returns ctx with the caller's claims extended by role.
+func withRole(ctx context.Context, role string) context.Context {
+	claims := &security.AuthenticationClaims{}
+	if existing := security.ClaimsFromContext(ctx); existing != nil {
+		*claims = *existing
+	}
+	claims.Roles = append(slices.Clone(claims.Roles), role)
+
+	return claims.ClaimsToContext(ctx)
+}
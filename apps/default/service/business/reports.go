@@ -0,0 +1,404 @@
+package business
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/apps/default/service/repository"
+	"github.com/pitabwire/util"
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/currency"
+)
+
+// ReportLine is a ledger in a hierarchical report. Balance is held by the ledger's own accounts and
+// Total by the ledger and all its descendants, both in the natural sign of the ledger type.
+type ReportLine struct {
+	LedgerID string
+	ParentID string
+	Type     string
+	Balance  decimal.Decimal
+	Total    decimal.Decimal
+	Children []*ReportLine
+}
+
+// Debit returns the ledger's own balance when it sits on the debit side of a trial balance.
+func (l *ReportLine) Debit() decimal.Decimal {
+	return decimal.Max(netDebit(l.Type, l.Balance), decimal.Zero)
+}
+
+// Credit returns the ledger's own balance when it sits on the credit side of a trial balance.
+func (l *ReportLine) Credit() decimal.Decimal {
+	return decimal.Max(netDebit(l.Type, l.Balance).Neg(), decimal.Zero)
+}
+
+// ReportOption changes how a report is built.
+type ReportOption func(options *reportOptions)
+
+type reportOptions struct {
+	convert bool
+}
+
+// ConvertCurrencies reports the balances of every currency, converted to the report currency at the rates
+// in effect at the end of the report. Without it a report only covers balances held in its currency.
+func ConvertCurrencies() ReportOption {
+	return func(options *reportOptions) {
+		options.convert = true
+	}
+}
+
+// TrialBalance lists the cleared balance of every ledger in a currency at an instant.
+// Balanced is false when total debits differ from total credits, which means the ledger is corrupt.
+// Rates holds the rate each other currency was converted at, when the report converts currencies.
+type TrialBalance struct {
+	Currency     string
+	AsOf         time.Time
+	Rates        map[string]decimal.Decimal
+	Lines        []*ReportLine
+	TotalDebits  decimal.Decimal
+	TotalCredits decimal.Decimal
+	Balanced     bool
+}
+
+// Imbalance returns by how much total debits exceed total credits.
+func (tb *TrialBalance) Imbalance() decimal.Decimal {
+	return tb.TotalDebits.Sub(tb.TotalCredits)
+}
+
+// BalanceSheet sets what the ledgers own against what they owe at an instant. Income and expenses not yet
+// closed into capital are carried as CurrentEarnings so that assets equal liabilities, capital and earnings.
+type BalanceSheet struct {
+	Currency         string
+	AsOf             time.Time
+	Rates            map[string]decimal.Decimal
+	Assets           []*ReportLine
+	Liabilities      []*ReportLine
+	Capital          []*ReportLine
+	TotalAssets      decimal.Decimal
+	TotalLiabilities decimal.Decimal
+	TotalCapital     decimal.Decimal
+	CurrentEarnings  decimal.Decimal
+	Balanced         bool
+}
+
+// IncomeStatement shows the income earned and the expenses incurred over the period (From, To].
+// Converted amounts are taken at the rates in effect at To.
+type IncomeStatement struct {
+	Currency      string
+	From          time.Time
+	To            time.Time
+	Rates         map[string]decimal.Decimal
+	Income        []*ReportLine
+	Expenses      []*ReportLine
+	TotalIncome   decimal.Decimal
+	TotalExpenses decimal.Decimal
+	NetIncome     decimal.Decimal
+}
+
+// ReportBusiness generates financial reports over the ledger hierarchy.
+type ReportBusiness interface {
+	TrialBalance(ctx context.Context, currency string, asOf time.Time, opts ...ReportOption) (*TrialBalance, error)
+	BalanceSheet(ctx context.Context, currency string, asOf time.Time, opts ...ReportOption) (*BalanceSheet, error)
+	IncomeStatement(
+		ctx context.Context,
+		currency string,
+		from, to time.Time,
+		opts ...ReportOption,
+	) (*IncomeStatement, error)
+}
+
+// reportBusiness implements the ReportBusiness interface.
+type reportBusiness struct {
+	ledgerRepo  repository.LedgerRepository
+	accountRepo repository.AccountRepository
+	fxRates     FXRateBusiness
+}
+
+// NewReportBusiness creates a new report business instance.
+func NewReportBusiness(
+	ledgerRepo repository.LedgerRepository,
+	accountRepo repository.AccountRepository,
+	fxRates FXRateBusiness,
+) ReportBusiness {
+	return &reportBusiness{
+		ledgerRepo:  ledgerRepo,
+		accountRepo: accountRepo,
+		fxRates:     fxRates,
+	}
+}
+
+// TrialBalance sums the cleared account balances of every ledger as of asOf and rolls them up
+// through the parent chain. Totals are taken from each ledger's own balance so nothing is counted twice.
+func (b *reportBusiness) TrialBalance(
+	ctx context.Context,
+	currency string,
+	asOf time.Time,
+	opts ...ReportOption,
+) (*TrialBalance, error) {
+	lines, rates, err := b.ledgerReport(ctx, currency, nil, asOf, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TrialBalance{
+		Currency:     currency,
+		AsOf:         asOf,
+		Rates:        rates,
+		Lines:        lines,
+		TotalDebits:  decimal.Zero,
+		TotalCredits: decimal.Zero,
+	}
+
+	walkReport(lines, func(line *ReportLine) {
+		report.TotalDebits = report.TotalDebits.Add(line.Debit())
+		report.TotalCredits = report.TotalCredits.Add(line.Credit())
+	})
+
+	report.Balanced = report.TotalDebits.Equal(report.TotalCredits)
+	if !report.Balanced {
+		util.Log(ctx).WithField("currency", currency).
+			WithField("total_debits", report.TotalDebits.String()).
+			WithField("total_credits", report.TotalCredits.String()).
+			Error("trial balance does not balance, the ledger is corrupt")
+	}
+
+	return report, nil
+}
+
+// BalanceSheet groups the root ledgers by type as of asOf. Child ledgers are reported under their root
+// whatever their own type, so a contra ledger reduces the section it is filed in.
+func (b *reportBusiness) BalanceSheet(
+	ctx context.Context,
+	currency string,
+	asOf time.Time,
+	opts ...ReportOption,
+) (*BalanceSheet, error) {
+	lines, rates, err := b.ledgerReport(ctx, currency, nil, asOf, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BalanceSheet{
+		Currency: currency,
+		AsOf:     asOf,
+		Rates:    rates,
+	}
+	report.Assets, report.TotalAssets = reportSection(lines, models.LedgerTypeAsset)
+	report.Liabilities, report.TotalLiabilities = reportSection(lines, models.LedgerTypeLiability)
+	report.Capital, report.TotalCapital = reportSection(lines, models.LedgerTypeCapital)
+
+	_, income := reportSection(lines, models.LedgerTypeIncome)
+	_, expenses := reportSection(lines, models.LedgerTypeExpense)
+	report.CurrentEarnings = income.Sub(expenses)
+
+	report.Balanced = report.TotalAssets.Equal(
+		report.TotalLiabilities.Add(report.TotalCapital).Add(report.CurrentEarnings))
+	if !report.Balanced {
+		util.Log(ctx).WithField("currency", currency).
+			WithField("total_assets", report.TotalAssets.String()).
+			WithField("total_liabilities", report.TotalLiabilities.String()).
+			WithField("total_capital", report.TotalCapital.String()).
+			WithField("current_earnings", report.CurrentEarnings.String()).
+			Error("balance sheet does not balance, the ledger is corrupt")
+	}
+
+	return report, nil
+}
+
+// IncomeStatement reports income minus expenses for the postings transacted in (from, to].
+func (b *reportBusiness) IncomeStatement(
+	ctx context.Context,
+	currency string,
+	from, to time.Time,
+	opts ...ReportOption,
+) (*IncomeStatement, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: %s is not before %s",
+			ErrReportPeriodInvalid, from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+
+	lines, rates, err := b.ledgerReport(ctx, currency, &from, to, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &IncomeStatement{
+		Currency: currency,
+		From:     from,
+		To:       to,
+		Rates:    rates,
+	}
+	report.Income, report.TotalIncome = reportSection(lines, models.LedgerTypeIncome)
+	report.Expenses, report.TotalExpenses = reportSection(lines, models.LedgerTypeExpense)
+	report.NetIncome = report.TotalIncome.Sub(report.TotalExpenses)
+
+	return report, nil
+}
+
+// reportSection returns the root lines of ledgerType and the sum of their totals.
+func reportSection(lines []*ReportLine, ledgerType string) ([]*ReportLine, decimal.Decimal) {
+	var section []*ReportLine
+	total := decimal.Zero
+	for _, line := range lines {
+		if line.Type != ledgerType {
+			continue
+		}
+		section = append(section, line)
+		total = total.Add(line.Total)
+	}
+	return section, total
+}
+
+// ledgerReport builds the ledger hierarchy with the balances posted in currencyCode over (from, to],
+// and when converting currencies those posted in every other currency with the rates they were taken at.
+func (b *reportBusiness) ledgerReport(
+	ctx context.Context,
+	currencyCode string,
+	from *time.Time,
+	to time.Time,
+	opts []ReportOption,
+) ([]*ReportLine, map[string]decimal.Decimal, error) {
+	options := &reportOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	currencyUnit, err := currency.ParseISO(currencyCode)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrReportCurrencyInvalid, currencyCode)
+	}
+
+	ledgers, err := b.ledgerRepo.ListAll(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	balances, err := b.accountRepo.LedgerBalances(ctx, currencyUnit.String(), from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !options.convert {
+		return buildReportLines(ledgers, balances), nil, nil
+	}
+
+	rates, err := b.convertBalances(ctx, currencyUnit, balances, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return buildReportLines(ledgers, balances), rates, nil
+}
+
+// convertBalances adds the ledger balances held in every other currency to balances, converted to
+// reportUnit at the rates in effect at to and rounded to its minor unit.
+func (b *reportBusiness) convertBalances(
+	ctx context.Context,
+	reportUnit currency.Unit,
+	balances map[string]decimal.Decimal,
+	from *time.Time,
+	to time.Time,
+) (map[string]decimal.Decimal, error) {
+	currencies, err := b.accountRepo.ListCurrencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scale, _ := currency.Standard.Rounding(reportUnit)
+	rates := map[string]decimal.Decimal{}
+	for _, code := range currencies {
+		if code == reportUnit.String() {
+			continue
+		}
+
+		foreign, balancesErr := b.accountRepo.LedgerBalances(ctx, code, from, to)
+		if balancesErr != nil {
+			return nil, balancesErr
+		}
+		if len(foreign) == 0 {
+			continue
+		}
+
+		rate, rateErr := b.fxRates.Rate(ctx, code, reportUnit.String(), to)
+		if rateErr != nil {
+			return nil, rateErr
+		}
+		rates[code] = rate
+
+		for ledgerID, balance := range foreign {
+			balances[ledgerID] = balances[ledgerID].Add(balance.Mul(rate).Round(int32(scale)))
+		}
+	}
+
+	return rates, nil
+}
+
+// buildReportLines arranges ledgers under their parents and rolls balances up from the leaves.
+// Ledgers whose parent is missing are reported as roots so no balance is dropped.
+func buildReportLines(ledgers []*models.Ledger, balances map[string]decimal.Decimal) []*ReportLine {
+	lines := make(map[string]*ReportLine, len(ledgers))
+	for _, ledger := range ledgers {
+		lines[ledger.ID] = &ReportLine{
+			LedgerID: ledger.ID,
+			ParentID: ledger.ParentID,
+			Type:     ledger.Type,
+			Balance:  balances[ledger.ID],
+		}
+	}
+
+	var roots []*ReportLine
+	for _, ledger := range ledgers {
+		line := lines[ledger.ID]
+		parent, ok := lines[ledger.ParentID]
+		if !ok || ledger.ParentID == ledger.ID {
+			roots = append(roots, line)
+			continue
+		}
+		parent.Children = append(parent.Children, line)
+	}
+
+	for _, root := range roots {
+		rollUp(root, map[string]bool{})
+	}
+
+	return roots
+}
+
+// rollUp sets the total of line from its own balance and its subtree, converting children of
+// another ledger type through their debit or credit side.
+func rollUp(line *ReportLine, visited map[string]bool) decimal.Decimal {
+	visited[line.LedgerID] = true
+
+	total := netDebit(line.Type, line.Balance)
+	for _, child := range line.Children {
+		if visited[child.LedgerID] {
+			continue
+		}
+		total = total.Add(rollUp(child, visited))
+	}
+
+	line.Total = netDebit(line.Type, total)
+	return total
+}
+
+// walkReport visits every line of a report, parents before their children.
+func walkReport(lines []*ReportLine, visit func(line *ReportLine)) {
+	for _, line := range lines {
+		visit(line)
+		walkReport(line.Children, visit)
+	}
+}
+
+// netDebit converts an amount in the natural sign of a ledger type to debits minus credits, and back.
+// ASSET and EXPENSE ledgers grow with debits; LIABILITY, INCOME and CAPITAL ledgers with credits.
+func netDebit(ledgerType string, amount decimal.Decimal) decimal.Decimal {
+	if isDebitNormal(ledgerType) {
+		return amount
+	}
+	return amount.Neg()
+}
+
+func isDebitNormal(ledgerType string) bool {
+	return ledgerType == models.LedgerTypeAsset || ledgerType == models.LedgerTypeExpense
+}
@@ -0,0 +1,150 @@
+package business_test
+
+import (
+	"testing"
+	"time"
+
+	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/pitabwire/frame/frametests/definition"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func findReportLine(lines []*business.ReportLine, ledgerID string) *business.ReportLine {
+	for _, line := range lines {
+		if line.LedgerID == ledgerID {
+			return line
+		}
+		found := findReportLine(line.Children, ledgerID)
+		if found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func (ts *TransactionsModelSuite) TestTrialBalance() {
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, depOpt *definition.DependencyOption) {
+		ctx, _, res := ts.CreateService(t, depOpt)
+		ts.setupFixtures(ctx, res)
+
+		_, err := res.LedgerBusiness.CreateLedger(ctx, &ledgerv1.CreateLedgerRequest{
+			Id:       "test-ledger-asset-child",
+			Type:     ledgerv1.LedgerType_ASSET,
+			ParentId: ts.ledger.ID,
+		})
+		require.NoError(t, err)
+
+		_, err = res.AccountBusiness.CreateAccount(ctx, &ledgerv1.CreateAccountRequest{
+			Id:       "c1",
+			LedgerId: "test-ledger-asset-child",
+			Currency: "UGX",
+		})
+		require.NoError(t, err)
+
+		for _, txn := range []struct {
+			id, dr, cr string
+			amount     int64
+		}{
+			{id: "trial-sale", dr: "a1", cr: "a2", amount: 100},
+			{id: "trial-child-sale", dr: "c1", cr: "a2", amount: 30},
+			{id: "trial-internal", dr: "b1", cr: "b2", amount: 15},
+		} {
+			_, err = res.TransactionBusiness.Transact(ctx, transfer(txn.id, txn.dr, txn.cr, txn.amount))
+			require.NoError(t, err)
+		}
+
+		report, err := res.ReportBusiness.TrialBalance(ctx, "UGX", time.Now().UTC())
+		require.NoError(t, err)
+
+		assert.True(t, report.Balanced)
+		assert.True(t, report.Imbalance().IsZero())
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(130)), utility.CleanDecimal(report.TotalDebits))
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(130)), utility.CleanDecimal(report.TotalCredits))
+
+		asset := findReportLine(report.Lines, ts.ledger.ID)
+		require.NotNil(t, asset)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(100)), utility.CleanDecimal(asset.Balance))
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(130)), utility.CleanDecimal(asset.Total))
+		require.Len(t, asset.Children, 1)
+		assert.Equal(t, "test-ledger-asset-child", asset.Children[0].LedgerID)
+
+		income := findReportLine(report.Lines, "test-ledger-income")
+		require.NotNil(t, income)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(130)), utility.CleanDecimal(income.Credit()))
+		assert.True(t, income.Debit().IsZero())
+
+		_, err = res.ReportBusiness.TrialBalance(ctx, "not-a-currency", time.Now().UTC())
+		require.ErrorIs(t, err, business.ErrReportCurrencyInvalid)
+	})
+}
+
+func (ts *TransactionsModelSuite) TestFinancialStatements() {
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, depOpt *definition.DependencyOption) {
+		ctx, _, res := ts.CreateService(t, depOpt)
+		ts.setupFixtures(ctx, res)
+
+		for _, ledger := range []struct {
+			id, account string
+			ledgerType  ledgerv1.LedgerType
+		}{
+			{id: "test-ledger-capital", account: "k1", ledgerType: ledgerv1.LedgerType_CAPITAL},
+			{id: "test-ledger-expense", account: "e1", ledgerType: ledgerv1.LedgerType_EXPENSE},
+		} {
+			_, err := res.LedgerBusiness.CreateLedger(ctx, &ledgerv1.CreateLedgerRequest{
+				Id:   ledger.id,
+				Type: ledger.ledgerType,
+			})
+			require.NoError(t, err)
+
+			_, err = res.AccountBusiness.CreateAccount(ctx, &ledgerv1.CreateAccountRequest{
+				Id:       ledger.account,
+				LedgerId: ledger.id,
+				Currency: "UGX",
+			})
+			require.NoError(t, err)
+		}
+
+		for _, txn := range []struct {
+			id, dr, cr string
+			amount     int64
+		}{
+			{id: "statements-capital", dr: "a1", cr: "k1", amount: 500},
+			{id: "statements-sale", dr: "a1", cr: "a2", amount: 100},
+			{id: "statements-rent", dr: "e1", cr: "a1", amount: 40},
+		} {
+			_, err := res.TransactionBusiness.Transact(ctx, transfer(txn.id, txn.dr, txn.cr, txn.amount))
+			require.NoError(t, err)
+		}
+
+		now := time.Now().UTC()
+
+		sheet, err := res.ReportBusiness.BalanceSheet(ctx, "UGX", now)
+		require.NoError(t, err)
+
+		assert.True(t, sheet.Balanced)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(560)), utility.CleanDecimal(sheet.TotalAssets))
+		assert.True(t, sheet.TotalLiabilities.IsZero())
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(500)), utility.CleanDecimal(sheet.TotalCapital))
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(60)), utility.CleanDecimal(sheet.CurrentEarnings))
+		require.Len(t, sheet.Assets, 1)
+		assert.Equal(t, ts.ledger.ID, sheet.Assets[0].LedgerID)
+
+		statement, err := res.ReportBusiness.IncomeStatement(ctx, "UGX", now.Add(-time.Hour), now)
+		require.NoError(t, err)
+
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(100)), utility.CleanDecimal(statement.TotalIncome))
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(40)), utility.CleanDecimal(statement.TotalExpenses))
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(60)), utility.CleanDecimal(statement.NetIncome))
+
+		earlier, err := res.ReportBusiness.IncomeStatement(ctx, "UGX", now.Add(-2*time.Hour), now.Add(-time.Hour))
+		require.NoError(t, err)
+		assert.True(t, earlier.NetIncome.IsZero())
+
+		_, err = res.ReportBusiness.IncomeStatement(ctx, "UGX", now, now.Add(-time.Hour))
+		require.ErrorIs(t, err, business.ErrReportPeriodInvalid)
+	})
+}
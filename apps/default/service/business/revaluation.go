@@ -0,0 +1,338 @@
+package business
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/apps/default/service/repository"
+	"github.com/pitabwire/frame/data"
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/currency"
+)
+
+// revaluationIDLayout stamps the instant of a revaluation into the ids of its transactions, which are numbered
+// in the order of the ledgers they revalue, e.g. FX_REVALUATION_20261016T000000_1.
+const (
+	revaluationIDPrefix = "FX_REVALUATION_"
+	revaluationIDLayout = "20060102T150405"
+)
+
+// RevaluationDataKey marks revaluation transactions in their data with the base currency they revalue into.
+const RevaluationDataKey = "fx_revaluation"
+
+// RevaluationConfig names the base currency foreign accounts are revalued into and the INCOME and EXPENSE
+// ledgers unrealised gains and losses are posted to.
+type RevaluationConfig struct {
+	BaseCurrency string
+	GainLedgerID string
+	LossLedgerID string
+}
+
+// RevaluationLine is the unrealised gain, or loss when negative, of one foreign currency account.
+type RevaluationLine struct {
+	AccountID string
+	Currency  string
+	Balance   decimal.Decimal
+	Rate      decimal.Decimal
+	BaseValue decimal.Decimal
+	Gain      decimal.Decimal
+}
+
+// Revaluation is a run of the revaluation job. It posts a transaction for each ledger with a gain or loss,
+// and Transactions is empty when none was found.
+type Revaluation struct {
+	BaseCurrency string
+	At           time.Time
+	Lines        []*RevaluationLine
+	Transactions []*models.Transaction
+}
+
+// RevaluationBusiness revalues foreign currency accounts into the base currency.
+type RevaluationBusiness interface {
+	Revalue(ctx context.Context, at time.Time) (*Revaluation, error)
+}
+
+// revaluationBusiness implements the RevaluationBusiness interface.
+type revaluationBusiness struct {
+	config              RevaluationConfig
+	ledgerRepo          repository.LedgerRepository
+	accountRepo         repository.AccountRepository
+	fxRateRepo          repository.FXRateRepository
+	fxRates             FXRateBusiness
+	transactionBusiness TransactionBusiness
+}
+
+// NewRevaluationBusiness creates a new revaluation business instance.
+func NewRevaluationBusiness(
+	config RevaluationConfig,
+	ledgerRepo repository.LedgerRepository,
+	accountRepo repository.AccountRepository,
+	fxRateRepo repository.FXRateRepository,
+	fxRates FXRateBusiness,
+	transactionBusiness TransactionBusiness,
+) RevaluationBusiness {
+	return &revaluationBusiness{
+		config:              config,
+		ledgerRepo:          ledgerRepo,
+		accountRepo:         accountRepo,
+		fxRateRepo:          fxRateRepo,
+		fxRates:             fxRates,
+		transactionBusiness: transactionBusiness,
+	}
+}
+
+// Revalue values every ASSET and LIABILITY account held in a foreign currency at the rate in effect at at
+// and posts the change in base currency value since its last revaluation as an unrealised gain or loss.
+// The change is carried in a base currency revaluation account of the account's ledger, named
+// <ledger>_FX_<base>, against the gain or loss account <ledger>_<base> of the configured ledgers; missing
+// accounts are opened on first use. Each ledger's change is posted in a transaction of its own, and the
+// transactions of a run are posted together with the revaluations they record or not at all. Runs into
+// the same base currency take turns, each starting from the revaluations of the one before, so an account
+// is not revalued again at or before the instant it was last revalued. The first revaluation of an
+// account only records its value, and movements since the last revaluation are taken at the new rate.
+// Run without claims, as by the scheduled job, it revalues the accounts of every tenant, and what it
+// records for an account keeps the tenancy of the account's ledger.
+func (b *revaluationBusiness) Revalue(ctx context.Context, at time.Time) (*Revaluation, error) {
+	baseUnit, err := currency.ParseISO(b.config.BaseCurrency)
+	if err != nil || b.config.GainLedgerID == "" || b.config.LossLedgerID == "" {
+		return nil, ErrFXRevaluationConfig
+	}
+
+	// Revaluations are stored to the microsecond, so a rerun of the same instant finds its own record.
+	at = at.UTC().Truncate(time.Microsecond)
+	result := &Revaluation{BaseCurrency: baseUnit.String(), At: at}
+
+	accounts, err := b.accountRepo.ListForeignAccounts(ctx, result.BaseCurrency,
+		models.LedgerTypeAsset, models.LedgerTypeLiability)
+	if err != nil {
+		return nil, err
+	}
+
+	accountIDs := make([]string, 0, len(accounts))
+	for _, account := range accounts {
+		accountIDs = append(accountIDs, account.ID)
+	}
+
+	var check func(accounts map[string]*models.Account, closedPeriod string) error
+	build := func(previous map[string]*models.FXRevaluation) ([]*models.Transaction, []*models.FXRevaluation, error) {
+		transactions, revaluations, buildErr := b.revalue(ctx, result, accounts, previous)
+		if buildErr != nil {
+			return nil, nil, buildErr
+		}
+
+		check, buildErr = b.transactionBusiness.PrepareLinked(ctx, transactions...)
+		if buildErr != nil {
+			return nil, nil, buildErr
+		}
+
+		return transactions, revaluations, nil
+	}
+
+	result.Transactions, err = b.fxRateRepo.PostRevaluation(ctx, result.BaseCurrency, accountIDs, build,
+		func(accounts map[string]*models.Account, closedPeriod string) error {
+			return check(accounts, closedPeriod)
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// revalue works out the revaluation at result.At of accounts from the last revaluation of each in
+// previous. It fills in result.Lines and returns the transactions posting the gains of each ledger and
+// the revaluations to record.
+func (b *revaluationBusiness) revalue(
+	ctx context.Context,
+	result *Revaluation,
+	accounts []*models.Account,
+	previous map[string]*models.FXRevaluation,
+) ([]*models.Transaction, []*models.FXRevaluation, error) {
+	baseUnit, _ := currency.ParseISO(result.BaseCurrency)
+	scale, _ := currency.Standard.Rounding(baseUnit)
+
+	result.Lines = nil
+	ledgerGains := map[string]decimal.Decimal{}
+	accountLedgers := make(map[string]string, len(accounts))
+	revaluations := make([]*models.FXRevaluation, 0, len(accounts))
+	for _, account := range accounts {
+		last, revalued := previous[account.ID]
+		if revalued && !last.RevaluedAt.Before(result.At) {
+			continue
+		}
+
+		rate, err := b.fxRates.Rate(ctx, account.Currency, result.BaseCurrency, result.At)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		line := &RevaluationLine{
+			AccountID: account.ID,
+			Currency:  account.Currency,
+			Balance:   account.Balance.Decimal,
+			Rate:      rate,
+			BaseValue: account.Balance.Decimal.Mul(rate).Round(int32(scale)),
+		}
+		if revalued {
+			// The balance held at the last revaluation changes value with the rate, newer movements do not.
+			line.Gain = netDebit(account.LedgerType,
+				last.Balance.Mul(rate).Round(int32(scale)).Sub(last.BaseValue))
+		}
+
+		revaluation := &models.FXRevaluation{
+			Currency:     account.Currency,
+			BaseCurrency: result.BaseCurrency,
+			Balance:      line.Balance,
+			Rate:         rate,
+			BaseValue:    line.BaseValue,
+			RevaluedAt:   result.At,
+		}
+		revaluation.CopyPartitionInfo(&account.BaseModel)
+		revaluation.GenID(ctx)
+		revaluation.ID = account.ID
+		revaluations = append(revaluations, revaluation)
+		accountLedgers[account.ID] = account.LedgerID
+
+		if line.Gain.IsZero() {
+			continue
+		}
+		result.Lines = append(result.Lines, line)
+		ledgerGains[account.LedgerID] = ledgerGains[account.LedgerID].Add(line.Gain)
+	}
+
+	for ledgerID, gain := range ledgerGains {
+		if gain.IsZero() {
+			delete(ledgerGains, ledgerID)
+		}
+	}
+
+	if len(ledgerGains) == 0 {
+		return nil, revaluations, nil
+	}
+
+	transactions, ledgerTransactions, err := b.revaluationTransactions(ctx, result.At, result.BaseCurrency,
+		ledgerGains)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, revaluation := range revaluations {
+		revaluation.TransactionID = ledgerTransactions[accountLedgers[revaluation.ID]]
+	}
+
+	return transactions, revaluations, nil
+}
+
+// revaluationTransactions builds the postings of the non-zero gain of each ledger, in the debit sense, to
+// its revaluation account against the gain account, or the loss account for a loss, in one transaction
+// per ledger. Each transaction takes the tenancy of the ledger it revalues, as the job runs without claims,
+// while the gain and loss ledgers are shared by every tenant. It returns the transactions and the id of
+// each ledger's transaction.
+func (b *revaluationBusiness) revaluationTransactions(
+	ctx context.Context,
+	at time.Time,
+	baseCurrency string,
+	ledgerGains map[string]decimal.Decimal,
+) ([]*models.Transaction, map[string]string, error) {
+	ledgerIDs := make([]string, 0, len(ledgerGains))
+	for ledgerID := range ledgerGains {
+		ledgerIDs = append(ledgerIDs, ledgerID)
+	}
+	sort.Strings(ledgerIDs)
+
+	transactions := make([]*models.Transaction, 0, len(ledgerIDs))
+	ledgerTransactions := make(map[string]string, len(ledgerIDs))
+	for _, ledgerID := range ledgerIDs {
+		gain := ledgerGains[ledgerID]
+		ledger, err := b.ledgerRepo.GetByID(ctx, ledgerID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		revaluationAccountID, err := b.revaluationAccount(ctx, ledgerID, ledgerID+"_FX_"+baseCurrency, baseCurrency)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		resultLedgerID := b.config.GainLedgerID
+		if gain.IsNegative() {
+			resultLedgerID = b.config.LossLedgerID
+		}
+		resultAccountID, err := b.revaluationAccount(ctx, resultLedgerID, resultLedgerID+"_"+baseCurrency, baseCurrency)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		amount := decimal.NewNullDecimal(gain.Abs())
+		transaction := &models.Transaction{
+			BaseModel: data.BaseModel{
+				ID: fmt.Sprintf("%s%s_%d", revaluationIDPrefix, at.Format(revaluationIDLayout), len(transactions)+1),
+			},
+			Currency:        baseCurrency,
+			TransactionType: ledgerv1.TransactionType_NORMAL.String(),
+			TransactedAt:    at,
+			ClearedAt:       at,
+			Data:            data.JSONMap{RevaluationDataKey: baseCurrency},
+			Entries: []*models.TransactionEntry{
+				{AccountID: revaluationAccountID, Amount: amount, Credit: gain.IsNegative()},
+				{AccountID: resultAccountID, Amount: amount, Credit: gain.IsPositive()},
+			},
+		}
+		transaction.CopyPartitionInfo(&ledger.BaseModel)
+		for _, entry := range transaction.Entries {
+			entry.CopyPartitionInfo(&ledger.BaseModel)
+		}
+		transactions = append(transactions, transaction)
+		ledgerTransactions[ledgerID] = transaction.GetID()
+	}
+
+	return transactions, ledgerTransactions, nil
+}
+
+// revaluationAccount returns accountID, opening it in ledgerID in baseCurrency with the ledger's tenancy
+// when it does not exist yet.
+func (b *revaluationBusiness) revaluationAccount(
+	ctx context.Context,
+	ledgerID, accountID, baseCurrency string,
+) (string, error) {
+	account, err := b.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return "", err
+	}
+	if account != nil {
+		return accountID, nil
+	}
+
+	ledger, err := b.ledgerRepo.GetByID(ctx, ledgerID)
+	if err != nil {
+		return "", fmt.Errorf("%w: ledger %s: %v", ErrFXRevaluationConfig, ledgerID, err)
+	}
+
+	switch {
+	case ledgerID == b.config.GainLedgerID && ledger.Type != models.LedgerTypeIncome,
+		ledgerID == b.config.LossLedgerID && ledger.Type != models.LedgerTypeExpense:
+		return "", fmt.Errorf("%w: %s is a %s ledger", ErrFXRevaluationLedgers, ledgerID, ledger.Type)
+	}
+
+	account = &models.Account{
+		LedgerID:   ledger.ID,
+		LedgerType: ledger.Type,
+		Currency:   baseCurrency,
+		Balance:    decimal.NewNullDecimal(decimal.Zero),
+		Data:       data.JSONMap{RevaluationDataKey: baseCurrency},
+	}
+	account.CopyPartitionInfo(&ledger.BaseModel)
+	account.GenID(ctx)
+	account.ID = accountID
+
+	err = b.accountRepo.Create(ctx, account)
+	if err != nil {
+		return "", err
+	}
+
+	return accountID, nil
+}
@@ -0,0 +1,335 @@
+package business
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/pitabwire/frame/data"
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/currency"
+)
+
+// reversalSuffix names a full reversal after the transaction it reverses, e.g. txn-42_REVERSAL.
+const reversalSuffix = "_REVERSAL"
+
+// ReversalEntry names an entry of the transaction to reverse and how much of it to reverse. A zero Amount
+// reverses all that remains of the entry.
+type ReversalEntry struct {
+	EntryID string
+	Amount  decimal.Decimal
+}
+
+// ReversalRequest reverses a NORMAL transaction in whole or in part. With neither Amount nor Entries all
+// that remains of it is reversed. Amount reverses that much of it, shared across its entries in proportion
+// to what remains of each. Entries reverse the named entries only and must balance. A transaction may be
+// reversed any number of times until nothing of it remains, and the reversals themselves are not
+// reversible, a reversal made in error is undone by posting again.
+//
+// An empty ID names a full reversal <id>_REVERSAL, while a partial reversal needs an ID of its own so that
+// a retry is not posted as another reversal. Reversing again with the same ID returns the reversal already
+// posted.
+type ReversalRequest struct {
+	ID            string
+	TransactionID string
+	Amount        decimal.Decimal
+	Entries       []*ReversalEntry
+	Data          data.JSONMap
+}
+
+// Reversible reports how much of a transaction its reversals have reversed and what remains reversible,
+// counting the debit side of the transaction.
+type Reversible struct {
+	TransactionID string
+	Currency      string
+	Amount        decimal.Decimal
+	Reversed      decimal.Decimal
+	Remaining     decimal.Decimal
+	Reversals     []string
+}
+
+// Reverse posts a reversal linked to the transaction it reverses. It is checked against the reversals
+// already posted under the transaction's lock, so concurrent reversals can never reverse more than it holds.
+func (b *transactionBusiness) Reverse(ctx context.Context, request *ReversalRequest) (*models.Transaction, error) {
+	if request.TransactionID == "" {
+		return nil, ErrTransactionIDRequired
+	}
+
+	if request.Amount.IsNegative() || request.Amount.IsPositive() && len(request.Entries) > 0 {
+		return nil, fmt.Errorf("%w: reverse %s by a positive amount or by entries",
+			ErrReversalInvalid, request.TransactionID)
+	}
+
+	reversalID := request.ID
+	if reversalID == "" {
+		if request.Amount.IsPositive() || len(request.Entries) > 0 {
+			return nil, fmt.Errorf("%w: partial reversal of %s needs an id",
+				ErrReversalInvalid, request.TransactionID)
+		}
+		reversalID = request.TransactionID + reversalSuffix
+	}
+
+	existing, err := b.transactionRepo.GetByID(ctx, reversalID)
+	if err == nil && existing.ReversalOf == request.TransactionID {
+		return existing, nil
+	}
+
+	var reversal *models.Transaction
+	build := func(original *models.Transaction, reversals []*models.Transaction) (*models.Transaction, error) {
+		if original.TransactionType != ledgerv1.TransactionType_NORMAL.String() {
+			return nil, apperrors.ErrTransactionTypeNotReversible.Extend(
+				fmt.Sprintf("transaction %s (type=%s) is not reversible", original.GetID(), original.TransactionType),
+			)
+		}
+
+		amounts, err := reversalAmounts(original, remainingAmounts(original, reversals), request)
+		if err != nil {
+			return nil, err
+		}
+
+		reversal = reversalOf(ctx, original, reversalID, amounts)
+		reversal.TransactedAt = time.Now()
+		reversal.CopyPartitionInfo(&original.BaseModel)
+		if request.Data != nil {
+			reversal.Data = request.Data
+		}
+
+		accounts, err := b.Validate(ctx, reversal)
+		if err != nil {
+			return nil, err
+		}
+
+		b.processTransactionEntriesWithAccounts(reversal, accounts)
+		return reversal, nil
+	}
+
+	check := func(accounts map[string]*models.Account, closedPeriod string) error {
+		return b.postingCheck(ctx, reversal)(accounts, closedPeriod)
+	}
+
+	posted, err := b.transactionRepo.PostReversal(ctx, request.TransactionID, build, check)
+	if errors.Is(err, ErrReversalInvalid) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, postingError(err)
+	}
+
+	return posted, nil
+}
+
+// GetReversible reports how much of the transaction with the given id remains reversible.
+func (b *transactionBusiness) GetReversible(ctx context.Context, id string) (*Reversible, error) {
+	if id == "" {
+		return nil, ErrTransactionIDRequired
+	}
+
+	original, err := b.transactionRepo.GetByID(ctx, id)
+	if err != nil {
+		if data.ErrorIsNoRows(err) {
+			return nil, apperrors.ErrTransactionNotFound.Extend(id)
+		}
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	reversals, err := b.transactionRepo.ListReversals(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	reversible := &Reversible{TransactionID: original.GetID(), Currency: original.Currency}
+	remaining := remainingAmounts(original, reversals)
+	for _, entry := range original.Entries {
+		if !entry.Credit {
+			reversible.Amount = reversible.Amount.Add(entry.Amount.Decimal.Abs())
+			reversible.Remaining = reversible.Remaining.Add(remaining[entry.GetID()])
+		}
+	}
+	reversible.Reversed = reversible.Amount.Sub(reversible.Remaining)
+
+	if original.TransactionType != ledgerv1.TransactionType_NORMAL.String() {
+		reversible.Remaining = decimal.Zero
+	}
+
+	for _, reversal := range reversals {
+		reversible.Reversals = append(reversible.Reversals, reversal.GetID())
+	}
+
+	return reversible, nil
+}
+
+// reversalOf builds a REVERSAL of original named id and linked to it, offsetting each entry by its amount
+// in amounts, or in full when amounts is nil. Original entry amounts carry their account's sign, so the
+// reversal takes their magnitude and lets signage be applied again in the opposite direction. The
+// entries of a full reversal are named after the entries they offset.
+func reversalOf(
+	ctx context.Context,
+	original *models.Transaction,
+	id string,
+	amounts map[string]decimal.Decimal,
+) *models.Transaction {
+	reversal := &models.Transaction{
+		Currency:        original.Currency,
+		TransactionType: ledgerv1.TransactionType_REVERSAL.String(),
+		ReversalOf:      original.GetID(),
+		Data:            original.Data,
+	}
+	reversal.GenID(ctx)
+	reversal.ID = id
+
+	for _, entry := range original.Entries {
+		amount := entry.Amount.Decimal.Abs()
+		if amounts != nil {
+			amount = amounts[entry.GetID()]
+		}
+		if amount.IsZero() {
+			continue
+		}
+
+		reversalEntry := &models.TransactionEntry{
+			AccountID: entry.AccountID,
+			Amount:    decimal.NewNullDecimal(amount),
+			Credit:    !entry.Credit, // Reverse the credit/debit
+		}
+		if id == original.GetID()+reversalSuffix {
+			reversalEntry.ID = entry.GetID() + reversalSuffix
+		}
+		reversal.Entries = append(reversal.Entries, reversalEntry)
+	}
+
+	return reversal
+}
+
+// remainingAmounts returns what remains reversible of each entry of original, keyed by entry id. What the
+// reversals took off an account in a direction is taken off the original's entries on that account in that
+// direction in turn.
+func remainingAmounts(original *models.Transaction, reversals []*models.Transaction) map[string]decimal.Decimal {
+	type side struct {
+		accountID string
+		credit    bool
+	}
+
+	reversed := map[side]decimal.Decimal{}
+	for _, reversal := range reversals {
+		for _, entry := range reversal.Entries {
+			key := side{accountID: entry.AccountID, credit: !entry.Credit}
+			reversed[key] = reversed[key].Add(entry.Amount.Decimal.Abs())
+		}
+	}
+
+	entries := slices.Clone(original.Entries)
+	slices.SortFunc(entries, func(a, b *models.TransactionEntry) int {
+		return strings.Compare(a.GetID(), b.GetID())
+	})
+
+	remaining := make(map[string]decimal.Decimal, len(entries))
+	for _, entry := range entries {
+		key := side{accountID: entry.AccountID, credit: entry.Credit}
+		amount := entry.Amount.Decimal.Abs()
+		taken := decimal.Min(amount, reversed[key])
+		reversed[key] = reversed[key].Sub(taken)
+		remaining[entry.GetID()] = amount.Sub(taken)
+	}
+
+	return remaining
+}
+
+// reversalAmounts works out how much of each entry of original a reversal request reverses.
+func reversalAmounts(
+	original *models.Transaction,
+	remaining map[string]decimal.Decimal,
+	request *ReversalRequest,
+) (map[string]decimal.Decimal, error) {
+	var amounts map[string]decimal.Decimal
+	switch {
+	case len(request.Entries) > 0:
+		amounts = map[string]decimal.Decimal{}
+		for _, line := range request.Entries {
+			left, ok := remaining[line.EntryID]
+			if !ok || line.Amount.IsNegative() {
+				return nil, fmt.Errorf("%w: entry %s of transaction %s cannot be reversed by %s",
+					ErrReversalInvalid, line.EntryID, original.GetID(), line.Amount)
+			}
+
+			amount := line.Amount
+			if amount.IsZero() {
+				amount = left
+			}
+
+			amounts[line.EntryID] = amounts[line.EntryID].Add(amount)
+			if amounts[line.EntryID].GreaterThan(left) {
+				return nil, apperrors.ErrTransactionReversalExceeded.Extend(
+					fmt.Sprintf("entry %s has %s remaining, %s requested", line.EntryID, left, amounts[line.EntryID]))
+			}
+		}
+
+	case request.Amount.IsPositive():
+		var err error
+		amounts, err = shareAmount(original, remaining, request.Amount)
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		amounts = remaining
+	}
+
+	for _, amount := range amounts {
+		if amount.IsPositive() {
+			return amounts, nil
+		}
+	}
+
+	return nil, apperrors.ErrTransactionReversalExceeded.Extend(
+		fmt.Sprintf("nothing remains to reverse of transaction %s", original.GetID()))
+}
+
+// shareAmount shares amount across the debit entries of original, and again across its credit entries, in
+// proportion to what remains of each. Shares are rounded to the currency's minor unit and the last entry
+// of each side takes what rounding leaves over.
+func shareAmount(
+	original *models.Transaction,
+	remaining map[string]decimal.Decimal,
+	amount decimal.Decimal,
+) (map[string]decimal.Decimal, error) {
+	unit, err := currency.ParseISO(original.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("%w: transaction %s currency %q", ErrReversalInvalid, original.GetID(), original.Currency)
+	}
+	scale, _ := currency.Standard.Rounding(unit)
+
+	amounts := map[string]decimal.Decimal{}
+	for _, credit := range []bool{false, true} {
+		var entries []*models.TransactionEntry
+		total := decimal.Zero
+		for _, entry := range original.Entries {
+			if entry.Credit == credit && remaining[entry.GetID()].IsPositive() {
+				entries = append(entries, entry)
+				total = total.Add(remaining[entry.GetID()])
+			}
+		}
+
+		if amount.GreaterThan(total) {
+			return nil, apperrors.ErrTransactionReversalExceeded.Extend(
+				fmt.Sprintf("transaction %s has %s remaining, %s requested", original.GetID(), total, amount))
+		}
+
+		left := amount
+		for index, entry := range entries {
+			share := left
+			if index < len(entries)-1 {
+				share = amount.Mul(remaining[entry.GetID()]).Div(total).Round(int32(scale))
+			}
+			amounts[entry.GetID()] = share
+			left = left.Sub(share)
+		}
+	}
+
+	return amounts, nil
+}
@@ -0,0 +1,100 @@
+package business_test
+
+import (
+	"testing"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/pitabwire/frame/frametests/definition"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (ts *TransactionsModelSuite) TestPartialReversals() {
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, depOpt *definition.DependencyOption) {
+		ctx, _, res := ts.CreateService(t, depOpt)
+		ts.setupFixtures(ctx, res)
+
+		txnBusiness := res.TransactionBusiness
+
+		_, err := txnBusiness.Transact(ctx, transfer("rev-t1", "a1", "a2", 100))
+		require.NoError(t, err)
+
+		partial := &business.ReversalRequest{
+			ID:            "rev-t1-refund-1",
+			TransactionID: "rev-t1",
+			Amount:        decimal.NewFromInt(30),
+		}
+		reversal, err := txnBusiness.Reverse(ctx, partial)
+		require.NoError(t, err)
+		assert.Equal(t, "rev-t1", reversal.ReversalOf)
+
+		retried, err := txnBusiness.Reverse(ctx, partial)
+		require.NoError(t, err, "Retrying a reversal should return the one posted")
+		assert.Equal(t, reversal.GetID(), retried.GetID())
+
+		reversible, err := txnBusiness.GetReversible(ctx, "rev-t1")
+		require.NoError(t, err)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(30)), utility.CleanDecimal(reversible.Reversed))
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(70)), utility.CleanDecimal(reversible.Remaining))
+		assert.Equal(t, []string{"rev-t1-refund-1"}, reversible.Reversals)
+
+		_, err = txnBusiness.Reverse(ctx, &business.ReversalRequest{
+			TransactionID: "rev-t1",
+			Amount:        decimal.NewFromInt(30),
+		})
+		require.ErrorIs(t, err, business.ErrReversalInvalid, "Partial reversals need an id")
+
+		_, err = txnBusiness.Reverse(ctx, &business.ReversalRequest{
+			ID:            "rev-t1-refund-2",
+			TransactionID: "rev-t1",
+			Amount:        decimal.NewFromInt(80),
+		})
+		require.ErrorIs(t, err, apperrors.ErrTransactionReversalExceeded)
+
+		full, err := txnBusiness.Reverse(ctx, &business.ReversalRequest{TransactionID: "rev-t1"})
+		require.NoError(t, err, "A full reversal should reverse what remains")
+		assert.Equal(t, "rev-t1_REVERSAL", full.GetID())
+
+		accounts, err := res.AccountRepository.ListByID(ctx, "a1")
+		require.NoError(t, err)
+		assert.True(t, accounts["a1"].Balance.Decimal.IsZero(), "A fully reversed transfer should leave no balance")
+
+		_, err = txnBusiness.Reverse(ctx, &business.ReversalRequest{
+			ID:            "rev-t1-refund-3",
+			TransactionID: "rev-t1",
+			Amount:        decimal.NewFromInt(1),
+		})
+		require.ErrorIs(t, err, apperrors.ErrTransactionReversalExceeded)
+
+		_, err = txnBusiness.Reverse(ctx, &business.ReversalRequest{TransactionID: "rev-t1_REVERSAL"})
+		require.ErrorIs(t, err, apperrors.ErrTransactionTypeNotReversible, "Reversals should not be reversible")
+
+		split := transfer("rev-t2", "a1", "a2", 70)
+		split.Entries = append(split.Entries, &models.TransactionEntry{
+			AccountID: "a2", Amount: decimal.NewNullDecimal(decimal.NewFromInt(30)), Credit: true,
+		})
+		split.Entries[0].Amount = decimal.NewNullDecimal(decimal.NewFromInt(100))
+		_, err = txnBusiness.Transact(ctx, split)
+		require.NoError(t, err)
+
+		reversal, err = txnBusiness.Reverse(ctx, &business.ReversalRequest{
+			ID:            "rev-t2-refund-1",
+			TransactionID: "rev-t2",
+			Amount:        decimal.NewFromInt(50),
+		})
+		require.NoError(t, err, "A transaction with several credits should be reversible in part")
+		assert.Equal(t, "rev-t2-refund-1", reversal.GetID())
+		assert.Len(t, reversal.Entries, 3)
+
+		_, err = txnBusiness.Reverse(ctx, &business.ReversalRequest{
+			ID:            "rev-t2-refund-2",
+			TransactionID: "rev-t2",
+			Entries:       []*business.ReversalEntry{{EntryID: "unknown-entry"}},
+		})
+		require.ErrorIs(t, err, business.ErrReversalInvalid)
+	})
+}
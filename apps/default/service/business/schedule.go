@@ -0,0 +1,45 @@
+package business
+
+import (
+	"context"
+	"time"
+
+	"github.com/pitabwire/frame/workerpool"
+	"github.com/pitabwire/util"
+)
+
+// RunPeriodically runs task on the worker pool every interval until ctx is done.
+// A failed run is logged and retried on the next tick; a non-positive interval disables the task.
+func RunPeriodically(
+	ctx context.Context,
+	workMan workerpool.Manager,
+	name string,
+	interval time.Duration,
+	task func(ctx context.Context) error,
+) error {
+	if interval <= 0 {
+		return nil
+	}
+
+	pool, err := workMan.GetPool()
+	if err != nil {
+		return err
+	}
+
+	return pool.Submit(ctx, func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				taskErr := task(ctx)
+				if taskErr != nil {
+					util.Log(ctx).WithError(taskErr).WithField("task", name).Error("scheduled task failed")
+				}
+			}
+		}
+	})
+}
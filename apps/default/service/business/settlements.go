@@ -0,0 +1,193 @@
+package business
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+)
+
+// Settlement actions. CLEAR settles pending transactions as paid. FAIL settles them as failed: each is
+// cleared and reversed in the same batch, so that it no longer counts towards any balance.
+const (
+	SettlementActionClear = "CLEAR"
+	SettlementActionFail  = "FAIL"
+)
+
+// settlementBatchLimit bounds the transactions settled by one batch, all of which are locked together.
+const settlementBatchLimit = 10000
+
+// SettlementItem names a pending transaction to settle and the reference it is settled under, e.g. the
+// line of the settlement file reporting it.
+type SettlementItem struct {
+	TransactionID string
+	Reference     string
+}
+
+// SettlementRequest settles a batch of pending transactions, named by Items or else matched by Query,
+// a search query as taken by SearchTransactions of which only pending NORMAL transactions are settled.
+// Reference applies to the transactions whose item gives none. ClearedAt defaults to now.
+type SettlementRequest struct {
+	Action    string
+	Items     []*SettlementItem
+	Query     string
+	Reference string
+	ClearedAt time.Time
+}
+
+// Settlement is the outcome of a batch: the transactions it cleared, the reversals posted for failed
+// ones and the ids of those left as they were because an earlier run had settled them.
+type Settlement struct {
+	Action    string
+	Settled   []*models.Transaction
+	Reversals []*models.Transaction
+	Skipped   []string
+}
+
+// Settle clears a batch of pending transactions, or fails them, all or none. Settling a transaction
+// again under the reference it was settled with leaves it as it is, so a settlement file can be replayed.
+func (b *transactionBusiness) Settle(ctx context.Context, request *SettlementRequest) (*Settlement, error) {
+	if request.Action != SettlementActionClear && request.Action != SettlementActionFail {
+		return nil, fmt.Errorf("%w: %q", ErrSettlementActionInvalid, request.Action)
+	}
+
+	if len(request.Items) == 0 && request.Query == "" {
+		return nil, ErrSettlementEmpty
+	}
+
+	settlements, err := b.settlementReferences(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(settlements) == 0 {
+		return &Settlement{Action: request.Action}, nil
+	}
+
+	clearedAt := request.ClearedAt
+	if clearedAt.IsZero() {
+		clearedAt = time.Now()
+	}
+
+	var reversals []*models.Transaction
+	var reverse func(pending []*models.Transaction) ([]*models.Transaction, error)
+	if request.Action == SettlementActionFail {
+		reverse = func(pending []*models.Transaction) ([]*models.Transaction, error) {
+			var reverseErr error
+			reversals, reverseErr = b.settlementReversals(ctx, pending, settlements)
+			return reversals, reverseErr
+		}
+	}
+
+	check := func(accounts map[string]*models.Account, closedPeriod string) error {
+		return b.postingCheck(ctx, reversals...)(accounts, closedPeriod)
+	}
+
+	settled, err := b.transactionRepo.SettleBatch(ctx, settlements, clearedAt, reverse, check)
+	if err != nil {
+		return nil, postingError(err)
+	}
+
+	settlement := &Settlement{Action: request.Action, Settled: settled, Reversals: reversals}
+	for _, transaction := range settled {
+		delete(settlements, transaction.GetID())
+	}
+	for id := range settlements {
+		settlement.Skipped = append(settlement.Skipped, id)
+	}
+	slices.Sort(settlement.Skipped)
+
+	return settlement, nil
+}
+
+// settlementReferences maps the transactions a request settles to their settlement references.
+func (b *transactionBusiness) settlementReferences(
+	ctx context.Context,
+	request *SettlementRequest,
+) (map[string]string, error) {
+	settlements := map[string]string{}
+	for _, item := range request.Items {
+		if item.TransactionID == "" {
+			return nil, ErrTransactionIDRequired
+		}
+
+		reference := item.Reference
+		if reference == "" {
+			reference = request.Reference
+		}
+		settlements[item.TransactionID] = reference
+	}
+
+	if len(request.Items) == 0 && request.Query != "" {
+		result, err := b.transactionRepo.SearchAsESQ(ctx, request.Query)
+		if err != nil {
+			return nil, err
+		}
+
+		for {
+			res, ok := result.ReadResult(ctx)
+			if !ok {
+				break
+			}
+
+			if res.IsError() {
+				return nil, res.Error()
+			}
+
+			for _, transaction := range res.Item() {
+				if transaction.ClearedAt.IsZero() &&
+					transaction.TransactionType == ledgerv1.TransactionType_NORMAL.String() {
+					settlements[transaction.GetID()] = request.Reference
+				}
+			}
+
+			if len(settlements) > settlementBatchLimit {
+				break
+			}
+		}
+	}
+
+	if len(settlements) > settlementBatchLimit {
+		return nil, fmt.Errorf("%w: more than %d transactions", ErrSettlementTooLarge, settlementBatchLimit)
+	}
+
+	return settlements, nil
+}
+
+// settlementReversals builds the reversals of failed transactions, dated and cleared now and carrying the
+// settlement reference and tenancy of the transaction they reverse.
+func (b *transactionBusiness) settlementReversals(
+	ctx context.Context,
+	pending []*models.Transaction,
+	settlements map[string]string,
+) ([]*models.Transaction, error) {
+	now := time.Now()
+	reversals := make([]*models.Transaction, 0, len(pending))
+	for _, original := range pending {
+		if original.TransactionType != ledgerv1.TransactionType_NORMAL.String() {
+			return nil, apperrors.ErrTransactionTypeNotReversible.Extend(
+				fmt.Sprintf("transaction %s (type=%s) is not reversible", original.GetID(), original.TransactionType),
+			)
+		}
+
+		reversal := reversalOf(ctx, original, original.GetID()+reversalSuffix, nil)
+		reversal.TransactedAt = now
+		reversal.ClearedAt = now
+		reversal.SettlementReference = settlements[original.GetID()]
+		reversal.CopyPartitionInfo(&original.BaseModel)
+
+		accounts, err := b.Validate(ctx, reversal)
+		if err != nil {
+			return nil, err
+		}
+
+		b.processTransactionEntriesWithAccounts(reversal, accounts)
+		reversals = append(reversals, reversal)
+	}
+
+	return reversals, nil
+}
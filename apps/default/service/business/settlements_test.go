@@ -0,0 +1,119 @@
+package business_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/pitabwire/frame/data"
+	"github.com/pitabwire/frame/frametests/definition"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pendingTransfer(id, debitAccountID, creditAccountID string, amount int64) *models.Transaction {
+	transaction := transfer(id, debitAccountID, creditAccountID, amount)
+	transaction.ClearedAt = time.Time{}
+	return transaction
+}
+
+func (ts *TransactionsModelSuite) TestSettle() {
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, depOpt *definition.DependencyOption) {
+		ctx, _, res := ts.CreateService(t, depOpt)
+		ts.setupFixtures(ctx, res)
+
+		txnBusiness := res.TransactionBusiness
+
+		batched := pendingTransfer("settle-4", "a1", "a2", 10)
+		batched.Data = data.JSONMap{"batch": "b-7"}
+		for _, transaction := range []*models.Transaction{
+			pendingTransfer("settle-1", "a1", "a2", 100),
+			pendingTransfer("settle-2", "a1", "a2", 50),
+			pendingTransfer("settle-3", "a1", "a2", 30),
+			batched,
+		} {
+			_, err := txnBusiness.Transact(ctx, transaction)
+			require.NoError(t, err)
+		}
+
+		clearRequest := &business.SettlementRequest{
+			Action:    business.SettlementActionClear,
+			Reference: "file-1",
+			Items: []*business.SettlementItem{
+				{TransactionID: "settle-1", Reference: "file-1/line-1"},
+				{TransactionID: "settle-2"},
+			},
+		}
+		settlement, err := txnBusiness.Settle(ctx, clearRequest)
+		require.NoError(t, err)
+		assert.Len(t, settlement.Settled, 2)
+
+		settled, err := res.TransactionRepository.GetByID(ctx, "settle-1")
+		require.NoError(t, err)
+		assert.False(t, settled.ClearedAt.IsZero())
+		assert.Equal(t, "file-1/line-1", settled.SettlementReference)
+
+		accounts, err := res.AccountRepository.ListByID(ctx, "a1")
+		require.NoError(t, err)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(150)),
+			utility.CleanDecimal(accounts["a1"].Balance.Decimal))
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(40)),
+			utility.CleanDecimal(accounts["a1"].UnClearedBalance.Decimal))
+
+		settlement, err = txnBusiness.Settle(ctx, clearRequest)
+		require.NoError(t, err, "Replaying a settlement should change nothing")
+		assert.Empty(t, settlement.Settled)
+		assert.Equal(t, []string{"settle-1", "settle-2"}, settlement.Skipped)
+
+		_, err = txnBusiness.Settle(ctx, &business.SettlementRequest{
+			Action: business.SettlementActionClear,
+			Items: []*business.SettlementItem{
+				{TransactionID: "settle-3"},
+				{TransactionID: "settle-1", Reference: "file-2"},
+			},
+		})
+		require.ErrorIs(t, err, apperrors.ErrTransactionNotPending)
+
+		pending, err := res.TransactionRepository.GetByID(ctx, "settle-3")
+		require.NoError(t, err)
+		assert.True(t, pending.ClearedAt.IsZero(), "A rejected batch should settle nothing")
+
+		_, err = txnBusiness.Settle(ctx, &business.SettlementRequest{
+			Action: business.SettlementActionClear,
+			Items:  []*business.SettlementItem{{TransactionID: "settle-404"}},
+		})
+		require.ErrorIs(t, err, apperrors.ErrTransactionNotFound)
+
+		settlement, err = txnBusiness.Settle(ctx, &business.SettlementRequest{
+			Action:    business.SettlementActionFail,
+			Reference: "file-2",
+			Items:     []*business.SettlementItem{{TransactionID: "settle-3"}},
+		})
+		require.NoError(t, err)
+		require.Len(t, settlement.Reversals, 1)
+		assert.Equal(t, "settle-3_REVERSAL", settlement.Reversals[0].GetID())
+
+		accounts, err = res.AccountRepository.ListByID(ctx, "a1")
+		require.NoError(t, err)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(150)),
+			utility.CleanDecimal(accounts["a1"].Balance.Decimal), "A failed transaction should be reversed")
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(10)),
+			utility.CleanDecimal(accounts["a1"].UnClearedBalance.Decimal))
+
+		settlement, err = txnBusiness.Settle(ctx, &business.SettlementRequest{
+			Action:    business.SettlementActionClear,
+			Reference: "file-3",
+			Query:     `{"query": {"must": {"terms": [{"batch": "b-7"}]}}}`,
+		})
+		require.NoError(t, err)
+		require.Len(t, settlement.Settled, 1)
+		assert.Equal(t, "settle-4", settlement.Settled[0].GetID())
+
+		_, err = txnBusiness.Settle(ctx, &business.SettlementRequest{Action: "PAY", Query: "{}"})
+		require.ErrorIs(t, err, business.ErrSettlementActionInvalid)
+	})
+}
@@ -0,0 +1,203 @@
+package business
+
+import (
+	"context"
+	"time"
+
+	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/apps/default/service/repository"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/pitabwire/util"
+	"github.com/shopspring/decimal"
+)
+
+// Statement summarises the posted activity of an account over the period (From, To].
+// Balances include uncleared postings, matching the entries listed on the statement.
+type Statement struct {
+	AccountID      string
+	Currency       string
+	From           time.Time
+	To             time.Time
+	OpeningBalance decimal.Decimal
+	ClosingBalance decimal.Decimal
+	TotalDebits    decimal.Decimal
+	TotalCredits   decimal.Decimal
+	EntryCount     int
+}
+
+// StatementLine is a statement entry together with the account balance once it was applied.
+type StatementLine struct {
+	*models.TransactionEntry
+	RunningBalance decimal.Decimal
+}
+
+// Value returns the unsigned amount of the line; Credit tells which side it was posted to.
+func (l *StatementLine) Value() decimal.Decimal {
+	return l.Amount.Decimal.Abs()
+}
+
+// ToAPI converts the line to a transaction entry carrying the running balance.
+func (l *StatementLine) ToAPI() *ledgerv1.TransactionEntry {
+	amount := utility.ToMoney(l.Currency, l.Value())
+	balance := utility.ToMoney(l.Currency, l.RunningBalance)
+
+	entry := &ledgerv1.TransactionEntry{
+		Id:            l.ID,
+		AccountId:     l.AccountID,
+		TransactionId: l.TransactionID,
+		TransactedAt:  l.TransactedAt.Format(time.RFC3339),
+		Amount:        &amount,
+		Credit:        l.Credit,
+		AccBalance:    &balance,
+	}
+
+	if !l.ClearedAt.IsZero() {
+		entry.ClearedAt = l.ClearedAt.Format(time.RFC3339)
+	}
+
+	return entry
+}
+
+// StatementBusiness builds account statements.
+type StatementBusiness interface {
+	// AccountStatement streams the statement lines of an account for the period (from, to] to consumer in
+	// batches. The statement passed along already has its opening and closing balances set,
+	// and the returned statement carries the debit and credit totals.
+	AccountStatement(
+		ctx context.Context,
+		accountID string,
+		from, to time.Time,
+		consumer func(ctx context.Context, statement *Statement, lines []*StatementLine) error,
+	) (*Statement, error)
+}
+
+// statementBusiness implements the StatementBusiness interface.
+type statementBusiness struct {
+	accountRepo     repository.AccountRepository
+	transactionRepo repository.TransactionRepository
+}
+
+// NewStatementBusiness creates a new statement business instance.
+func NewStatementBusiness(
+	accountRepo repository.AccountRepository,
+	transactionRepo repository.TransactionRepository,
+) StatementBusiness {
+	return &statementBusiness{
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+	}
+}
+
+// AccountStatement streams an account's entries ordered by transacted_at, each with its running balance.
+func (b *statementBusiness) AccountStatement(
+	ctx context.Context,
+	accountID string,
+	from, to time.Time,
+	consumer func(ctx context.Context, statement *Statement, lines []*StatementLine) error,
+) (*Statement, error) {
+	statement, err := b.openStatement(ctx, accountID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := b.transactionRepo.StatementEntries(ctx, accountID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	running := statement.OpeningBalance
+	for {
+		res, ok := result.ReadResult(ctx)
+		if !ok {
+			break
+		}
+
+		if res.IsError() {
+			return nil, res.Error()
+		}
+
+		lines := make([]*StatementLine, 0, len(res.Item()))
+		for _, entry := range res.Item() {
+			running = running.Add(entry.Amount.Decimal)
+			line := &StatementLine{TransactionEntry: entry, RunningBalance: running}
+			if entry.Credit {
+				statement.TotalCredits = statement.TotalCredits.Add(line.Value())
+			} else {
+				statement.TotalDebits = statement.TotalDebits.Add(line.Value())
+			}
+			lines = append(lines, line)
+		}
+
+		statement.EntryCount += len(lines)
+		err = consumer(ctx, statement, lines)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !running.Equal(statement.ClosingBalance) {
+		// Entries back-dated into the period while the statement was read can make the lines disagree
+		// with the balances taken when it was opened.
+		util.Log(ctx).WithField("account_id", accountID).
+			WithField("closing_balance", statement.ClosingBalance.String()).
+			WithField("running_balance", running.String()).
+			Warn("statement lines do not reconcile to the closing balance")
+	}
+
+	return statement, nil
+}
+
+// openStatement validates the period and computes the balances the account held at its start and end.
+func (b *statementBusiness) openStatement(
+	ctx context.Context,
+	accountID string,
+	from, to time.Time,
+) (*Statement, error) {
+	if accountID == "" {
+		return nil, ErrAccountIDRequired
+	}
+
+	if !to.After(from) {
+		return nil, ErrStatementPeriodInvalid
+	}
+
+	account, err := b.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	opening, err := b.accountRepo.AggregateBalancesAt(ctx, from, false, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	closing, err := b.accountRepo.AggregateBalancesAt(ctx, to, false, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Statement{
+		AccountID:      account.ID,
+		Currency:       account.Currency,
+		From:           from,
+		To:             to,
+		OpeningBalance: postedBalance(opening[accountID]),
+		ClosingBalance: postedBalance(closing[accountID]),
+		TotalDebits:    decimal.Zero,
+		TotalCredits:   decimal.Zero,
+	}, nil
+}
+
+// postedBalance returns the cleared and uncleared balance together, or zero if nothing was posted.
+func postedBalance(balance *models.AccountBalance) decimal.Decimal {
+	if balance == nil {
+		return decimal.Zero
+	}
+
+	return balance.Balance.Add(balance.UnClearedBalance)
+}
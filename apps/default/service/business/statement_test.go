@@ -0,0 +1,69 @@
+package business_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/pitabwire/frame/frametests/definition"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (ts *TransactionsModelSuite) TestAccountStatement() {
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, depOpt *definition.DependencyOption) {
+		ctx, _, res := ts.CreateService(t, depOpt)
+		ts.setupFixtures(ctx, res)
+
+		now := time.Now().UTC()
+
+		postings := []struct {
+			id       string
+			dr, cr   string
+			amount   int64
+			postedAt time.Time
+		}{
+			{id: "statement-opening", dr: "b1", cr: "b2", amount: 100, postedAt: now.Add(-3 * time.Hour)},
+			{id: "statement-debit", dr: "b1", cr: "b2", amount: 40, postedAt: now.Add(-2 * time.Hour)},
+			{id: "statement-credit", dr: "b2", cr: "b1", amount: 25, postedAt: now.Add(-time.Hour)},
+		}
+
+		for _, posting := range postings {
+			txn := transfer(posting.id, posting.dr, posting.cr, posting.amount)
+			txn.TransactedAt = posting.postedAt
+			_, err := res.TransactionBusiness.Transact(ctx, txn)
+			require.NoError(t, err)
+		}
+
+		var lines []*business.StatementLine
+		statement, err := res.StatementBusiness.AccountStatement(ctx, "b1", now.Add(-150*time.Minute), now,
+			func(_ context.Context, statement *business.Statement, batch []*business.StatementLine) error {
+				assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(100)),
+					utility.CleanDecimal(statement.OpeningBalance))
+				lines = append(lines, batch...)
+				return nil
+			})
+		require.NoError(t, err)
+
+		require.Len(t, lines, 2)
+		assert.Equal(t, "statement-debit", lines[0].TransactionID)
+		assert.False(t, lines[0].Credit)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(140)), utility.CleanDecimal(lines[0].RunningBalance))
+		assert.Equal(t, "statement-credit", lines[1].TransactionID)
+		assert.True(t, lines[1].Credit)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(25)), utility.CleanDecimal(lines[1].Value()))
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(115)), utility.CleanDecimal(lines[1].RunningBalance))
+
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(115)), utility.CleanDecimal(statement.ClosingBalance))
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(40)), utility.CleanDecimal(statement.TotalDebits))
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(25)), utility.CleanDecimal(statement.TotalCredits))
+		assert.Equal(t, 2, statement.EntryCount)
+
+		_, err = res.StatementBusiness.AccountStatement(ctx, "b1", now, now.Add(-time.Hour),
+			func(_ context.Context, _ *business.Statement, _ []*business.StatementLine) error { return nil })
+		require.ErrorIs(t, err, business.ErrStatementPeriodInvalid)
+	})
+}
@@ -0,0 +1,277 @@
+package business
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/apps/default/service/repository"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/pitabwire/frame/data"
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/currency"
+)
+
+// TemplateParamID names the parameter that sets the id of the transaction a template posts. Without it the
+// id is generated, so postings that may be retried should give one.
+const TemplateParamID = "id"
+
+// Templated transactions record in their data the template they were posted from and its parameters.
+const (
+	TemplateDataKey       = "template"
+	TemplateParamsDataKey = "template_params"
+)
+
+var (
+	// templateReference matches the {param} references of account and currency expressions.
+	templateReference = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+	// templateLegName matches the names legs may be given to be referred to by later formulas.
+	templateLegName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// TemplateBusiness stores named posting templates and posts transactions from them, so that clients name
+// an operation and its parameters instead of building debit and credit entries themselves.
+type TemplateBusiness interface {
+	SaveTemplate(ctx context.Context, template *models.PostingTemplate) (*models.PostingTemplate, error)
+	GetTemplate(ctx context.Context, name string) (*models.PostingTemplate, error)
+	ExpandTemplate(ctx context.Context, name string, params map[string]string) (*models.Transaction, error)
+	PostTemplate(ctx context.Context, name string, params map[string]string) (*models.Transaction, error)
+}
+
+// templateBusiness implements the TemplateBusiness interface.
+type templateBusiness struct {
+	templateRepo        repository.PostingTemplateRepository
+	transactionBusiness TransactionBusiness
+}
+
+// NewTemplateBusiness creates a new template business instance.
+func NewTemplateBusiness(
+	templateRepo repository.PostingTemplateRepository,
+	transactionBusiness TransactionBusiness,
+) TemplateBusiness {
+	return &templateBusiness{
+		templateRepo:        templateRepo,
+		transactionBusiness: transactionBusiness,
+	}
+}
+
+// SaveTemplate validates and stores template under its id, its name, replacing any template of that name.
+func (b *templateBusiness) SaveTemplate(
+	ctx context.Context,
+	template *models.PostingTemplate,
+) (*models.PostingTemplate, error) {
+	err := validateTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+
+	template.GenID(ctx)
+	err = b.templateRepo.SaveTemplate(ctx, template)
+	if err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// GetTemplate returns the template with the given name.
+func (b *templateBusiness) GetTemplate(ctx context.Context, name string) (*models.PostingTemplate, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w: a template needs a name", ErrPostingTemplateInvalid)
+	}
+
+	template, err := b.templateRepo.GetByID(ctx, name)
+	if err != nil {
+		if data.ErrorIsNoRows(err) {
+			return nil, fmt.Errorf("%w: %s", ErrPostingTemplateNotFound, name)
+		}
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return template, nil
+}
+
+// ExpandTemplate builds the transaction the named template posts for params without posting it. The
+// transaction balances and has one debit, but its accounts are only checked when it is posted.
+func (b *templateBusiness) ExpandTemplate(
+	ctx context.Context,
+	name string,
+	params map[string]string,
+) (*models.Transaction, error) {
+	template, err := b.GetTemplate(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return expandTemplate(ctx, template, params)
+}
+
+// PostTemplate expands the named template for params and posts the transaction through Transact.
+func (b *templateBusiness) PostTemplate(
+	ctx context.Context,
+	name string,
+	params map[string]string,
+) (*models.Transaction, error) {
+	transaction, err := b.ExpandTemplate(ctx, name, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.transactionBusiness.Transact(ctx, transaction)
+}
+
+func validateTemplate(template *models.PostingTemplate) error {
+	if template.GetID() == "" {
+		return fmt.Errorf("%w: a template needs a name", ErrPostingTemplateInvalid)
+	}
+	if template.Currency == "" {
+		return fmt.Errorf("%w: template %s needs a currency", ErrPostingTemplateInvalid, template.GetID())
+	}
+	if len(template.Legs) < 2 {
+		return fmt.Errorf("%w: template %s needs a debit and a credit leg", ErrPostingTemplateInvalid,
+			template.GetID())
+	}
+
+	names := map[string]bool{}
+	for i, leg := range template.Legs {
+		if leg.Account == "" {
+			return fmt.Errorf("%w: leg %d of template %s needs an account", ErrPostingTemplateInvalid,
+				i+1, template.GetID())
+		}
+
+		_, err := parseFormula(leg.Amount)
+		if err != nil {
+			return fmt.Errorf("%w: leg %d of template %s: %v", ErrPostingTemplateInvalid, i+1, template.GetID(), err)
+		}
+
+		if leg.Name != "" {
+			if !templateLegName.MatchString(leg.Name) || names[leg.Name] {
+				return fmt.Errorf("%w: leg %d of template %s is not uniquely named: %q", ErrPostingTemplateInvalid,
+					i+1, template.GetID(), leg.Name)
+			}
+			names[leg.Name] = true
+		}
+	}
+
+	return nil
+}
+
+// expandTemplate builds the transaction template posts for params. Leg amounts are worked out in order
+// and rounded to the currency's minor unit, and a named leg's amount shadows any parameter of that name.
+func expandTemplate(
+	ctx context.Context,
+	template *models.PostingTemplate,
+	params map[string]string,
+) (*models.Transaction, error) {
+	currencyCode, err := expandReferences(template.Currency, params)
+	if err != nil {
+		return nil, err
+	}
+
+	currencyUnit, err := currency.ParseISO(currencyCode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: currency %q", ErrPostingTemplateParams, currencyCode)
+	}
+	scale, _ := currency.Standard.Rounding(currencyUnit)
+
+	legAmounts := map[string]decimal.Decimal{}
+	lookup := func(name string) (decimal.Decimal, error) {
+		if amount, ok := legAmounts[name]; ok {
+			return amount, nil
+		}
+
+		value, ok := params[name]
+		if !ok {
+			return decimal.Zero, fmt.Errorf("missing parameter %s", name)
+		}
+
+		amount, parseErr := decimal.NewFromString(value)
+		if parseErr != nil {
+			return decimal.Zero, fmt.Errorf("parameter %s is not a number: %q", name, value)
+		}
+		return amount, nil
+	}
+
+	now := time.Now().UTC()
+	transaction := &models.Transaction{
+		Currency:        currencyUnit.String(),
+		TransactionType: ledgerv1.TransactionType_NORMAL.String(),
+		TransactedAt:    now,
+		Data:            data.JSONMap{TemplateDataKey: template.GetID(), TemplateParamsDataKey: params},
+	}
+	if !template.Pending {
+		transaction.ClearedAt = now
+	}
+
+	transaction.GenID(ctx)
+	if id := params[TemplateParamID]; id != "" {
+		transaction.ID = id
+	}
+
+	for i, leg := range template.Legs {
+		accountID, expandErr := expandReferences(leg.Account, params)
+		if expandErr != nil {
+			return nil, expandErr
+		}
+
+		formula, parseErr := parseFormula(leg.Amount)
+		if parseErr != nil {
+			return nil, fmt.Errorf("%w: leg %d of template %s: %v", ErrPostingTemplateInvalid,
+				i+1, template.GetID(), parseErr)
+		}
+
+		amount, evalErr := formula.eval(lookup)
+		if evalErr != nil {
+			return nil, fmt.Errorf("%w: leg %d of template %s: %v", ErrPostingTemplateParams,
+				i+1, template.GetID(), evalErr)
+		}
+
+		amount = amount.Round(int32(scale))
+		if amount.IsNegative() {
+			return nil, fmt.Errorf("%w: leg %d of template %s works out to %s", ErrPostingTemplateParams,
+				i+1, template.GetID(), amount)
+		}
+
+		if leg.Name != "" {
+			legAmounts[leg.Name] = amount
+		}
+		if amount.IsZero() {
+			continue
+		}
+
+		transaction.Entries = append(transaction.Entries, &models.TransactionEntry{
+			AccountID: accountID,
+			Amount:    decimal.NewNullDecimal(amount),
+			Credit:    leg.Credit,
+		})
+	}
+
+	if !transaction.IsZeroSum() || !transaction.IsTrueDrCr() {
+		return nil, fmt.Errorf("%w: template %s does not post one balanced debit for these parameters",
+			ErrPostingTemplateInvalid, template.GetID())
+	}
+
+	return transaction, nil
+}
+
+// expandReferences replaces the {param} references of expression with their parameter values.
+func expandReferences(expression string, params map[string]string) (string, error) {
+	var missing string
+	expanded := templateReference.ReplaceAllStringFunc(expression, func(reference string) string {
+		name := templateReference.FindStringSubmatch(reference)[1]
+		value, ok := params[name]
+		if !ok || value == "" {
+			missing = name
+		}
+		return value
+	})
+
+	if missing != "" {
+		return "", fmt.Errorf("%w: missing parameter %s for %q", ErrPostingTemplateParams, missing, expression)
+	}
+
+	return expanded, nil
+}
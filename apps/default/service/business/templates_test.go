@@ -0,0 +1,80 @@
+package business_test
+
+import (
+	"testing"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/pitabwire/frame/data"
+	"github.com/pitabwire/frame/frametests/definition"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (ts *TransactionsModelSuite) TestPostTemplate() {
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, depOpt *definition.DependencyOption) {
+		ctx, _, res := ts.CreateService(t, depOpt)
+		ts.setupFixtures(ctx, res)
+
+		templates := res.TemplateBusiness
+
+		_, err := templates.SaveTemplate(ctx, &models.PostingTemplate{
+			BaseModel: data.BaseModel{ID: "p2p-with-fee"},
+			Currency:  "{currency}",
+			Legs: []*models.PostingLeg{
+				{Name: "fee", Account: "a2", Credit: true, Amount: "min(amount * fee_rate%, 500)"},
+				{Account: "{payer}", Amount: "amount"},
+				{Account: "{payee}", Credit: true, Amount: "amount - fee"},
+			},
+		})
+		require.NoError(t, err)
+
+		params := map[string]string{
+			business.TemplateParamID: "p2p-1",
+			"currency":               "ugx",
+			"payer":                  "a1",
+			"payee":                  "a2",
+			"amount":                 "1000",
+			"fee_rate":               "1.5",
+		}
+		transaction, err := templates.PostTemplate(ctx, "p2p-with-fee", params)
+		require.NoError(t, err)
+		assert.Equal(t, "p2p-1", transaction.GetID())
+		assert.Equal(t, "UGX", transaction.Currency)
+		require.Len(t, transaction.Entries, 3)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(15)),
+			utility.CleanDecimal(transaction.Entries[0].Amount.Decimal.Abs()))
+
+		_, err = templates.PostTemplate(ctx, "p2p-with-fee", params)
+		require.NoError(t, err, "Posting a template again with the same id should not post twice")
+
+		accounts, err := res.AccountRepository.ListByID(ctx, "a1")
+		require.NoError(t, err)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(1000)),
+			utility.CleanDecimal(accounts["a1"].Balance.Decimal))
+
+		params["fee_rate"] = "0"
+		expanded, err := templates.ExpandTemplate(ctx, "p2p-with-fee", params)
+		require.NoError(t, err)
+		assert.Len(t, expanded.Entries, 2, "A leg working out to zero should be left out")
+
+		delete(params, "payee")
+		_, err = templates.PostTemplate(ctx, "p2p-with-fee", params)
+		require.ErrorIs(t, err, business.ErrPostingTemplateParams)
+
+		_, err = templates.PostTemplate(ctx, "p2p-unknown", params)
+		require.ErrorIs(t, err, business.ErrPostingTemplateNotFound)
+
+		_, err = templates.SaveTemplate(ctx, &models.PostingTemplate{
+			BaseModel: data.BaseModel{ID: "p2p-broken"},
+			Currency:  "UGX",
+			Legs: []*models.PostingLeg{
+				{Account: "{payer}", Amount: "amount *"},
+				{Account: "{payee}", Credit: true, Amount: "amount"},
+			},
+		})
+		require.ErrorIs(t, err, business.ErrPostingTemplateInvalid)
+	})
+}
@@ -36,6 +36,18 @@ type TransactionBusiness interface {
 		ctx context.Context, transaction2 *models.Transaction) (bool, error)
 	Transact(
 		ctx context.Context, transaction *models.Transaction) (*models.Transaction, error)
+	TransactLinked(ctx context.Context, transactions []*models.Transaction) ([]*models.Transaction, error)
+	PrepareLinked(ctx context.Context, transactions ...*models.Transaction,
+	) (func(accounts map[string]*models.Account, closedPeriod string) error, error)
+	Exchange(ctx context.Context, request *ExchangeRequest) ([]*models.Transaction, error)
+	Settle(ctx context.Context, request *SettlementRequest) (*Settlement, error)
+	Reverse(ctx context.Context, request *ReversalRequest) (*models.Transaction, error)
+	GetReversible(ctx context.Context, id string) (*Reversible, error)
+
+	GetHold(ctx context.Context, id string) (*models.Hold, error)
+	CaptureHold(ctx context.Context, request *CaptureRequest) (*models.Hold, *models.Transaction, error)
+	ReleaseHold(ctx context.Context, id string) (*models.Hold, error)
+	ExpireHolds(ctx context.Context, at time.Time) (int, error)
 }
 
 // transactionBusiness implements the TransactionBusiness interface.
@@ -43,18 +55,30 @@ type transactionBusiness struct {
 	workMan         workerpool.Manager
 	transactionRepo repository.TransactionRepository
 	accountRepo     repository.AccountRepository
+	adjustmentRole  string
+	fxPositions     map[string]string
+	holdTTL         time.Duration
 }
 
 // NewTransactionBusiness creates a new transaction business instance.
+// Only callers holding adjustmentRole may post into a closed accounting period, exchanges post
+// against the position account fxPositions holds for each currency, and reservations that set no
+// expiry of their own are released after holdTTL.
 func NewTransactionBusiness(
 	workMan workerpool.Manager,
 	accountRepo repository.AccountRepository,
 	transactionRepo repository.TransactionRepository,
+	adjustmentRole string,
+	fxPositions map[string]string,
+	holdTTL time.Duration,
 ) TransactionBusiness {
 	return &transactionBusiness{
 		workMan:         workMan,
 		transactionRepo: transactionRepo,
 		accountRepo:     accountRepo,
+		adjustmentRole:  adjustmentRole,
+		fxPositions:     fxPositions,
+		holdTTL:         holdTTL,
 	}
 }
 
@@ -115,9 +139,7 @@ func (b *transactionBusiness) CreateTransaction(
 		return nil, err
 	}
 
-	// Process transaction entries (apply business logic for balances and signage)
-	b.processTransactionEntriesWithAccounts(transactionModel, accountsMap)
-
+	// Signage is applied once in Transact, applying it here as well stored amounts unsigned
 	// Create the transaction through repository
 	result, err := b.Transact(ctx, transactionModel)
 	if err != nil {
@@ -267,15 +289,16 @@ func (b *transactionBusiness) UpdateTransaction(
 		}
 	}
 
+	var clearedAt time.Time
 	if existingTransaction.ClearedAt.IsZero() {
-		err = b.processClearanceUpdate(ctx, req, existingTransaction)
+		clearedAt, err = clearanceTime(req)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	// Update through repository
-	_, err = b.transactionRepo.Update(ctx, existingTransaction)
+	// Store the data and any clearance together, so a failed clearance leaves the transaction unchanged
+	err = b.transactionRepo.UpdateAndClear(ctx, existingTransaction, clearedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -284,7 +307,7 @@ func (b *transactionBusiness) UpdateTransaction(
 	return existingTransaction.ToAPI(), nil
 }
 
-// ReverseTransaction reverses a transaction by creating offsetting entries.
+// ReverseTransaction reverses all that remains of a transaction by creating offsetting entries.
 func (b *transactionBusiness) ReverseTransaction(
 	ctx context.Context,
 	req *ledgerv1.ReverseTransactionRequest,
@@ -294,39 +317,7 @@ func (b *transactionBusiness) ReverseTransaction(
 		return nil, ErrTransactionIDRequired
 	}
 
-	// Get the original transaction to reverse
-	originalTxn, err := b.transactionRepo.GetByID(ctx, req.GetId())
-	if err != nil {
-		return nil, apperrors.ErrSystemFailure.Override(err)
-	}
-
-	if originalTxn.TransactionType != ledgerv1.TransactionType_NORMAL.String() {
-		return nil, apperrors.ErrTransactionTypeNotReversible.Extend(
-			fmt.Sprintf("transaction (type=%s) is not reversible", originalTxn.TransactionType),
-		)
-	}
-
-	// Create a new reversal transaction instead of modifying the original
-	reversalTxn := &models.Transaction{
-		Currency:        originalTxn.Currency,
-		TransactionType: ledgerv1.TransactionType_REVERSAL.String(),
-		TransactedAt:    time.Now(),
-		Data:            originalTxn.Data,
-	}
-	reversalTxn.GenID(ctx)
-	reversalTxn.ID = fmt.Sprintf("%s_REVERSAL", originalTxn.ID)
-
-	// Create reversed entries
-	for _, entry := range originalTxn.Entries {
-		reversalTxn.Entries = append(reversalTxn.Entries, &models.TransactionEntry{
-			BaseModel: data.BaseModel{ID: fmt.Sprintf("%s_REVERSAL", entry.ID)},
-			AccountID: entry.AccountID,
-			Amount:    entry.Amount,
-			Credit:    !entry.Credit, // Reverse the credit/debit
-		})
-	}
-
-	reversedTxn, err := b.Transact(ctx, reversalTxn)
+	reversedTxn, err := b.Reverse(ctx, &ReversalRequest{TransactionID: req.GetId()})
 	if err != nil {
 		return nil, err
 	}
@@ -398,7 +389,9 @@ func (b *transactionBusiness) Validate(
 			return nil, apperrors.ErrTransactionHasNonZeroSum
 		}
 
-		if !txn.IsTrueDrCr() {
+		// A reversal mirrors what it reverses, so it has as many debits as the original had credits.
+		if ledgerv1.TransactionType_NORMAL.String() == txn.TransactionType && !txn.IsTrueDrCr() ||
+			!txn.HasDrCr() {
 			return nil, apperrors.ErrTransactionHasInvalidDrCrEntry
 		}
 	} else if ledgerv1.TransactionType_RESERVATION.String() == txn.TransactionType {
@@ -442,6 +435,13 @@ func (b *transactionBusiness) Validate(
 			)
 		}
 
+		if !isHoldSettlement(txn) {
+			postingErr := checkEntryPostable(entry, account)
+			if postingErr != nil {
+				return nil, postingErr
+			}
+		}
+
 		if !strings.EqualFold(txn.Currency, account.Currency) {
 			return nil, apperrors.ErrTransactionAccountsDifferCurrency.Extend(
 				fmt.Sprintf(
@@ -458,6 +458,37 @@ func (b *transactionBusiness) Validate(
 	return accountsMap, nil
 }
 
+// checkEntryPostable rejects entries against closed accounts or in a direction blocked by the account status.
+func checkEntryPostable(entry *models.TransactionEntry, account *models.Account) error {
+	if account.IsClosed() {
+		return apperrors.ErrAccountClosed.Extend(
+			fmt.Sprintf("entry [id=%s, account_id=%s] account was closed on %s",
+				entry.ID, entry.AccountID, account.ClosedAt.Format(time.RFC3339)),
+		)
+	}
+
+	return checkEntryAllowedByStatus(entry, account)
+}
+
+// checkEntryAllowedByStatus rejects entries whose direction is blocked by the account status.
+func checkEntryAllowedByStatus(entry *models.TransactionEntry, account *models.Account) error {
+	if entry.Credit && !account.AllowsCredit() {
+		return apperrors.ErrAccountCreditBlocked.Extend(
+			fmt.Sprintf("entry [id=%s, account_id=%s] account status is %s",
+				entry.ID, entry.AccountID, account.CurrentStatus()),
+		)
+	}
+
+	if !entry.Credit && !account.AllowsDebit() {
+		return apperrors.ErrAccountDebitBlocked.Extend(
+			fmt.Sprintf("entry [id=%s, account_id=%s] account status is %s",
+				entry.ID, entry.AccountID, account.CurrentStatus()),
+		)
+	}
+
+	return nil
+}
+
 // IsConflict says whether a transaction conflicts with an existing transaction.
 func (b *transactionBusiness) IsConflict(
 	ctx context.Context, transaction2 *models.Transaction) (bool, error) {
@@ -491,12 +522,15 @@ func (b *transactionBusiness) Transact(
 		return nil, apperrors.ErrSystemFailure.Override(aerr)
 	}
 
-	// Process transaction entries with account balances and signage
+	// Apply signage once, ledger types do not change between validation and posting
 	b.processTransactionEntriesWithAccounts(transaction, accountsMap)
 
-	// Try to create transaction with built-in conflict detection
-	// This handles the race condition between existence check and creation
-	err := b.transactionRepo.Create(ctx, transaction)
+	holdErr := b.setHoldExpiry(transaction)
+	if holdErr != nil {
+		return nil, holdErr
+	}
+
+	err := b.transactionRepo.Post(ctx, transaction, b.postingCheck(ctx, transaction))
 	if err == nil {
 		// Return the created transaction (no need for another GetByID call)
 		return transaction, nil
@@ -504,6 +538,10 @@ func (b *transactionBusiness) Transact(
 
 	// Handle duplicate transaction error
 	if !b.isDuplicateTransactionError(err) {
+		var appErr apperrors.ApplicationError
+		if errors.As(err, &appErr) {
+			return nil, appErr
+		}
 		return nil, apperrors.ErrSystemFailure.Override(err)
 	}
 
@@ -526,26 +564,86 @@ func (b *transactionBusiness) Transact(
 	return existingTransaction, nil
 }
 
-// processTransactionEntriesWithAccounts processes transaction entries with balance and signage logic.
+// TransactLinked posts transactions that must all be posted or none, each validated as by Transact.
+// Posting the same transactions again returns those already posted, and fails as conflicting when
+// any of them was posted with other entries or not at all.
+func (b *transactionBusiness) TransactLinked(
+	ctx context.Context,
+	transactions []*models.Transaction,
+) ([]*models.Transaction, error) {
+	check, err := b.PrepareLinked(ctx, transactions...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.transactionRepo.PostLinked(ctx, transactions, check)
+	if err == nil {
+		return transactions, nil
+	}
+
+	if !b.isDuplicateTransactionError(err) {
+		return nil, postingError(err)
+	}
+
+	return b.existingTransactions(ctx, transactions)
+}
+
+// PrepareLinked validates transactions and applies their signage for posting them together, and returns
+// the checks to make on them under the account locks. It serves callers that post transactions alongside
+// records of their own in one repository call, as TransactLinked does with nothing alongside.
+func (b *transactionBusiness) PrepareLinked(
+	ctx context.Context,
+	transactions ...*models.Transaction,
+) (func(accounts map[string]*models.Account, closedPeriod string) error, error) {
+	for _, transaction := range transactions {
+		if transaction.TransactedAt.IsZero() {
+			transaction.TransactedAt = time.Now()
+		}
+
+		accounts, err := b.Validate(ctx, transaction)
+		if err != nil {
+			return nil, err
+		}
+
+		b.processTransactionEntriesWithAccounts(transaction, accounts)
+	}
+
+	return b.postingCheck(ctx, transactions...), nil
+}
+
+// existingTransactions returns the posted transactions with the ids of transactions, which must all have
+// been posted with the same entries.
+func (b *transactionBusiness) existingTransactions(
+	ctx context.Context,
+	transactions []*models.Transaction,
+) ([]*models.Transaction, error) {
+	existing := make([]*models.Transaction, 0, len(transactions))
+	for _, transaction := range transactions {
+		posted, err := b.transactionRepo.GetByID(ctx, transaction.GetID())
+		if err != nil {
+			if data.ErrorIsNoRows(err) {
+				return nil, apperrors.ErrTransactionIsConfilicting
+			}
+			return nil, apperrors.ErrSystemFailure.Override(err)
+		}
+
+		if !containsSameElements(posted.Entries, transaction.Entries) {
+			return nil, apperrors.ErrTransactionIsConfilicting
+		}
+		existing = append(existing, posted)
+	}
+
+	return existing, nil
+}
+
+// processTransactionEntriesWithAccounts applies debit/credit signage to the entries based on account ledger types.
 func (b *transactionBusiness) processTransactionEntriesWithAccounts(
 	transaction *models.Transaction,
 	accountsMap map[string]*models.Account,
 ) {
-	// Define ledger type mappings for debit/credit rules
-	typedLedgerMap := make(map[string][]string)
-	typedLedgerMap[models.LedgerTypeAsset] = []string{"CR", "DR"}
-	typedLedgerMap[models.LedgerTypeExpense] = []string{"DR", "CR"}
-	typedLedgerMap[models.LedgerTypeLiability] = []string{"CR", "DR"}
-	typedLedgerMap[models.LedgerTypeIncome] = []string{"CR", "DR"}
-	typedLedgerMap[models.LedgerTypeCapital] = []string{"CR", "DR"}
-
-	// Process all transaction entries atomically
 	for _, line := range transaction.Entries {
 		account := accountsMap[line.AccountID]
 
-		// Set the account balance snapshot at transaction time
-		line.Balance = decimal.NewNullDecimal(account.Balance.Decimal)
-
 		// Apply signage based on double-entry bookkeeping rules (DEADCLIC)
 		// Debit: Expense, Asset | Credit: Liability, Income, Capital
 		if line.Credit &&
@@ -557,12 +655,228 @@ func (b *transactionBusiness) processTransactionEntriesWithAccounts(
 	}
 }
 
+// postingCheck returns the checks made on transactions under the account row locks, taken in account
+// id order, so that the account state checked and the balances snapshotted onto the entries cannot
+// change until the transactions commit.
+func (b *transactionBusiness) postingCheck(
+	ctx context.Context,
+	transactions ...*models.Transaction,
+) func(accounts map[string]*models.Account, closedPeriod string) error {
+	return func(accounts map[string]*models.Account, closedPeriod string) error {
+		for _, transaction := range transactions {
+			err := b.checkPeriodOpen(ctx, transaction, closedPeriod)
+			if err != nil {
+				return err
+			}
+
+			err = checkLockedAccounts(transaction, accounts)
+			if err != nil {
+				return err
+			}
+
+			snapshotEntryBalances(transaction, accounts)
+			err = checkFunds(transaction, accounts)
+			if err != nil {
+				return err
+			}
+
+			advanceBalances(transaction, accounts)
+		}
+		return nil
+	}
+}
+
+// checkPeriodOpen rejects postings into a closed accounting period unless the caller may post adjustments,
+// in which case the transaction records the period it adjusts.
+func (b *transactionBusiness) checkPeriodOpen(
+	ctx context.Context,
+	transaction *models.Transaction,
+	closedPeriod string,
+) error {
+	if closedPeriod == "" {
+		return nil
+	}
+
+	if !hasRole(ctx, b.adjustmentRole) {
+		return apperrors.ErrPeriodClosed.Extend(
+			fmt.Sprintf("transaction %s is dated %s in closed period %s",
+				transaction.GetID(), transaction.TransactedAt.Format(time.RFC3339), closedPeriod),
+		)
+	}
+
+	transaction.AdjustedPeriod = closedPeriod
+	return nil
+}
+
+// checkLockedAccounts re-checks account state once the accounts are locked,
+// since an account may have been closed or blocked after Validate read it.
+func checkLockedAccounts(transaction *models.Transaction, accounts map[string]*models.Account) error {
+	for _, entry := range transaction.Entries {
+		account, ok := accounts[entry.AccountID]
+		if !ok {
+			return apperrors.ErrAccountNotFound.Extend(
+				fmt.Sprintf("Account %s was not found in the system", entry.AccountID),
+			)
+		}
+
+		if isHoldSettlement(transaction) {
+			continue
+		}
+
+		err := checkEntryPostable(entry, account)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// snapshotEntryBalances records on each entry the account balance just before the entry applies.
+// Balances must have been read under the posting lock; cleared entries for the same account
+// within one transaction build on each other.
+func snapshotEntryBalances(transaction *models.Transaction, accounts map[string]*models.Account) {
+	running := make(map[string]decimal.Decimal, len(accounts))
+	for accountID, account := range accounts {
+		running[accountID] = account.Balance.Decimal
+	}
+
+	cleared := !transaction.ClearedAt.IsZero() &&
+		transaction.TransactionType != ledgerv1.TransactionType_RESERVATION.String()
+
+	for _, line := range transaction.Entries {
+		line.Balance = decimal.NewNullDecimal(running[line.AccountID])
+		if cleared {
+			running[line.AccountID] = running[line.AccountID].Add(line.Amount.Decimal)
+		}
+	}
+}
+
+// advanceBalances applies a checked transaction to the locked accounts' balances, so the transactions
+// posted after it in the same batch are checked against what it leaves.
+func advanceBalances(transaction *models.Transaction, accounts map[string]*models.Account) {
+	for _, entry := range transaction.Entries {
+		account, ok := accounts[entry.AccountID]
+		if !ok {
+			continue
+		}
+
+		amount := entry.Amount.Decimal
+		switch {
+		case transaction.TransactionType == ledgerv1.TransactionType_RESERVATION.String():
+			account.ReservedBalance = decimal.NewNullDecimal(account.ReservedBalance.Decimal.Add(amount))
+			if amount.IsNegative() == (transaction.HoldID == "") {
+				account.ReservedOutgoing = decimal.NewNullDecimal(account.ReservedOutgoing.Decimal.Add(amount))
+			}
+		case transaction.ClearedAt.IsZero():
+			account.UnClearedBalance = decimal.NewNullDecimal(account.UnClearedBalance.Decimal.Add(amount))
+			account.UnClearedOutgoing = decimal.NewNullDecimal(
+				account.UnClearedOutgoing.Decimal.Add(decimal.Min(amount, decimal.Zero)))
+		default:
+			account.Balance = decimal.NewNullDecimal(account.Balance.Decimal.Add(amount))
+		}
+	}
+}
+
+// checkFunds rejects postings that would spend more than an account may. Entry amounts must already
+// carry the account's sign. Normal postings are held to the balance floor, where pending debits count
+// against it while pending credits do not, so uncleared funds cannot be spent ahead of clearing. Accounts
+// whose ledger holds them to their available balance also have new reservations checked, though not the
+// entries settling a hold, which only unwind one.
+func checkFunds(transaction *models.Transaction, accounts map[string]*models.Account) error {
+	normal := transaction.TransactionType == ledgerv1.TransactionType_NORMAL.String()
+	reservation := transaction.TransactionType == ledgerv1.TransactionType_RESERVATION.String() &&
+		!isHoldSettlement(transaction)
+	if !normal && !reservation {
+		return nil
+	}
+
+	deltas := map[string]decimal.Decimal{}
+	for _, entry := range transaction.Entries {
+		deltas[entry.AccountID] = deltas[entry.AccountID].Add(entry.Amount.Decimal)
+	}
+
+	for accountID, delta := range deltas {
+		account, ok := accounts[accountID]
+		if !ok || !delta.IsNegative() {
+			continue
+		}
+
+		var err error
+		switch {
+		case account.HoldsToAvailableBalance():
+			err = checkAvailableBalance(transaction, account, delta)
+		case normal:
+			err = checkBalanceFloor(account, delta)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkBalanceFloor rejects a delta that would take the account below its balance floor, if it has one.
+func checkBalanceFloor(account *models.Account, delta decimal.Decimal) error {
+	floor := account.EffectiveBalanceFloor()
+	if !floor.Valid {
+		return nil
+	}
+
+	projected := account.Balance.Decimal.
+		Add(account.UnClearedOutgoing.Decimal).
+		Add(delta)
+	if projected.LessThan(floor.Decimal) {
+		return apperrors.ErrAccountBalanceBelowFloor.Extend(
+			fmt.Sprintf("account_id=%s balance would be %s, floor is %s", account.ID, projected, floor.Decimal),
+		)
+	}
+
+	return nil
+}
+
+// checkAvailableBalance rejects a delta that would take the available balance below the balance floor,
+// or below zero for an account without one. A capture spends funds its own hold reserved, which are
+// released with it, so they are not counted against it twice.
+func checkAvailableBalance(transaction *models.Transaction, account *models.Account, delta decimal.Decimal) error {
+	available := account.AvailableBalance()
+	if transaction.HoldID != "" {
+		available = account.Balance.Decimal.
+			Add(account.UnClearedOutgoing.Decimal).
+			Add(decimal.Min(account.ReservedOutgoing.Decimal.Sub(delta), decimal.Zero))
+	}
+
+	floor := account.EffectiveBalanceFloor().Decimal
+	projected := available.Add(delta)
+	if projected.LessThan(floor) {
+		return apperrors.ErrAccountFundsUnavailable.Extend(
+			fmt.Sprintf("account_id=%s available balance would be %s, floor is %s", account.ID, projected, floor),
+		)
+	}
+
+	return nil
+}
+
+// postingError passes application errors through and reports anything else as a system failure.
+func postingError(err error) error {
+	var appErr apperrors.ApplicationError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return apperrors.ErrSystemFailure.Override(err)
+}
+
 // isDuplicateTransactionError checks if the error indicates a duplicate transaction.
 func (b *transactionBusiness) isDuplicateTransactionError(err error) bool {
 	if err == nil {
 		return false
 	}
 
+	if errors.Is(err, apperrors.ErrTransactionAlreadyExists) {
+		return true
+	}
+
 	// Check for unique constraint violations or duplicate key errors
 	errStr := strings.ToLower(err.Error())
 
@@ -593,30 +907,11 @@ func (b *transactionBusiness) isDuplicateTransactionError(err error) bool {
 	return false
 }
 
-// processClearanceUpdate handles the clearance time update for a transaction.
-func (b *transactionBusiness) processClearanceUpdate(
-	ctx context.Context,
-	req *ledgerv1.UpdateTransactionRequest,
-	existingTransaction *models.Transaction,
-) error {
+// clearanceTime parses the clearance time requested for a pending transaction, zero when none is.
+func clearanceTime(req *ledgerv1.UpdateTransactionRequest) (time.Time, error) {
 	if req.GetClearedAt() == "" {
-		return nil
-	}
-
-	clearanceTime, parseErr := time.Parse(DefaultTimestamLayout, req.GetClearedAt())
-	if parseErr != nil {
-		return parseErr
+		return time.Time{}, nil
 	}
 
-	accountsMap, validationErr := b.Validate(ctx, existingTransaction)
-	if validationErr != nil {
-		return validationErr
-	}
-
-	for _, line := range existingTransaction.Entries {
-		account := accountsMap[line.AccountID]
-		line.Balance = decimal.NewNullDecimal(account.Balance.Decimal)
-	}
-	existingTransaction.ClearedAt = clearanceTime
-	return nil
+	return time.Parse(DefaultTimestamLayout, req.GetClearedAt())
 }
@@ -134,6 +134,55 @@ func (ts *TransactionBusinessSuite) TestCreateTransactionWithBusinessValidation(
 	})
 }
 
+func (ts *TransactionBusinessSuite) TestCreateTransactionStoresSignedEntries() {
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, dep *definition.DependencyOption) {
+		ctx, _, resources := ts.CreateService(t, dep)
+		ts.setupFixtures(ctx, resources)
+
+		transactionBusiness := resources.TransactionBusiness
+
+		_, err := transactionBusiness.CreateTransaction(ctx, &ledgerv1.CreateTransactionRequest{
+			Id:       "signed-sale",
+			Currency: "USD",
+			Type:     ledgerv1.TransactionType_NORMAL,
+			Entries: []*ledgerv1.TransactionEntry{
+				{AccountId: "asset-account", Credit: false, Amount: &money.Money{CurrencyCode: "USD", Units: 100}},
+				{AccountId: "income-account", Credit: true, Amount: &money.Money{CurrencyCode: "USD", Units: 100}},
+			},
+			Cleared: true,
+		})
+		require.NoError(t, err, "Error creating sale")
+
+		_, err = transactionBusiness.CreateTransaction(ctx, &ledgerv1.CreateTransactionRequest{
+			Id:       "signed-refund",
+			Currency: "USD",
+			Type:     ledgerv1.TransactionType_NORMAL,
+			Entries: []*ledgerv1.TransactionEntry{
+				{AccountId: "asset-account", Credit: true, Amount: &money.Money{CurrencyCode: "USD", Units: 30}},
+				{AccountId: "income-account", Credit: false, Amount: &money.Money{CurrencyCode: "USD", Units: 30}},
+			},
+			Cleared: true,
+		})
+		require.NoError(t, err, "Error creating refund")
+
+		refund, err := transactionBusiness.GetTransaction(ctx, "signed-refund")
+		require.NoError(t, err, "Error retrieving refund")
+		require.Len(t, refund.GetEntries(), 2)
+		for _, entry := range refund.GetEntries() {
+			assert.Equal(t, int64(-30), entry.GetAmount().GetUnits(),
+				"entry on %s should be stored against the natural sign of its account", entry.GetAccountId())
+		}
+
+		asset, err := resources.AccountBusiness.GetAccount(ctx, "asset-account")
+		require.NoError(t, err)
+		assert.Equal(t, int64(70), asset.GetBalance().GetUnits(), "asset balance should net the refund")
+
+		income, err := resources.AccountBusiness.GetAccount(ctx, "income-account")
+		require.NoError(t, err)
+		assert.Equal(t, int64(70), income.GetBalance().GetUnits(), "income balance should net the refund")
+	})
+}
+
 func (ts *TransactionBusinessSuite) TestCreateTransactionNonZeroSum() {
 	ts.WithTestDependencies(ts.T(), func(t *testing.T, dep *definition.DependencyOption) {
 		ctx, _, resources := ts.CreateService(t, dep)
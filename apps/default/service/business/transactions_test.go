@@ -2,13 +2,17 @@ package business_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
 
 	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
 	"github.com/antinvestor/service-ledger/apps/default/service/models"
 	"github.com/antinvestor/service-ledger/apps/default/tests"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
 	"github.com/antinvestor/service-ledger/internal/utility"
 	_ "github.com/lib/pq"
 	"github.com/pitabwire/frame/data"
@@ -615,6 +619,392 @@ func (ts *TransactionsModelSuite) TestTransactWithBoundaryValues() {
 	})
 }
 
+// transfer builds a cleared normal transaction debiting drAccount and crediting crAccount.
+func transfer(id, drAccount, crAccount string, amount int64) *models.Transaction {
+	timeNow := time.Now().UTC()
+	return &models.Transaction{
+		BaseModel:       data.BaseModel{ID: id},
+		Currency:        "UGX",
+		TransactionType: ledgerv1.TransactionType_NORMAL.String(),
+		TransactedAt:    timeNow,
+		ClearedAt:       timeNow,
+		Entries: []*models.TransactionEntry{
+			{AccountID: drAccount, Amount: decimal.NewNullDecimal(decimal.NewFromInt(amount))},
+			{AccountID: crAccount, Amount: decimal.NewNullDecimal(decimal.NewFromInt(amount)), Credit: true},
+		},
+	}
+}
+
+func (ts *TransactionsModelSuite) TestTransactBalanceFloor() {
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, depOpt *definition.DependencyOption) {
+		ctx, _, res := ts.CreateService(t, depOpt)
+		ts.setupFixtures(ctx, res)
+
+		txnBusiness := res.TransactionBusiness
+
+		_, err := res.AccountBusiness.SetBalanceFloor(ctx, "a3", decimal.NewNullDecimal(decimal.Zero))
+		require.NoError(t, err)
+
+		_, err = txnBusiness.Transact(ctx, transfer("floor-overdraw", "a4", "a3", 10))
+		require.ErrorIs(t, err, apperrors.ErrAccountBalanceBelowFloor, "Empty account should not go negative")
+
+		_, err = txnBusiness.Transact(ctx, transfer("floor-fund", "a3", "a4", 30))
+		require.NoError(t, err)
+
+		_, err = txnBusiness.Transact(ctx, transfer("floor-spend", "a4", "a3", 20))
+		require.NoError(t, err, "Spending within the balance should pass")
+
+		_, err = txnBusiness.Transact(ctx, transfer("floor-overspend", "a4", "a3", 20))
+		require.ErrorIs(t, err, apperrors.ErrAccountBalanceBelowFloor, "Spending beyond the balance should fail")
+
+		_, err = res.LedgerBusiness.SetBalanceFloor(ctx, ts.ledger.ID, decimal.NewNullDecimal(decimal.NewFromInt(-100)))
+		require.NoError(t, err)
+
+		_, err = txnBusiness.Transact(ctx, transfer("ledger-overdraft", "a2", "b1", 100))
+		require.NoError(t, err, "Accounts should inherit the ledger overdraft limit")
+
+		_, err = txnBusiness.Transact(ctx, transfer("ledger-overdraft-exceeded", "a2", "b1", 1))
+		require.ErrorIs(t, err, apperrors.ErrAccountBalanceBelowFloor, "Ledger overdraft limit should be enforced")
+	})
+}
+
+func (ts *TransactionsModelSuite) TestTransactAvailableBalance() {
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, depOpt *definition.DependencyOption) {
+		ctx, _, res := ts.CreateService(t, depOpt)
+		ts.setupFixtures(ctx, res)
+
+		txnBusiness := res.TransactionBusiness
+
+		_, err := res.LedgerBusiness.SetFundsPolicy(ctx, ts.ledger.ID, "OVERDRAFT")
+		require.ErrorIs(t, err, business.ErrFundsPolicyInvalid)
+
+		_, err = res.LedgerBusiness.SetFundsPolicy(ctx, ts.ledger.ID, models.FundsPolicyAvailable)
+		require.NoError(t, err)
+
+		_, err = txnBusiness.Transact(ctx, transfer("available-fund", "a1", "a2", 100))
+		require.NoError(t, err)
+
+		_, err = txnBusiness.Transact(ctx, reservation("available-hold", "a1", 60, nil))
+		require.NoError(t, err)
+
+		account, err := res.AccountBusiness.GetAccountBalances(ctx, "a1")
+		require.NoError(t, err)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(40)), utility.CleanDecimal(account.AvailableBalance()))
+
+		_, err = txnBusiness.Transact(ctx, transfer("available-overspend", "a4", "a1", 50))
+		require.ErrorIs(t, err, apperrors.ErrAccountFundsUnavailable, "Reserved funds should not be spendable")
+
+		_, err = txnBusiness.Transact(ctx, reservation("available-overhold", "a1", 50, nil))
+		require.ErrorIs(t, err, apperrors.ErrAccountFundsUnavailable, "Reserved funds should not be reserved again")
+
+		_, err = txnBusiness.Transact(ctx, transfer("available-spend", "a4", "a1", 40))
+		require.NoError(t, err, "Spending within the available balance should pass")
+
+		_, _, err = txnBusiness.CaptureHold(ctx, &business.CaptureRequest{HoldID: "available-hold", AccountID: "a4"})
+		require.NoError(t, err, "A capture should spend the funds its hold reserved")
+
+		account, err = res.AccountBusiness.GetAccountBalances(ctx, "a1")
+		require.NoError(t, err)
+		assert.True(t, account.Balance.Decimal.IsZero())
+		assert.True(t, account.AvailableBalance().IsZero())
+	})
+}
+
+func (ts *TransactionsModelSuite) TestAvailableBalanceMixedPending() {
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, depOpt *definition.DependencyOption) {
+		ctx, _, res := ts.CreateService(t, depOpt)
+		ts.setupFixtures(ctx, res)
+
+		txnBusiness := res.TransactionBusiness
+
+		_, err := res.LedgerBusiness.SetFundsPolicy(ctx, ts.ledger.ID, models.FundsPolicyAvailable)
+		require.NoError(t, err)
+
+		_, err = txnBusiness.Transact(ctx, transfer("mixed-fund", "a1", "a2", 100))
+		require.NoError(t, err)
+
+		incomingHold := reservation("mixed-hold-in", "a1", 20, nil)
+		incomingHold.Entries[0].Credit = false
+		for _, transaction := range []*models.Transaction{
+			pendingTransfer("mixed-pending-out", "a4", "a1", 30),
+			pendingTransfer("mixed-pending-in", "a1", "a2", 50),
+			reservation("mixed-hold-out", "a1", 10, nil),
+			incomingHold,
+		} {
+			_, err = txnBusiness.Transact(ctx, transaction)
+			require.NoError(t, err)
+		}
+
+		account, err := res.AccountBusiness.GetAccountBalances(ctx, "a1")
+		require.NoError(t, err)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(60)), utility.CleanDecimal(account.AvailableBalance()),
+			"Pending and reserved credits should not offset pending and reserved debits")
+
+		_, err = txnBusiness.Transact(ctx, transfer("mixed-overspend", "a4", "a1", 61))
+		require.ErrorIs(t, err, apperrors.ErrAccountFundsUnavailable)
+
+		_, err = txnBusiness.Transact(ctx, transfer("mixed-spend", "a4", "a1", 60))
+		require.NoError(t, err)
+
+		_, err = txnBusiness.Settle(ctx, &business.SettlementRequest{
+			Action: business.SettlementActionClear,
+			Items: []*business.SettlementItem{
+				{TransactionID: "mixed-pending-out"},
+				{TransactionID: "mixed-pending-in"},
+			},
+		})
+		require.NoError(t, err)
+
+		account, err = res.AccountBusiness.GetAccountBalances(ctx, "a1")
+		require.NoError(t, err)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(60)), utility.CleanDecimal(account.Balance.Decimal))
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(50)), utility.CleanDecimal(account.AvailableBalance()),
+			"Cleared pending amounts should count in the balance alone")
+
+		_, err = txnBusiness.ReleaseHold(ctx, "mixed-hold-out")
+		require.NoError(t, err)
+
+		account, err = res.AccountBusiness.GetAccountBalances(ctx, "a1")
+		require.NoError(t, err)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(60)), utility.CleanDecimal(account.AvailableBalance()),
+			"Releasing a hold should free the funds it reserved")
+	})
+}
+
+func (ts *TransactionsModelSuite) TestTransactBalanceFloorConcurrent() {
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, depOpt *definition.DependencyOption) {
+		ctx, _, res := ts.CreateService(t, depOpt)
+		ts.setupFixtures(ctx, res)
+
+		txnBusiness := res.TransactionBusiness
+
+		_, err := res.AccountBusiness.SetBalanceFloor(ctx, "a3", decimal.NewNullDecimal(decimal.Zero))
+		require.NoError(t, err)
+
+		_, err = txnBusiness.Transact(ctx, transfer("concurrent-floor-fund", "a3", "a4", 100))
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		succeeded, rejected := 0, 0
+		for i := range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, txnErr := txnBusiness.Transact(ctx, transfer(fmt.Sprintf("concurrent-floor-%d", i), "a4", "a3", 20))
+
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case txnErr == nil:
+					succeeded++
+				case errors.Is(txnErr, apperrors.ErrAccountBalanceBelowFloor):
+					rejected++
+				default:
+					t.Errorf("unexpected error: %v", txnErr)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 5, succeeded, "Only the funded debits should pass")
+		assert.Equal(t, 5, rejected, "The remaining debits should hit the floor")
+
+		account, err := res.AccountRepository.GetByID(ctx, "a3")
+		require.NoError(t, err)
+		assert.True(t, account.Balance.Decimal.IsZero(), "Balance should stop exactly at the floor")
+	})
+}
+
+func (ts *TransactionsModelSuite) TestTransactConcurrentBalanceSnapshots() {
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, depOpt *definition.DependencyOption) {
+		ctx, _, res := ts.CreateService(t, depOpt)
+		ts.setupFixtures(ctx, res)
+
+		postings := 10
+
+		var wg sync.WaitGroup
+		for i := range postings {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, txnErr := res.TransactionBusiness.Transact(ctx, transfer(fmt.Sprintf("snapshot-%d", i), "b1", "b2", 10))
+				assert.NoError(t, txnErr)
+			}()
+		}
+		wg.Wait()
+
+		jobResult, err := res.TransactionRepository.SearchEntries(
+			ctx, `{"query": {"must": {"fields": [{"account_id": {"eq": "b1"}}]}}}`)
+		require.NoError(t, err)
+
+		var snapshots []string
+		for {
+			result, ok := jobResult.ReadResult(ctx)
+			if !ok {
+				break
+			}
+			require.NoError(t, result.Error())
+			for _, entry := range result.Item() {
+				snapshots = append(snapshots, entry.Balance.Decimal.String())
+			}
+		}
+
+		expected := make([]string, 0, postings)
+		for i := range postings {
+			expected = append(expected, decimal.NewFromInt(int64(i*10)).String())
+		}
+
+		assert.ElementsMatch(t, expected, snapshots, "Each posting should see the balance left by the previous one")
+	})
+}
+
+func (ts *TransactionsModelSuite) TestMaintainedBalancesMatchAggregate() {
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, depOpt *definition.DependencyOption) {
+		ctx, _, res := ts.CreateService(t, depOpt)
+		ts.setupFixtures(ctx, res)
+
+		txnBusiness := res.TransactionBusiness
+
+		_, err := txnBusiness.Transact(ctx, transfer("maintained-cleared", "b1", "b2", 70))
+		require.NoError(t, err)
+
+		pending := transfer("maintained-pending", "b1", "b2", 30)
+		pending.ClearedAt = time.Time{}
+		_, err = txnBusiness.Transact(ctx, pending)
+		require.NoError(t, err)
+
+		_, err = txnBusiness.Transact(ctx, &models.Transaction{
+			BaseModel:       data.BaseModel{ID: "maintained-reserve"},
+			Currency:        "UGX",
+			TransactionType: ledgerv1.TransactionType_RESERVATION.String(),
+			Entries: []*models.TransactionEntry{
+				{AccountID: "b1", Amount: decimal.NewNullDecimal(decimal.NewFromInt(15))},
+			},
+		})
+		require.NoError(t, err)
+
+		accounts, err := res.AccountRepository.ListByID(ctx, "b1", "b2")
+		require.NoError(t, err)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(70)), utility.CleanDecimal(accounts["b1"].Balance.Decimal))
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(30)),
+			utility.CleanDecimal(accounts["b1"].UnClearedBalance.Decimal))
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(15)),
+			utility.CleanDecimal(accounts["b1"].ReservedBalance.Decimal))
+
+		_, err = txnBusiness.UpdateTransaction(ctx, &ledgerv1.UpdateTransactionRequest{
+			Id:        "maintained-pending",
+			ClearedAt: time.Now().UTC().Format(business.DefaultTimestamLayout),
+		})
+		require.NoError(t, err)
+
+		accounts, err = res.AccountRepository.ListByID(ctx, "b1", "b2")
+		require.NoError(t, err)
+
+		aggregates, err := res.AccountRepository.AggregateBalances(ctx, "b1", "b2")
+		require.NoError(t, err)
+
+		for _, accountID := range []string{"b1", "b2"} {
+			account, aggregate := accounts[accountID], aggregates[accountID]
+			assert.Equal(t, utility.CleanDecimal(aggregate.Balance), utility.CleanDecimal(account.Balance.Decimal))
+			assert.Equal(t, utility.CleanDecimal(aggregate.UnClearedBalance),
+				utility.CleanDecimal(account.UnClearedBalance.Decimal))
+			assert.Equal(t, utility.CleanDecimal(aggregate.ReservedBalance),
+				utility.CleanDecimal(account.ReservedBalance.Decimal))
+			assert.Equal(t, aggregate.LastEntrySequence, account.LastSequence)
+		}
+
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(100)), utility.CleanDecimal(accounts["b1"].Balance.Decimal))
+		assert.True(t, accounts["b1"].UnClearedBalance.Decimal.IsZero(), "Cleared amounts should leave uncleared")
+	})
+}
+
+func (ts *TransactionsModelSuite) TestVerifyBalancesRecordsDrift() {
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, depOpt *definition.DependencyOption) {
+		ctx, _, res := ts.CreateService(t, depOpt)
+		ts.setupFixtures(ctx, res)
+
+		_, err := res.TransactionBusiness.Transact(ctx, transfer("verify-drift", "b1", "b2", 40))
+		require.NoError(t, err)
+
+		verifier := business.NewBalanceVerifier(res.AccountRepository, 1000)
+
+		discrepancies, err := verifier.VerifyBalances(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, discrepancies, "Maintained balances should match their entries")
+
+		err = res.AccountRepository.Pool().DB(ctx, false).
+			Exec(`UPDATE account_balances SET balance = balance + 5 WHERE id = ?`, "b1").Error
+		require.NoError(t, err)
+
+		discrepancies, err = verifier.VerifyBalances(ctx)
+		require.NoError(t, err)
+		require.Len(t, discrepancies, 1)
+		assert.Equal(t, "b1", discrepancies[0].AccountID)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(45)),
+			utility.CleanDecimal(discrepancies[0].CachedBalance))
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(40)),
+			utility.CleanDecimal(discrepancies[0].ComputedBalance))
+
+		recorded, err := res.AccountRepository.ListBalanceDiscrepancies(ctx, "b1")
+		require.NoError(t, err)
+		assert.Len(t, recorded, 1)
+	})
+}
+
+func (ts *TransactionsModelSuite) TestGetAccountBalanceAt() {
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, depOpt *definition.DependencyOption) {
+		ctx, _, res := ts.CreateService(t, depOpt)
+		ts.setupFixtures(ctx, res)
+
+		now := time.Now().UTC()
+
+		early := transfer("balance-at-early", "b1", "b2", 50)
+		early.TransactedAt = now.Add(-2 * time.Hour)
+		early.ClearedAt = now.Add(-2 * time.Hour)
+		_, err := res.TransactionBusiness.Transact(ctx, early)
+		require.NoError(t, err)
+
+		late := transfer("balance-at-late", "b1", "b2", 30)
+		late.TransactedAt = now.Add(-time.Hour)
+		late.ClearedAt = time.Time{}
+		_, err = res.TransactionBusiness.Transact(ctx, late)
+		require.NoError(t, err)
+
+		_, err = res.TransactionBusiness.UpdateTransaction(ctx, &ledgerv1.UpdateTransactionRequest{
+			Id:        "balance-at-late",
+			ClearedAt: now.Format(business.DefaultTimestamLayout),
+		})
+		require.NoError(t, err)
+
+		testcases := []struct {
+			name        string
+			at          time.Time
+			byClearedAt bool
+			balance     int64
+			uncleared   int64
+		}{
+			{name: "before any transaction", at: now.Add(-3 * time.Hour)},
+			{name: "after the first transaction", at: now.Add(-90 * time.Minute), balance: 50},
+			{name: "current clearing state", at: now.Add(-30 * time.Minute), balance: 80},
+			{name: "clearing state at the time", at: now.Add(-30 * time.Minute), byClearedAt: true,
+				balance: 50, uncleared: 30},
+			{name: "after clearing", at: now.Add(time.Minute), byClearedAt: true, balance: 80},
+		}
+
+		for _, tc := range testcases {
+			t.Run(tc.name, func(t *testing.T) {
+				account, balanceErr := res.AccountBusiness.GetAccountBalanceAt(ctx, "b1", tc.at, tc.byClearedAt)
+				require.NoError(t, balanceErr)
+				assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(tc.balance)),
+					utility.CleanDecimal(utility.FromMoney(account.GetBalance())))
+				assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(tc.uncleared)),
+					utility.CleanDecimal(utility.FromMoney(account.GetUnclearedBalance())))
+			})
+		}
+	})
+}
+
 func TestTransactionsModelSuite(t *testing.T) {
 	suite.Run(t, new(TransactionsModelSuite))
 }
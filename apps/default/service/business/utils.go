@@ -1,7 +1,11 @@
 package business
 
 import (
+	"context"
+	"slices"
+
 	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/pitabwire/frame/security"
 )
 
 // DefaultTimestamLayout is the timestamp layout followed in Ledger.
@@ -47,3 +51,13 @@ func containsSameElements(l1 []*models.TransactionEntry, l2 []*models.Transactio
 	}
 	return true
 }
+
+// hasRole reports whether the claims of ctx grant role. An empty role is never granted.
+func hasRole(ctx context.Context, role string) bool {
+	claims := security.ClaimsFromContext(ctx)
+	if role == "" || claims == nil {
+		return false
+	}
+
+	return slices.Contains(claims.GetRoles(), role)
+}
@@ -0,0 +1,84 @@
+package business
+
+import (
+	"context"
+	"sync"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/apps/default/service/repository"
+	"github.com/pitabwire/frame/telemetry"
+	"github.com/pitabwire/util"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const verificationMetricsPackage = "ledger_balance_verification"
+
+// BalanceVerifier proves the maintained account balances agree with the entries they summarise.
+type BalanceVerifier interface {
+	// VerifyBalances checks the next window of accounts and returns the discrepancies it recorded.
+	VerifyBalances(ctx context.Context) ([]*models.BalanceDiscrepancy, error)
+}
+
+// balanceVerifier walks all accounts in id order, one window per run, wrapping around at the end.
+type balanceVerifier struct {
+	accountRepo repository.AccountRepository
+	batchSize   int
+
+	mu     sync.Mutex
+	cursor string
+
+	verifiedCounter    metric.Int64Counter
+	discrepancyCounter metric.Int64Counter
+}
+
+// NewBalanceVerifier creates a verifier that checks batchSize accounts on each run.
+func NewBalanceVerifier(accountRepo repository.AccountRepository, batchSize int) BalanceVerifier {
+	return &balanceVerifier{
+		accountRepo: accountRepo,
+		batchSize:   batchSize,
+		verifiedCounter: telemetry.DimensionlessMeasure(
+			verificationMetricsPackage, "/accounts_verified", "Accounts whose balances were verified"),
+		discrepancyCounter: telemetry.DimensionlessMeasure(
+			verificationMetricsPackage, "/discrepancies", "Accounts whose balances differ from their entries"),
+	}
+}
+
+// VerifyBalances recomputes the balances of the next window of accounts from their entries,
+// persists a discrepancy for every account whose maintained balances differ and reports both counts as metrics.
+func (v *balanceVerifier) VerifyBalances(ctx context.Context) ([]*models.BalanceDiscrepancy, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	accountIDs, err := v.accountRepo.ListIDsAfter(ctx, v.cursor, v.batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(accountIDs) < v.batchSize {
+		v.cursor = ""
+	} else {
+		v.cursor = accountIDs[len(accountIDs)-1]
+	}
+
+	discrepancies, err := v.accountRepo.FindBalanceDiscrepancies(ctx, accountIDs...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = v.accountRepo.SaveBalanceDiscrepancies(ctx, discrepancies)
+	if err != nil {
+		return nil, err
+	}
+
+	v.verifiedCounter.Add(ctx, int64(len(accountIDs)))
+	v.discrepancyCounter.Add(ctx, int64(len(discrepancies)))
+
+	for _, d := range discrepancies {
+		util.Log(ctx).WithField("account_id", d.AccountID).
+			WithField("cached_balance", d.CachedBalance.String()).
+			WithField("computed_balance", d.ComputedBalance.String()).
+			Error("account balance does not match its entries")
+	}
+
+	return discrepancies, nil
+}
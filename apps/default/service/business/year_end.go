@@ -0,0 +1,164 @@
+package business
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/apps/default/service/repository"
+	"github.com/pitabwire/frame/data"
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/currency"
+)
+
+// yearEndSuffix joins the account closed and the fiscal year in the ids of year-end closing transactions,
+// e.g. sales-ugx_YEAR_END_2025, so an account is closed once for a year whichever accounts sort before it.
+const yearEndSuffix = "_YEAR_END_"
+
+// YearEndDataKey marks closing transactions in their data with the fiscal year they close.
+const YearEndDataKey = models.YearEndDataKey
+
+// YearEndLine is an INCOME or EXPENSE account swept by a year-end close, with its balance before the close.
+type YearEndLine struct {
+	AccountID  string
+	LedgerType string
+	Balance    decimal.Decimal
+}
+
+// YearEndClose is the closing of a fiscal year in one currency. Transactions zero each line into the
+// retained earnings account, one per line, and are empty when there was nothing to close. Posted is false
+// on a dry run.
+type YearEndClose struct {
+	Year                      int
+	Currency                  string
+	RetainedEarningsAccountID string
+	ClosingAt                 time.Time
+	Lines                     []*YearEndLine
+	NetIncome                 decimal.Decimal
+	Transactions              []*models.Transaction
+	Posted                    bool
+}
+
+// YearEndBusiness sweeps income and expenses into retained earnings at the end of a fiscal year.
+type YearEndBusiness interface {
+	CloseYear(
+		ctx context.Context,
+		year int,
+		currencyCode string,
+		retainedEarningsAccountID string,
+		dryRun bool,
+	) (*YearEndClose, error)
+}
+
+// yearEndBusiness implements the YearEndBusiness interface.
+type yearEndBusiness struct {
+	accountRepo         repository.AccountRepository
+	transactionBusiness TransactionBusiness
+	adminRole           string
+}
+
+// NewYearEndBusiness creates a new year-end business instance.
+func NewYearEndBusiness(
+	accountRepo repository.AccountRepository,
+	transactionBusiness TransactionBusiness,
+	adminRole string,
+) YearEndBusiness {
+	return &yearEndBusiness{
+		accountRepo:         accountRepo,
+		transactionBusiness: transactionBusiness,
+		adminRole:           adminRole,
+	}
+}
+
+// CloseYear zeroes the cleared balance of every INCOME and EXPENSE account in currencyCode into the
+// CAPITAL account retainedEarningsAccountID, each with a transaction of its own dated at the last instant
+// of the year. The transactions are posted together or not at all, and are left out of the year's
+// income statement. Their ids are derived from the account and year, so running the close again returns
+// the transactions already posted, and fails as conflicting if postings made since would change them.
+// Only callers holding the period admin role may close a year, and closing a year whose December is a
+// closed period also needs the adjustment role.
+func (b *yearEndBusiness) CloseYear(
+	ctx context.Context,
+	year int,
+	currencyCode string,
+	retainedEarningsAccountID string,
+	dryRun bool,
+) (*YearEndClose, error) {
+	if !hasRole(ctx, b.adminRole) {
+		return nil, fmt.Errorf("%w: %s", ErrPeriodForbidden, b.adminRole)
+	}
+
+	currencyUnit, err := currency.ParseISO(currencyCode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrYearEndCurrencyInvalid, currencyCode)
+	}
+
+	closingAt := time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC).Add(-time.Microsecond)
+	if year < 1 || closingAt.After(time.Now().UTC()) {
+		return nil, fmt.Errorf("%w: fiscal year %d", ErrPeriodNotEnded, year)
+	}
+
+	retained, err := b.accountRepo.GetByID(ctx, retainedEarningsAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if retained == nil || retained.LedgerType != models.LedgerTypeCapital ||
+		retained.Currency != currencyUnit.String() {
+		return nil, fmt.Errorf("%w: %s", ErrYearEndRetainedInvalid, retainedEarningsAccountID)
+	}
+
+	result := &YearEndClose{
+		Year:                      year,
+		Currency:                  currencyUnit.String(),
+		RetainedEarningsAccountID: retainedEarningsAccountID,
+		ClosingAt:                 closingAt,
+	}
+
+	accounts, err := b.accountRepo.ListNominalBalances(ctx, result.Currency, closingAt, year)
+	if err != nil {
+		return nil, err
+	}
+
+	// A debit-normal balance is zeroed with a credit and a credit-normal one with a debit, each against
+	// retained earnings.
+	for _, account := range accounts {
+		balance := account.Balance.Decimal
+		result.Lines = append(result.Lines, &YearEndLine{
+			AccountID:  account.ID,
+			LedgerType: account.LedgerType,
+			Balance:    balance,
+		})
+
+		debit := netDebit(account.LedgerType, balance)
+		amount := decimal.NewNullDecimal(debit.Abs())
+		result.NetIncome = result.NetIncome.Sub(debit)
+		result.Transactions = append(result.Transactions, &models.Transaction{
+			BaseModel: data.BaseModel{
+				ID: fmt.Sprintf("%s%s%d", account.ID, yearEndSuffix, year),
+			},
+			Currency:        result.Currency,
+			TransactionType: ledgerv1.TransactionType_NORMAL.String(),
+			TransactedAt:    closingAt,
+			ClearedAt:       closingAt,
+			Data:            data.JSONMap{YearEndDataKey: year},
+			Entries: []*models.TransactionEntry{
+				{AccountID: account.ID, Amount: amount, Credit: debit.IsPositive()},
+				{AccountID: retainedEarningsAccountID, Amount: amount, Credit: !debit.IsPositive()},
+			},
+		})
+	}
+
+	if dryRun || len(result.Transactions) == 0 {
+		return result, nil
+	}
+
+	result.Transactions, err = b.transactionBusiness.TransactLinked(ctx, result.Transactions)
+	if err != nil {
+		return nil, err
+	}
+	result.Posted = true
+
+	return result, nil
+}
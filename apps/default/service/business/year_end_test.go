@@ -0,0 +1,166 @@
+package business_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/pitabwire/frame/frametests/definition"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (ts *TransactionsModelSuite) TestCloseYear() {
+	ts.WithTestDependencies(ts.T(), func(t *testing.T, depOpt *definition.DependencyOption) {
+		ctx, _, res := ts.CreateService(t, depOpt)
+		ts.setupFixtures(ctx, res)
+
+		for _, ledger := range []struct {
+			id         string
+			accounts   []string
+			ledgerType ledgerv1.LedgerType
+		}{
+			{id: "test-ledger-capital", accounts: []string{"retained-ugx"}, ledgerType: ledgerv1.LedgerType_CAPITAL},
+			{id: "test-ledger-expense", accounts: []string{"e1", "e2"}, ledgerType: ledgerv1.LedgerType_EXPENSE},
+		} {
+			_, err := res.LedgerBusiness.CreateLedger(ctx, &ledgerv1.CreateLedgerRequest{
+				Id:   ledger.id,
+				Type: ledger.ledgerType,
+			})
+			require.NoError(t, err)
+
+			for _, account := range ledger.accounts {
+				_, err = res.AccountBusiness.CreateAccount(ctx, &ledgerv1.CreateAccountRequest{
+					Id:       account,
+					LedgerId: ledger.id,
+					Currency: "UGX",
+				})
+				require.NoError(t, err)
+			}
+		}
+
+		_, err := res.AccountBusiness.CreateAccount(ctx, &ledgerv1.CreateAccountRequest{
+			Id:       "a5",
+			LedgerId: "test-ledger-income",
+			Currency: "UGX",
+		})
+		require.NoError(t, err)
+
+		// The earlier year makes a loss and the later one a profit over two income accounts.
+		year := time.Now().UTC().Year() - 1
+		lossYear := year - 1
+		for _, txn := range []struct {
+			id, dr, cr string
+			amount     int64
+			year       int
+		}{
+			{id: "loss-sale", dr: "a1", cr: "a2", amount: 20, year: lossYear},
+			{id: "loss-rent", dr: "e1", cr: "a1", amount: 50, year: lossYear},
+			{id: "year-sale", dr: "a1", cr: "a2", amount: 100, year: year},
+			{id: "year-fees", dr: "a1", cr: "a5", amount: 30, year: year},
+			{id: "year-rent", dr: "e1", cr: "a1", amount: 40, year: year},
+			{id: "year-power", dr: "e2", cr: "a1", amount: 10, year: year},
+		} {
+			midYear := time.Date(txn.year, time.June, 15, 0, 0, 0, 0, time.UTC)
+			posting := transfer(txn.id, txn.dr, txn.cr, txn.amount)
+			posting.TransactedAt = midYear
+			posting.ClearedAt = midYear
+			_, err = res.TransactionBusiness.Transact(ctx, posting)
+			require.NoError(t, err)
+		}
+
+		_, err = res.YearEndBusiness.CloseYear(ctx, lossYear, "UGX", "retained-ugx", true)
+		require.ErrorIs(t, err, business.ErrPeriodForbidden, "Only period admins may close years")
+
+		adminCtx := withRole(ctx, "ledger_period_admin")
+
+		loss, err := res.YearEndBusiness.CloseYear(adminCtx, lossYear, "UGX", "retained-ugx", false)
+		require.NoError(t, err)
+		require.Len(t, loss.Lines, 2)
+		require.Len(t, loss.Transactions, 2)
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(-30)), utility.CleanDecimal(loss.NetIncome))
+
+		preview, err := res.YearEndBusiness.CloseYear(adminCtx, year, "UGX", "retained-ugx", true)
+		require.NoError(t, err)
+		assert.False(t, preview.Posted)
+		require.Len(t, preview.Lines, 4)
+		require.Len(t, preview.Transactions, 4)
+		for _, transaction := range preview.Transactions {
+			require.Len(t, transaction.Entries, 2, "Each account should be closed in a transaction of its own")
+			assert.True(t, transaction.IsTrueDrCr())
+			assert.True(t, transaction.IsZeroSum())
+		}
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(80)), utility.CleanDecimal(preview.NetIncome))
+
+		closing, err := res.YearEndBusiness.CloseYear(adminCtx, year, "UGX", "retained-ugx", false)
+		require.NoError(t, err)
+		assert.True(t, closing.Posted)
+		require.Len(t, closing.Transactions, 4)
+		closedIDs := map[string]string{}
+		for i, transaction := range closing.Transactions {
+			assert.Equal(t, preview.Transactions[i].GetID(), transaction.GetID())
+			closedIDs[closing.Lines[i].AccountID] = transaction.GetID()
+		}
+		assert.Equal(t, fmt.Sprintf("e1_YEAR_END_%d", year), closedIDs["e1"])
+
+		rerun, err := res.YearEndBusiness.CloseYear(adminCtx, year, "UGX", "retained-ugx", false)
+		require.NoError(t, err, "Closing a year again should be idempotent")
+		require.Len(t, rerun.Transactions, 4)
+		for i, transaction := range rerun.Transactions {
+			assert.Equal(t, closing.Transactions[i].GetID(), transaction.GetID())
+		}
+
+		sheet, err := res.ReportBusiness.BalanceSheet(ctx, "UGX", time.Now().UTC())
+		require.NoError(t, err)
+		assert.True(t, sheet.Balanced)
+		assert.True(t, sheet.CurrentEarnings.IsZero(), "Income and expenses should be swept into capital")
+		assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(50)), utility.CleanDecimal(sheet.TotalCapital))
+
+		for _, closed := range []struct {
+			year              int
+			income, netIncome int64
+		}{
+			{year: lossYear, income: 20, netIncome: -30},
+			{year: year, income: 130, netIncome: 80},
+		} {
+			from := time.Date(closed.year, time.January, 1, 0, 0, 0, 0, time.UTC).Add(-time.Microsecond)
+			to := time.Date(closed.year+1, time.January, 1, 0, 0, 0, 0, time.UTC).Add(-time.Microsecond)
+			statement, statementErr := res.ReportBusiness.IncomeStatement(ctx, "UGX", from, to)
+			require.NoError(t, statementErr)
+			assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(closed.income)),
+				utility.CleanDecimal(statement.TotalIncome), "Closing entries should not zero the year's income")
+			assert.Equal(t, utility.CleanDecimal(decimal.NewFromInt(closed.netIncome)),
+				utility.CleanDecimal(statement.NetIncome))
+		}
+
+		// A posting back-dated into the closed year changes only the closing transaction of its account.
+		backDated := transfer("late-power", "e2", "a1", 5)
+		backDated.TransactedAt = time.Date(year, time.December, 1, 0, 0, 0, 0, time.UTC)
+		backDated.ClearedAt = backDated.TransactedAt
+		_, err = res.TransactionBusiness.Transact(ctx, backDated)
+		require.NoError(t, err)
+
+		revised, err := res.YearEndBusiness.CloseYear(adminCtx, year, "UGX", "retained-ugx", true)
+		require.NoError(t, err)
+		require.Len(t, revised.Transactions, 4)
+		for i, transaction := range revised.Transactions {
+			assert.Equal(t, closedIDs[revised.Lines[i].AccountID], transaction.GetID(),
+				"Closing transaction ids should follow the account closed")
+		}
+
+		_, err = res.YearEndBusiness.CloseYear(adminCtx, year, "UGX", "retained-ugx", false)
+		require.ErrorIs(t, err, apperrors.ErrTransactionIsConfilicting,
+			"Closing again after a back-dated posting should not sweep the account twice")
+
+		_, err = res.YearEndBusiness.CloseYear(adminCtx, year, "UGX", "a1", true)
+		require.ErrorIs(t, err, business.ErrYearEndRetainedInvalid)
+
+		_, err = res.YearEndBusiness.CloseYear(adminCtx, time.Now().UTC().Year(), "UGX", "retained-ugx", true)
+		require.ErrorIs(t, err, business.ErrPeriodNotEnded)
+	})
+}
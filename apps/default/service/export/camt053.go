@@ -0,0 +1,163 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/shopspring/decimal"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.08"
+
+// ISO 20022 codes used in the statement.
+const (
+	camtCredit         = "CRDT"
+	camtDebit          = "DBIT"
+	camtOpeningBooked  = "OPBD"
+	camtClosingBooked  = "CLBD"
+	camtEntryBooked    = "BOOK"
+	camtEntryPending   = "PDNG"
+	camtLedgerTxCode   = "LEDGER_ENTRY"
+	camtDateTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+)
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtBalance struct {
+	Type     string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount   camtAmount `xml:"Amt"`
+	Ind      string     `xml:"CdtDbtInd"`
+	DateTime string     `xml:"Dt>DtTm"`
+}
+
+type camtEntry struct {
+	XMLName     xml.Name   `xml:"Ntry"`
+	Reference   string     `xml:"NtryRef"`
+	Amount      camtAmount `xml:"Amt"`
+	Ind         string     `xml:"CdtDbtInd"`
+	Status      string     `xml:"Sts>Cd"`
+	BookingDate string     `xml:"BookgDt>DtTm"`
+	ValueDate   string     `xml:"ValDt>DtTm,omitempty"`
+	ServicerRef string     `xml:"AcctSvcrRef"`
+	TxCode      string     `xml:"BkTxCd>Prtry>Cd"`
+}
+
+type camtAccount struct {
+	ID       string `xml:"Id>Othr>Id"`
+	Currency string `xml:"Ccy"`
+}
+
+type camtPeriod struct {
+	From string `xml:"FrDtTm"`
+	To   string `xml:"ToDtTm"`
+}
+
+type camtGroupHeader struct {
+	MessageID string `xml:"MsgId"`
+	CreatedAt string `xml:"CreDtTm"`
+}
+
+// camt053Writer renders a statement as an ISO 20022 camt.053 bank to customer statement seen from the
+// account holder. Balances and amounts are unsigned with CRDT or DBIT giving their direction;
+// cleared entries are booked and the rest pending.
+type camt053Writer struct {
+	enc       *xml.Encoder
+	createdAt time.Time
+}
+
+func newCAMT053Writer(out io.Writer) Writer {
+	return &camt053Writer{enc: xml.NewEncoder(out), createdAt: time.Now()}
+}
+
+func (w *camt053Writer) ContentType() string {
+	return "application/xml"
+}
+
+func (w *camt053Writer) FileExtension() string {
+	return "xml"
+}
+
+func (w *camt053Writer) Open(statement *business.Statement) error {
+	document := start("Document")
+	document.Attr = []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: camt053Namespace}}
+
+	statementID := camtStatementID(statement)
+
+	return encode(w.enc,
+		xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8"`)},
+		document,
+		start("BkToCstmrStmt"),
+		element{"GrpHdr", camtGroupHeader{MessageID: statementID, CreatedAt: camtDateTime(w.createdAt)}},
+		start("Stmt"),
+		element{"Id", statementID},
+		element{"CreDtTm", camtDateTime(w.createdAt)},
+		element{"FrToDt", camtPeriod{From: camtDateTime(statement.From), To: camtDateTime(statement.To)}},
+		element{"Acct", camtAccount{ID: statement.AccountID, Currency: statement.Currency}},
+		element{"Bal", camtBalanceOf(camtOpeningBooked, statement, statement.OpeningBalance, statement.From)},
+		element{"Bal", camtBalanceOf(camtClosingBooked, statement, statement.ClosingBalance, statement.To)},
+	)
+}
+
+func (w *camt053Writer) Lines(statement *business.Statement, lines []*business.StatementLine) error {
+	for _, line := range lines {
+		entry := camtEntry{
+			Reference:   line.ID,
+			Amount:      camtAmountOf(statement, balanceEffect(line)),
+			Ind:         camtIndicator(balanceEffect(line)),
+			Status:      camtEntryPending,
+			BookingDate: camtDateTime(line.TransactedAt),
+			ServicerRef: line.TransactionID,
+			TxCode:      camtLedgerTxCode,
+		}
+
+		if !line.ClearedAt.IsZero() {
+			entry.Status = camtEntryBooked
+			entry.ValueDate = camtDateTime(line.ClearedAt)
+		}
+
+		err := w.enc.Encode(entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.enc.Flush()
+}
+
+func (w *camt053Writer) Close(_ *business.Statement) error {
+	return encode(w.enc, end("Stmt"), end("BkToCstmrStmt"), end("Document"))
+}
+
+func camtStatementID(statement *business.Statement) string {
+	return statement.AccountID + "-" + statement.To.UTC().Format("20060102150405")
+}
+
+func camtDateTime(t time.Time) string {
+	return t.UTC().Format(camtDateTimeLayout)
+}
+
+func camtIndicator(amount decimal.Decimal) string {
+	if amount.IsNegative() {
+		return camtDebit
+	}
+	return camtCredit
+}
+
+func camtAmountOf(statement *business.Statement, amount decimal.Decimal) camtAmount {
+	return camtAmount{Currency: statement.Currency, Value: utility.MoneyString(statement.Currency, amount.Abs())}
+}
+
+func camtBalanceOf(code string, statement *business.Statement, amount decimal.Decimal, at time.Time) camtBalance {
+	return camtBalance{
+		Type:     code,
+		Amount:   camtAmountOf(statement, amount),
+		Ind:      camtIndicator(amount),
+		DateTime: camtDateTime(at),
+	}
+}
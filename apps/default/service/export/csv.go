@@ -0,0 +1,116 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"time"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/shopspring/decimal"
+)
+
+// CSV row types.
+const (
+	csvRowOpeningBalance = "OPENING_BALANCE"
+	csvRowEntry          = "ENTRY"
+	csvRowClosingBalance = "CLOSING_BALANCE"
+	csvRowTotals         = "TOTALS"
+)
+
+var csvHeader = []string{
+	"row_type", "transacted_at", "cleared_at", "transaction_id", "entry_id",
+	"currency", "debit", "credit", "balance",
+}
+
+// csvWriter renders a statement as it is posted in the ledger: one row per entry with the amount in
+// the debit or credit column, framed by opening and closing balance rows and followed by the totals.
+type csvWriter struct {
+	out *csv.Writer
+}
+
+func newCSVWriter(out io.Writer) Writer {
+	return &csvWriter{out: csv.NewWriter(out)}
+}
+
+func (w *csvWriter) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (w *csvWriter) FileExtension() string {
+	return "csv"
+}
+
+func (w *csvWriter) Open(statement *business.Statement) error {
+	err := w.out.Write(csvHeader)
+	if err != nil {
+		return err
+	}
+
+	return w.writeBalance(csvRowOpeningBalance, statement, statement.From, statement.OpeningBalance)
+}
+
+func (w *csvWriter) Lines(statement *business.Statement, lines []*business.StatementLine) error {
+	for _, line := range lines {
+		debit, credit := "", ""
+		if line.Credit {
+			credit = utility.MoneyString(statement.Currency, line.Value())
+		} else {
+			debit = utility.MoneyString(statement.Currency, line.Value())
+		}
+
+		clearedAt := ""
+		if !line.ClearedAt.IsZero() {
+			clearedAt = line.ClearedAt.UTC().Format(time.RFC3339)
+		}
+
+		err := w.out.Write([]string{
+			csvRowEntry,
+			line.TransactedAt.UTC().Format(time.RFC3339),
+			clearedAt,
+			line.TransactionID,
+			line.ID,
+			statement.Currency,
+			debit,
+			credit,
+			utility.MoneyString(statement.Currency, line.RunningBalance),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	w.out.Flush()
+	return w.out.Error()
+}
+
+func (w *csvWriter) Close(statement *business.Statement) error {
+	err := w.writeBalance(csvRowClosingBalance, statement, statement.To, statement.ClosingBalance)
+	if err != nil {
+		return err
+	}
+
+	err = w.out.Write([]string{
+		csvRowTotals, "", "", "", "", statement.Currency,
+		utility.MoneyString(statement.Currency, statement.TotalDebits),
+		utility.MoneyString(statement.Currency, statement.TotalCredits), "",
+	})
+	if err != nil {
+		return err
+	}
+
+	w.out.Flush()
+	return w.out.Error()
+}
+
+func (w *csvWriter) writeBalance(
+	rowType string,
+	statement *business.Statement,
+	at time.Time,
+	balance decimal.Decimal,
+) error {
+	return w.out.Write([]string{
+		rowType, at.UTC().Format(time.RFC3339), "", "", "", statement.Currency, "", "",
+		utility.MoneyString(statement.Currency, balance),
+	})
+}
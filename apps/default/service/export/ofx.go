@@ -0,0 +1,122 @@
+package export
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/internal/utility"
+)
+
+const ofxTimeLayout = "20060102150405.000[+0:UTC]"
+
+type ofxTransaction struct {
+	XMLName xml.Name `xml:"STMTTRN"`
+	Type    string   `xml:"TRNTYPE"`
+	Posted  string   `xml:"DTPOSTED"`
+	Amount  string   `xml:"TRNAMT"`
+	FitID   string   `xml:"FITID"`
+	Name    string   `xml:"NAME"`
+}
+
+type ofxBalance struct {
+	Amount string `xml:"BALAMT"`
+	AsOf   string `xml:"DTASOF"`
+}
+
+// ofxWriter renders a statement as an OFX 2.2 bank statement seen from the account holder:
+// amounts that grew the balance are credits, the rest debits.
+type ofxWriter struct {
+	enc *xml.Encoder
+}
+
+func newOFXWriter(out io.Writer) Writer {
+	return &ofxWriter{enc: xml.NewEncoder(out)}
+}
+
+func (w *ofxWriter) ContentType() string {
+	return "application/x-ofx"
+}
+
+func (w *ofxWriter) FileExtension() string {
+	return "ofx"
+}
+
+func (w *ofxWriter) Open(statement *business.Statement) error {
+	status := ofxStatus{Code: "0", Severity: "INFO"}
+
+	return encode(w.enc,
+		xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8" standalone="no"`)},
+		xml.ProcInst{Target: "OFX", Inst: []byte(
+			`OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"`)},
+		start("OFX"),
+		element{"SIGNONMSGSRSV1", ofxSignOn{
+			Status: status, Server: statement.To.UTC().Format(ofxTimeLayout), Language: "ENG",
+		}},
+		start("BANKMSGSRSV1"),
+		start("STMTTRNRS"),
+		element{"TRNUID", statement.AccountID},
+		element{"STATUS", status},
+		start("STMTRS"),
+		element{"CURDEF", statement.Currency},
+		element{"BANKACCTFROM", ofxAccount{BankID: "LEDGER", AcctID: statement.AccountID, AcctType: "CHECKING"}},
+		start("BANKTRANLIST"),
+		element{"DTSTART", statement.From.UTC().Format(ofxTimeLayout)},
+		element{"DTEND", statement.To.UTC().Format(ofxTimeLayout)},
+	)
+}
+
+func (w *ofxWriter) Lines(statement *business.Statement, lines []*business.StatementLine) error {
+	for _, line := range lines {
+		effect := balanceEffect(line)
+
+		trnType := "CREDIT"
+		if effect.IsNegative() {
+			trnType = "DEBIT"
+		}
+
+		err := w.enc.Encode(ofxTransaction{
+			Type:   trnType,
+			Posted: line.TransactedAt.UTC().Format(ofxTimeLayout),
+			Amount: utility.MoneyString(statement.Currency, effect),
+			FitID:  line.ID,
+			Name:   line.TransactionID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.enc.Flush()
+}
+
+func (w *ofxWriter) Close(statement *business.Statement) error {
+	return encode(w.enc,
+		end("BANKTRANLIST"),
+		element{"LEDGERBAL", ofxBalance{
+			Amount: utility.MoneyString(statement.Currency, statement.ClosingBalance),
+			AsOf:   statement.To.UTC().Format(ofxTimeLayout),
+		}},
+		end("STMTRS"),
+		end("STMTTRNRS"),
+		end("BANKMSGSRSV1"),
+		end("OFX"),
+	)
+}
+
+type ofxStatus struct {
+	Code     string `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxSignOn struct {
+	Status   ofxStatus `xml:"SONRS>STATUS"`
+	Server   string    `xml:"SONRS>DTSERVER"`
+	Language string    `xml:"SONRS>LANGUAGE"`
+}
+
+type ofxAccount struct {
+	BankID   string `xml:"BANKID"`
+	AcctID   string `xml:"ACCTID"`
+	AcctType string `xml:"ACCTTYPE"`
+}
@@ -0,0 +1,90 @@
+// Package export renders account statements as files for systems outside the API.
+package export
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/shopspring/decimal"
+)
+
+// Supported statement file formats.
+const (
+	FormatCSV     = "csv"
+	FormatOFX     = "ofx"
+	FormatCAMT053 = "camt053"
+)
+
+var ErrFormatUnsupported = errors.New("statement export format is not supported")
+
+// Writer renders a statement to an output stream as its lines are read, without holding the statement in memory.
+// Open is called once before any lines, Lines for every batch and Close once all lines were written.
+type Writer interface {
+	ContentType() string
+	FileExtension() string
+	Open(statement *business.Statement) error
+	Lines(statement *business.Statement, lines []*business.StatementLine) error
+	Close(statement *business.Statement) error
+}
+
+// NewWriter returns a writer rendering statements in format to out.
+func NewWriter(format string, out io.Writer) (Writer, error) {
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		return newCSVWriter(out), nil
+	case FormatOFX:
+		return newOFXWriter(out), nil
+	case FormatCAMT053:
+		return newCAMT053Writer(out), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrFormatUnsupported, format)
+	}
+}
+
+// FileName returns the name a statement file is offered for download under.
+func FileName(statement *business.Statement, writer Writer) string {
+	const layout = "20060102"
+	return fmt.Sprintf("statement-%s-%s-%s.%s", statement.AccountID,
+		statement.From.UTC().Format(layout), statement.To.UTC().Format(layout), writer.FileExtension())
+}
+
+// balanceEffect is the change a line made to the account balance, positive when the balance grew.
+func balanceEffect(line *business.StatementLine) decimal.Decimal {
+	return line.Amount.Decimal
+}
+
+// element is a value encoded as an XML element with the given name.
+type element struct {
+	name  string
+	value any
+}
+
+func start(name string) xml.StartElement {
+	return xml.StartElement{Name: xml.Name{Local: name}}
+}
+
+func end(name string) xml.EndElement {
+	return xml.EndElement{Name: xml.Name{Local: name}}
+}
+
+// encode writes XML tokens and elements in order and flushes them to the output.
+func encode(enc *xml.Encoder, items ...any) error {
+	for _, item := range items {
+		var err error
+		switch v := item.(type) {
+		case element:
+			err = enc.EncodeElement(v.value, start(v.name))
+		case xml.Token:
+			err = enc.EncodeToken(v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return enc.Flush()
+}
@@ -0,0 +1,128 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/apps/default/service/export"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/pitabwire/frame/data"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleStatement() (*business.Statement, []*business.StatementLine) {
+	to := time.Date(2026, 10, 31, 23, 59, 59, 0, time.UTC)
+	statement := &business.Statement{
+		AccountID:      "acc-1",
+		Currency:       "UGX",
+		From:           time.Date(2026, 9, 30, 23, 59, 59, 0, time.UTC),
+		To:             to,
+		OpeningBalance: decimal.NewFromInt(100),
+		ClosingBalance: decimal.RequireFromString("114.5"),
+		TotalDebits:    decimal.NewFromInt(40),
+		TotalCredits:   decimal.RequireFromString("25.5"),
+		EntryCount:     2,
+	}
+
+	lines := []*business.StatementLine{
+		{
+			TransactionEntry: &models.TransactionEntry{
+				BaseModel: data.BaseModel{ID: "entry-1"}, TransactionID: "txn-1", Currency: "UGX",
+				Amount: decimal.NewNullDecimal(decimal.NewFromInt(40)), TransactedAt: to.AddDate(0, 0, -20),
+				ClearedAt: to.AddDate(0, 0, -20),
+			},
+			RunningBalance: decimal.NewFromInt(140),
+		},
+		{
+			TransactionEntry: &models.TransactionEntry{
+				BaseModel: data.BaseModel{ID: "entry-2"}, TransactionID: "txn-<2>", Currency: "UGX",
+				Amount:       decimal.NewNullDecimal(decimal.RequireFromString("-25.5")),
+				Credit:       true,
+				TransactedAt: to.AddDate(0, 0, -10),
+			},
+			RunningBalance: decimal.RequireFromString("114.5"),
+		},
+	}
+
+	return statement, lines
+}
+
+func render(t *testing.T, format string) string {
+	statement, lines := sampleStatement()
+
+	var out bytes.Buffer
+	writer, err := export.NewWriter(format, &out)
+	require.NoError(t, err)
+
+	require.NoError(t, writer.Open(statement))
+	for _, line := range lines {
+		require.NoError(t, writer.Lines(statement, []*business.StatementLine{line}))
+	}
+	require.NoError(t, writer.Close(statement))
+
+	return out.String()
+}
+
+func requireWellFormedXML(t *testing.T, document string) {
+	decoder := xml.NewDecoder(bytes.NewBufferString(document))
+	for {
+		_, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		require.NoError(t, err, "document is not well formed:\n%s", document)
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewBufferString(render(t, export.FormatCSV))).ReadAll()
+	require.NoError(t, err)
+
+	require.Len(t, records, 6)
+	assert.Equal(t, "row_type", records[0][0])
+	assert.Equal(t, []string{"OPENING_BALANCE", "2026-09-30T23:59:59Z", "", "", "", "UGX", "", "", "100"}, records[1])
+	assert.Equal(t, "40", records[2][6], "debits are written to the debit column")
+	assert.Equal(t, "25.5", records[3][7], "credits are written to the credit column")
+	assert.Equal(t, "114.5", records[3][8])
+	assert.Equal(t, "CLOSING_BALANCE", records[4][0])
+	assert.Equal(t, []string{"TOTALS", "", "", "", "", "UGX", "40", "25.5", ""}, records[5])
+}
+
+func TestOFXWriter(t *testing.T) {
+	document := render(t, export.FormatOFX)
+	requireWellFormedXML(t, document)
+
+	assert.Contains(t, document, `<?OFX OFXHEADER="200" VERSION="220"`)
+	assert.Contains(t, document, "<TRNTYPE>CREDIT</TRNTYPE><DTPOSTED>20261011235959.000[+0:UTC]</DTPOSTED>"+
+		"<TRNAMT>40</TRNAMT><FITID>entry-1</FITID>")
+	assert.Contains(t, document, "<TRNTYPE>DEBIT</TRNTYPE>")
+	assert.Contains(t, document, "<TRNAMT>-25.5</TRNAMT>")
+	assert.Contains(t, document, "<NAME>txn-&lt;2&gt;</NAME>")
+	assert.Contains(t, document, "<LEDGERBAL><BALAMT>114.5</BALAMT>")
+}
+
+func TestCAMT053Writer(t *testing.T) {
+	document := render(t, export.FormatCAMT053)
+	requireWellFormedXML(t, document)
+
+	assert.Contains(t, document, `<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.08">`)
+	assert.Contains(t, document, `<Bal><Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp><Amt Ccy="UGX">100</Amt>`)
+	assert.Contains(t, document, `<Bal><Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp><Amt Ccy="UGX">114.5</Amt>`)
+	assert.Contains(t, document, `<NtryRef>entry-1</NtryRef><Amt Ccy="UGX">40</Amt><CdtDbtInd>CRDT</CdtDbtInd>`+
+		`<Sts><Cd>BOOK</Cd></Sts>`)
+	assert.Contains(t, document, `<NtryRef>entry-2</NtryRef><Amt Ccy="UGX">25.5</Amt><CdtDbtInd>DBIT</CdtDbtInd>`+
+		`<Sts><Cd>PDNG</Cd></Sts>`)
+}
+
+func TestNewWriterRejectsUnknownFormat(t *testing.T) {
+	_, err := export.NewWriter("pdf", io.Discard)
+	require.ErrorIs(t, err, export.ErrFormatUnsupported)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/pitabwire/util"
+)
+
+// AccountBalancesPath serves the current balances of an account, e.g. /accounts/balances?id=wallet-42.
+const AccountBalancesPath = "/accounts/balances"
+
+// AccountBalancesIDParam names the account.
+const AccountBalancesIDParam = "id"
+
+// AccountBalances holds the balances of an account formatted in its currency. The available balance is
+// the balance less pending debits and reserved funds, and is what a funds check under the AVAILABLE
+// policy holds debits and reservations to.
+type AccountBalances struct {
+	ID               string `json:"id"`
+	Currency         string `json:"currency"`
+	Balance          string `json:"balance"`
+	UnClearedBalance string `json:"uncleared_balance"`
+	ReservedBalance  string `json:"reserved_balance"`
+	AvailableBalance string `json:"available_balance"`
+	BalanceFloor     string `json:"balance_floor,omitempty"`
+	FundsPolicy      string `json:"funds_policy"`
+}
+
+// AccountBalancesHandler serves account balances as JSON.
+type AccountBalancesHandler struct {
+	Account business.AccountBusiness
+}
+
+// NewAccountBalancesHandler creates a new AccountBalancesHandler with injected dependencies.
+func NewAccountBalancesHandler(accountBusiness business.AccountBusiness) *AccountBalancesHandler {
+	return &AccountBalancesHandler{
+		Account: accountBusiness,
+	}
+}
+
+func (h *AccountBalancesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	account, err := h.Account.GetAccountBalances(r.Context(), r.URL.Query().Get(AccountBalancesIDParam))
+	if err != nil {
+		switch {
+		case errors.Is(err, business.ErrAccountIDRequired):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, business.ErrAccountNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			util.Log(r.Context()).WithError(err).Error("could not load account balances")
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	body := &AccountBalances{
+		ID:               account.ID,
+		Currency:         account.Currency,
+		Balance:          utility.MoneyString(account.Currency, account.Balance.Decimal),
+		UnClearedBalance: utility.MoneyString(account.Currency, account.UnClearedBalance.Decimal),
+		ReservedBalance:  utility.MoneyString(account.Currency, account.ReservedBalance.Decimal),
+		AvailableBalance: utility.MoneyString(account.Currency, account.AvailableBalance()),
+		FundsPolicy:      account.FundsPolicy,
+	}
+	if floor := account.EffectiveBalanceFloor(); floor.Valid {
+		body.BalanceFloor = utility.MoneyString(account.Currency, floor.Decimal)
+	}
+
+	writeJSON(w, r, body)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/pitabwire/util"
+)
+
+// ChartApplyPath applies the YAML or JSON chart of accounts template posted as the request body,
+// e.g. POST /charts/apply?prefix=tenant-a-&dry_run=true.
+const ChartApplyPath = "/charts/apply"
+
+// Chart template query parameters.
+const (
+	ChartPrefixParam = "prefix"
+	ChartDryRunParam = "dry_run"
+)
+
+// maxChartTemplateSize bounds the template read from a request body.
+const maxChartTemplateSize = 1 << 20
+
+// ChartHandler provisions ledger trees and system accounts from chart of accounts templates.
+type ChartHandler struct {
+	Chart business.ChartBusiness
+}
+
+// NewChartHandler creates a new ChartHandler with injected dependencies.
+func NewChartHandler(chartBusiness business.ChartBusiness) *ChartHandler {
+	return &ChartHandler{
+		Chart: chartBusiness,
+	}
+}
+
+// ServeHTTP applies the posted template and answers with the changes found.
+// A dry run only reports what applying the template would create.
+func (h *ChartHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+
+	dryRun := false
+	if value := query.Get(ChartDryRunParam); value != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "invalid dry_run: "+value, http.StatusBadRequest)
+			return
+		}
+	}
+
+	content, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxChartTemplateSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	template, err := business.ParseChartTemplate(content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.Chart.ApplyChartTemplate(r.Context(), template, query.Get(ChartPrefixParam), dryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, business.ErrChartTemplateInvalid):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, apperrors.ErrLedgerNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			util.Log(r.Context()).WithError(err).Error("could not apply chart of accounts template")
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, r, result)
+}
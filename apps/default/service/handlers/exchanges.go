@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/pitabwire/util"
+	"github.com/shopspring/decimal"
+)
+
+// ExchangePath posts a currency exchange given as a JSON ExchangeRequest body.
+const ExchangePath = "/transactions/exchange"
+
+// maxExchangeRequestSize bounds the exchange read from a request body.
+const maxExchangeRequestSize = 1 << 16
+
+// ExchangeRequest is the JSON body of an exchange. Amounts are decimal strings in the entry account's currency.
+type ExchangeRequest struct {
+	ID             string           `json:"id"`
+	SourceCurrency string           `json:"source_currency"`
+	Rate           string           `json:"rate"`
+	RateSource     string           `json:"rate_source"`
+	TransactedAt   time.Time        `json:"transacted_at"`
+	ClearedAt      time.Time        `json:"cleared_at"`
+	Data           map[string]any   `json:"data,omitempty"`
+	Entries        []*ExchangeEntry `json:"entries"`
+}
+
+// ExchangeEntry is an entry of an exchange or of one of its posted legs.
+type ExchangeEntry struct {
+	AccountID string `json:"account_id"`
+	Amount    string `json:"amount"`
+	Credit    bool   `json:"credit"`
+}
+
+// ExchangeLeg is the transaction an exchange posted in one currency.
+type ExchangeLeg struct {
+	ID       string           `json:"id"`
+	Currency string           `json:"currency"`
+	Entries  []*ExchangeEntry `json:"entries"`
+}
+
+// ExchangeResponse is the JSON body answered for a posted exchange.
+type ExchangeResponse struct {
+	ID             string         `json:"id"`
+	Rate           string         `json:"rate"`
+	RateSource     string         `json:"rate_source,omitempty"`
+	SourceCurrency string         `json:"source_currency"`
+	SourceAmount   string         `json:"source_amount"`
+	TargetCurrency string         `json:"target_currency"`
+	TargetAmount   string         `json:"target_amount"`
+	Legs           []*ExchangeLeg `json:"legs"`
+}
+
+// ExchangeHandler posts multi-currency exchanges.
+type ExchangeHandler struct {
+	Transaction business.TransactionBusiness
+}
+
+// NewExchangeHandler creates a new ExchangeHandler with injected dependencies.
+func NewExchangeHandler(transactionBusiness business.TransactionBusiness) *ExchangeHandler {
+	return &ExchangeHandler{
+		Transaction: transactionBusiness,
+	}
+}
+
+// ServeHTTP posts the exchange in the request body and answers with its legs.
+func (h *ExchangeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	body := new(ExchangeRequest)
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxExchangeRequestSize)).Decode(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	request, err := toBusinessExchange(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	legs, err := h.Transaction.Exchange(r.Context(), request)
+	if err != nil {
+		writeExchangeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, toExchangeResponse(legs))
+}
+
+func toBusinessExchange(body *ExchangeRequest) (*business.ExchangeRequest, error) {
+	rate, err := decimal.NewFromString(body.Rate)
+	if err != nil {
+		return nil, errors.New("invalid rate: " + body.Rate)
+	}
+
+	request := &business.ExchangeRequest{
+		ID:             body.ID,
+		SourceCurrency: body.SourceCurrency,
+		Rate:           rate,
+		RateSource:     body.RateSource,
+		TransactedAt:   body.TransactedAt,
+		ClearedAt:      body.ClearedAt,
+		Data:           body.Data,
+	}
+
+	for _, entry := range body.Entries {
+		amount, amountErr := decimal.NewFromString(entry.Amount)
+		if amountErr != nil {
+			return nil, errors.New("invalid amount: " + entry.Amount)
+		}
+
+		request.Entries = append(request.Entries, &models.TransactionEntry{
+			AccountID: entry.AccountID,
+			Amount:    decimal.NewNullDecimal(amount),
+			Credit:    entry.Credit,
+		})
+	}
+
+	return request, nil
+}
+
+func toExchangeResponse(legs []*models.Transaction) *ExchangeResponse {
+	details := legs[0].Exchange
+	response := &ExchangeResponse{
+		ID:             details.ID,
+		Rate:           details.Rate.Decimal.String(),
+		RateSource:     details.RateSource,
+		SourceCurrency: details.SourceCurrency,
+		SourceAmount:   utility.MoneyString(details.SourceCurrency, details.SourceAmount.Decimal),
+		TargetCurrency: details.TargetCurrency,
+		TargetAmount:   utility.MoneyString(details.TargetCurrency, details.TargetAmount.Decimal),
+	}
+
+	for _, leg := range legs {
+		exchangeLeg := &ExchangeLeg{ID: leg.GetID(), Currency: leg.Currency}
+		for _, entry := range leg.Entries {
+			exchangeLeg.Entries = append(exchangeLeg.Entries, &ExchangeEntry{
+				AccountID: entry.AccountID,
+				Amount:    utility.MoneyString(leg.Currency, entry.Amount.Decimal.Abs()),
+				Credit:    entry.Credit,
+			})
+		}
+		response.Legs = append(response.Legs, exchangeLeg)
+	}
+
+	return response
+}
+
+func writeExchangeError(w http.ResponseWriter, r *http.Request, err error) {
+	var appErr apperrors.ApplicationError
+	switch {
+	case errors.Is(err, business.ErrTransactionIDRequired), errors.Is(err, business.ErrExchangeRateInvalid),
+		errors.Is(err, business.ErrExchangeCurrenciesInvalid), errors.Is(err, business.ErrExchangeRateMismatch):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, apperrors.ErrTransactionIsConfilicting):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.As(err, &appErr) && !errors.Is(err, apperrors.ErrSystemFailure):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		util.Log(r.Context()).WithError(err).Error("could not post exchange")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/pitabwire/util"
+	"github.com/shopspring/decimal"
+)
+
+// FX paths, e.g. POST /fx/rates with a JSON array of rates, POST /fx/rates/import?source=central-bank with a
+// CSV body, GET /fx/rates?base=USD&quote=UGX&at=2026-10-16T00:00:00Z and POST /fx/revaluations?at=....
+const (
+	FXPath            = "/fx/"
+	FXRatesPath       = "/fx/rates"
+	FXRatesImportPath = "/fx/rates/import"
+	FXRevaluationPath = "/fx/revaluations"
+)
+
+// FX query parameters. Timestamps are RFC3339 and default to now.
+const (
+	FXBaseParam   = "base"
+	FXQuoteParam  = "quote"
+	FXAtParam     = "at"
+	FXSourceParam = "source"
+)
+
+// maxFXRatesRequestSize bounds the rates read from a request body.
+const maxFXRatesRequestSize = 1 << 22
+
+// FXRate is the JSON body of a rate: one unit of base currency buys rate units of quote currency.
+type FXRate struct {
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	EffectiveAt   time.Time `json:"effective_at"`
+	Rate          string    `json:"rate"`
+	Source        string    `json:"source,omitempty"`
+}
+
+// FXRatesImport is the JSON body answered for imported rates.
+type FXRatesImport struct {
+	Imported int `json:"imported"`
+}
+
+// FXRevaluationLine is an account revalued with its unrealised gain, negative for a loss.
+type FXRevaluationLine struct {
+	AccountID string `json:"account_id"`
+	Currency  string `json:"currency"`
+	Balance   string `json:"balance"`
+	Rate      string `json:"rate"`
+	BaseValue string `json:"base_value"`
+	Gain      string `json:"gain"`
+}
+
+// FXRevaluationReport is the JSON body of a revaluation run.
+type FXRevaluationReport struct {
+	BaseCurrency   string               `json:"base_currency"`
+	At             time.Time            `json:"at"`
+	TransactionIDs []string             `json:"transaction_ids,omitempty"`
+	Lines          []*FXRevaluationLine `json:"lines"`
+}
+
+// FXHandler stores FX rates and revalues foreign currency accounts.
+type FXHandler struct {
+	FXRate      business.FXRateBusiness
+	Revaluation business.RevaluationBusiness
+	mux         *http.ServeMux
+}
+
+// NewFXHandler creates a new FXHandler with injected dependencies.
+func NewFXHandler(
+	fxRateBusiness business.FXRateBusiness,
+	revaluationBusiness business.RevaluationBusiness,
+) *FXHandler {
+	h := &FXHandler{
+		FXRate:      fxRateBusiness,
+		Revaluation: revaluationBusiness,
+		mux:         http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("POST "+FXRatesPath, h.SaveRates)
+	h.mux.HandleFunc("POST "+FXRatesImportPath, h.ImportRates)
+	h.mux.HandleFunc("GET "+FXRatesPath, h.GetRate)
+	h.mux.HandleFunc("POST "+FXRevaluationPath, h.Revalue)
+	return h
+}
+
+func (h *FXHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// SaveRates stores the rates in the request body, replacing those already held for the same pair and instant.
+func (h *FXHandler) SaveRates(w http.ResponseWriter, r *http.Request) {
+	var body []*FXRate
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxFXRatesRequestSize)).Decode(&body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rates := make([]*models.FXRate, 0, len(body))
+	for _, rate := range body {
+		value, rateErr := decimal.NewFromString(rate.Rate)
+		if rateErr != nil {
+			http.Error(w, "invalid rate: "+rate.Rate, http.StatusBadRequest)
+			return
+		}
+
+		rates = append(rates, &models.FXRate{
+			BaseCurrency:  rate.BaseCurrency,
+			QuoteCurrency: rate.QuoteCurrency,
+			EffectiveAt:   rate.EffectiveAt,
+			Rate:          value,
+			Source:        rate.Source,
+		})
+	}
+
+	err = h.FXRate.SaveRates(r.Context(), rates)
+	if err != nil {
+		writeFXError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, &FXRatesImport{Imported: len(rates)})
+}
+
+// ImportRates stores the rates of the CSV file in the request body.
+func (h *FXHandler) ImportRates(w http.ResponseWriter, r *http.Request) {
+	imported, err := h.FXRate.ImportRatesCSV(r.Context(),
+		http.MaxBytesReader(w, r.Body, maxFXRatesRequestSize), r.URL.Query().Get(FXSourceParam))
+	if err != nil {
+		writeFXError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, &FXRatesImport{Imported: imported})
+}
+
+// GetRate answers with the rate of a currency pair in effect at the requested instant.
+func (h *FXHandler) GetRate(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	at, err := parseReportTime(query.Get(FXAtParam))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rate, err := h.FXRate.Rate(r.Context(), query.Get(FXBaseParam), query.Get(FXQuoteParam), at)
+	if err != nil {
+		writeFXError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, &FXRate{
+		BaseCurrency:  query.Get(FXBaseParam),
+		QuoteCurrency: query.Get(FXQuoteParam),
+		EffectiveAt:   at,
+		Rate:          rate.String(),
+	})
+}
+
+// Revalue revalues every foreign currency account at the requested instant and answers with the gains posted.
+func (h *FXHandler) Revalue(w http.ResponseWriter, r *http.Request) {
+	at, err := parseReportTime(r.URL.Query().Get(FXAtParam))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.Revaluation.Revalue(r.Context(), at)
+	if err != nil {
+		writeFXError(w, r, err)
+		return
+	}
+
+	body := &FXRevaluationReport{
+		BaseCurrency: result.BaseCurrency,
+		At:           result.At,
+		Lines:        make([]*FXRevaluationLine, 0, len(result.Lines)),
+	}
+	for _, transaction := range result.Transactions {
+		body.TransactionIDs = append(body.TransactionIDs, transaction.GetID())
+	}
+	for _, line := range result.Lines {
+		body.Lines = append(body.Lines, &FXRevaluationLine{
+			AccountID: line.AccountID,
+			Currency:  line.Currency,
+			Balance:   utility.MoneyString(line.Currency, line.Balance),
+			Rate:      line.Rate.String(),
+			BaseValue: utility.MoneyString(result.BaseCurrency, line.BaseValue),
+			Gain:      utility.MoneyString(result.BaseCurrency, line.Gain),
+		})
+	}
+
+	writeJSON(w, r, body)
+}
+
+func writeFXError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, business.ErrFXRateInvalid), errors.Is(err, business.ErrFXRateImportInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, apperrors.ErrFXRateNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, business.ErrFXRevaluationConfig), errors.Is(err, business.ErrFXRevaluationLedgers):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, apperrors.ErrPeriodClosed):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		util.Log(r.Context()).WithError(err).Error("could not process FX request")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
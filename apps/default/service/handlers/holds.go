@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/pitabwire/util"
+	"github.com/shopspring/decimal"
+)
+
+// Hold paths, e.g. GET /holds/status?id=hold-42, POST /holds/capture with a JSON CaptureRequest body and
+// POST /holds/release?id=hold-42. A hold is named by the id of the reservation that placed it.
+const (
+	HoldsPath       = "/holds/"
+	HoldStatusPath  = "/holds/status"
+	HoldCapturePath = "/holds/capture"
+	HoldReleasePath = "/holds/release"
+)
+
+// HoldIDParam names the hold.
+const HoldIDParam = "id"
+
+// maxCaptureRequestSize bounds the capture read from a request body.
+const maxCaptureRequestSize = 1 << 16
+
+// CaptureRequest is the JSON body of a capture. Without an amount all that remains of the hold is captured,
+// with one the capture needs an id.
+type CaptureRequest struct {
+	ID        string         `json:"id,omitempty"`
+	HoldID    string         `json:"hold_id"`
+	AccountID string         `json:"account_id"`
+	Amount    string         `json:"amount,omitempty"`
+	Data      map[string]any `json:"data,omitempty"`
+}
+
+// Hold is the JSON body of a hold with its amounts formatted in its currency.
+type Hold struct {
+	ID        string     `json:"id"`
+	AccountID string     `json:"account_id"`
+	Currency  string     `json:"currency"`
+	Credit    bool       `json:"credit"`
+	Amount    string     `json:"amount"`
+	Captured  string     `json:"captured"`
+	Released  string     `json:"released"`
+	Remaining string     `json:"remaining"`
+	Status    string     `json:"status"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CaptureResponse is the JSON body answered for a capture.
+type CaptureResponse struct {
+	Hold          *Hold  `json:"hold"`
+	TransactionID string `json:"transaction_id"`
+}
+
+// HoldsHandler captures and releases the holds placed by reservations.
+type HoldsHandler struct {
+	Transaction business.TransactionBusiness
+	mux         *http.ServeMux
+}
+
+// NewHoldsHandler creates a new HoldsHandler with injected dependencies.
+func NewHoldsHandler(transactionBusiness business.TransactionBusiness) *HoldsHandler {
+	h := &HoldsHandler{
+		Transaction: transactionBusiness,
+		mux:         http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("GET "+HoldStatusPath, h.GetHold)
+	h.mux.HandleFunc("POST "+HoldCapturePath, h.CaptureHold)
+	h.mux.HandleFunc("POST "+HoldReleasePath, h.ReleaseHold)
+	return h
+}
+
+func (h *HoldsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// GetHold answers with the named hold.
+func (h *HoldsHandler) GetHold(w http.ResponseWriter, r *http.Request) {
+	hold, err := h.Transaction.GetHold(r.Context(), r.URL.Query().Get(HoldIDParam))
+	if err != nil {
+		writeHoldError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, toHold(hold))
+}
+
+// CaptureHold posts the capture in the request body and answers with the hold and the capture's id.
+func (h *HoldsHandler) CaptureHold(w http.ResponseWriter, r *http.Request) {
+	body := new(CaptureRequest)
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCaptureRequestSize)).Decode(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	request := &business.CaptureRequest{
+		ID:        body.ID,
+		HoldID:    body.HoldID,
+		AccountID: body.AccountID,
+		Data:      body.Data,
+	}
+	if body.Amount != "" {
+		request.Amount, err = decimal.NewFromString(body.Amount)
+		if err != nil {
+			http.Error(w, "invalid amount: "+body.Amount, http.StatusBadRequest)
+			return
+		}
+	}
+
+	hold, capture, err := h.Transaction.CaptureHold(r.Context(), request)
+	if err != nil {
+		writeHoldError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, &CaptureResponse{Hold: toHold(hold), TransactionID: capture.GetID()})
+}
+
+// ReleaseHold cancels what remains of the named hold and answers with the hold.
+func (h *HoldsHandler) ReleaseHold(w http.ResponseWriter, r *http.Request) {
+	hold, err := h.Transaction.ReleaseHold(r.Context(), r.URL.Query().Get(HoldIDParam))
+	if err != nil {
+		writeHoldError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, toHold(hold))
+}
+
+func toHold(hold *models.Hold) *Hold {
+	return &Hold{
+		ID:        hold.GetID(),
+		AccountID: hold.AccountID,
+		Currency:  hold.Currency,
+		Credit:    hold.Credit,
+		Amount:    utility.MoneyString(hold.Currency, hold.Amount),
+		Captured:  utility.MoneyString(hold.Currency, hold.Captured),
+		Released:  utility.MoneyString(hold.Currency, hold.Released),
+		Remaining: utility.MoneyString(hold.Currency, hold.Remaining()),
+		Status:    hold.Status,
+		ExpiresAt: hold.ExpiresAt,
+	}
+}
+
+func writeHoldError(w http.ResponseWriter, r *http.Request, err error) {
+	var appErr apperrors.ApplicationError
+	switch {
+	case errors.Is(err, business.ErrTransactionIDRequired), errors.Is(err, business.ErrHoldCaptureInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, apperrors.ErrHoldNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, apperrors.ErrHoldNotOpen), errors.Is(err, apperrors.ErrTransactionIsConfilicting):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.As(err, &appErr) && !errors.Is(err, apperrors.ErrSystemFailure):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		util.Log(r.Context()).WithError(err).Error("could not process hold")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/pitabwire/util"
+)
+
+// LedgerTreePath serves a ledger subtree in one call, e.g. /ledgers/tree?root_id=assets&depth=2.
+// Without depth the whole subtree is returned.
+const LedgerTreePath = "/ledgers/tree"
+
+// Ledger tree query parameters.
+const (
+	LedgerTreeRootParam  = "root_id"
+	LedgerTreeDepthParam = "depth"
+)
+
+// LedgerTreeNode is a ledger in the tree with its subtree balances formatted per currency.
+type LedgerTreeNode struct {
+	ID           string                        `json:"id"`
+	Type         string                        `json:"type"`
+	ParentID     string                        `json:"parent_id,omitempty"`
+	Data         map[string]any                `json:"data,omitempty"`
+	Depth        int                           `json:"depth"`
+	ChildCount   int                           `json:"child_count"`
+	AccountCount int                           `json:"account_count"`
+	Balances     map[string]*LedgerTreeBalance `json:"balances"`
+	Children     []*LedgerTreeNode             `json:"children,omitempty"`
+}
+
+// LedgerTreeBalance holds the summed balances of a ledger subtree in one currency.
+type LedgerTreeBalance struct {
+	Balance          string `json:"balance"`
+	UnClearedBalance string `json:"uncleared_balance"`
+	ReservedBalance  string `json:"reserved_balance"`
+}
+
+// LedgerTreeHandler serves ledger subtrees with their rollups as JSON.
+type LedgerTreeHandler struct {
+	Ledger business.LedgerBusiness
+}
+
+// NewLedgerTreeHandler creates a new LedgerTreeHandler with injected dependencies.
+func NewLedgerTreeHandler(ledgerBusiness business.LedgerBusiness) *LedgerTreeHandler {
+	return &LedgerTreeHandler{
+		Ledger: ledgerBusiness,
+	}
+}
+
+func (h *LedgerTreeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+
+	depth := 0
+	if value := query.Get(LedgerTreeDepthParam); value != "" {
+		var err error
+		depth, err = strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "invalid depth: "+value, http.StatusBadRequest)
+			return
+		}
+	}
+
+	tree, err := h.Ledger.GetLedgerTree(r.Context(), query.Get(LedgerTreeRootParam), depth)
+	if err != nil {
+		switch {
+		case errors.Is(err, business.ErrLedgerIDRequired):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, apperrors.ErrLedgerNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			util.Log(r.Context()).WithError(err).Error("could not load ledger tree")
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, r, toLedgerTreeNode(tree))
+}
+
+func toLedgerTreeNode(node *models.LedgerTreeNode) *LedgerTreeNode {
+	result := &LedgerTreeNode{
+		ID:           node.Ledger.ID,
+		Type:         node.Ledger.Type,
+		ParentID:     node.Ledger.ParentID,
+		Data:         node.Ledger.Data,
+		Depth:        node.Depth,
+		ChildCount:   node.ChildCount,
+		AccountCount: node.AccountCount,
+		Balances:     make(map[string]*LedgerTreeBalance, len(node.Balances)),
+	}
+
+	for currency, balance := range node.Balances {
+		result.Balances[currency] = &LedgerTreeBalance{
+			Balance:          utility.MoneyString(currency, balance.Balance),
+			UnClearedBalance: utility.MoneyString(currency, balance.UnClearedBalance),
+			ReservedBalance:  utility.MoneyString(currency, balance.ReservedBalance),
+		}
+	}
+
+	for _, child := range node.Children {
+		result.Children = append(result.Children, toLedgerTreeNode(child))
+	}
+
+	return result
+}
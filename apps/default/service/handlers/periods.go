@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/pitabwire/util"
+)
+
+// Accounting period paths, e.g. POST /periods/close?name=2026-09, GET /periods/balances?name=2026-09 and
+// POST /periods/year-end?year=2025&currency=UGX&retained_earnings_account=retained-ugx&dry_run=true.
+const (
+	PeriodsPath        = "/periods/"
+	PeriodClosePath    = "/periods/close"
+	PeriodBalancesPath = "/periods/balances"
+	YearEndClosePath   = "/periods/year-end"
+)
+
+// PeriodNameParam names the accounting period, a month formatted as YYYY-MM.
+const PeriodNameParam = "name"
+
+// Year-end close query parameters.
+const (
+	YearEndYearParam     = "year"
+	YearEndCurrencyParam = "currency"
+	YearEndRetainedParam = "retained_earnings_account"
+	YearEndDryRunParam   = "dry_run"
+)
+
+// AccountingPeriod is the JSON body of a closed accounting period.
+type AccountingPeriod struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	ClosedAt time.Time `json:"closed_at"`
+	ClosedBy string    `json:"closed_by"`
+}
+
+// PeriodBalance is the balance of an account snapshotted when its period closed.
+type PeriodBalance struct {
+	AccountID        string `json:"account_id"`
+	Currency         string `json:"currency"`
+	Balance          string `json:"balance"`
+	UnClearedBalance string `json:"uncleared_balance"`
+	ReservedBalance  string `json:"reserved_balance"`
+}
+
+// PeriodBalancesReport is the JSON body of the closing balances of a period.
+type PeriodBalancesReport struct {
+	Period   *AccountingPeriod `json:"period"`
+	Balances []*PeriodBalance  `json:"balances"`
+}
+
+// YearEndLine is an account swept by a year-end close with its balance before the close.
+type YearEndLine struct {
+	AccountID  string `json:"account_id"`
+	LedgerType string `json:"ledger_type"`
+	Balance    string `json:"balance"`
+}
+
+// YearEndEntry is a leg of one of the closing transactions.
+type YearEndEntry struct {
+	TransactionID string `json:"transaction_id"`
+	AccountID     string `json:"account_id"`
+	Amount        string `json:"amount"`
+	Credit        bool   `json:"credit"`
+}
+
+// YearEndCloseReport is the JSON body of a year-end close or of its dry run.
+type YearEndCloseReport struct {
+	Year                      int             `json:"year"`
+	Currency                  string          `json:"currency"`
+	RetainedEarningsAccountID string          `json:"retained_earnings_account"`
+	ClosingAt                 time.Time       `json:"closing_at"`
+	TransactionIDs            []string        `json:"transaction_ids,omitempty"`
+	Lines                     []*YearEndLine  `json:"lines"`
+	Entries                   []*YearEndEntry `json:"entries"`
+	NetIncome                 string          `json:"net_income"`
+	Posted                    bool            `json:"posted"`
+}
+
+// PeriodsHandler closes accounting periods and fiscal years and serves closing balances as JSON.
+type PeriodsHandler struct {
+	Period  business.PeriodBusiness
+	YearEnd business.YearEndBusiness
+	mux     *http.ServeMux
+}
+
+// NewPeriodsHandler creates a new PeriodsHandler with injected dependencies.
+func NewPeriodsHandler(
+	periodBusiness business.PeriodBusiness,
+	yearEndBusiness business.YearEndBusiness,
+) *PeriodsHandler {
+	h := &PeriodsHandler{
+		Period:  periodBusiness,
+		YearEnd: yearEndBusiness,
+		mux:     http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("POST "+PeriodClosePath, h.ClosePeriod)
+	h.mux.HandleFunc("GET "+PeriodBalancesPath, h.PeriodBalances)
+	h.mux.HandleFunc("POST "+YearEndClosePath, h.CloseYear)
+	return h
+}
+
+func (h *PeriodsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// ClosePeriod closes the named period to postings and answers with the closed period.
+func (h *PeriodsHandler) ClosePeriod(w http.ResponseWriter, r *http.Request) {
+	period, err := h.Period.ClosePeriod(r.Context(), r.URL.Query().Get(PeriodNameParam))
+	if err != nil {
+		writePeriodError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, toAccountingPeriod(period))
+}
+
+// PeriodBalances answers with the balances of every account at the end of the named closed period.
+func (h *PeriodsHandler) PeriodBalances(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get(PeriodNameParam)
+
+	period, err := h.Period.GetPeriod(r.Context(), name)
+	if err != nil {
+		writePeriodError(w, r, err)
+		return
+	}
+
+	balances, err := h.Period.ListPeriodBalances(r.Context(), name)
+	if err != nil {
+		writePeriodError(w, r, err)
+		return
+	}
+
+	body := &PeriodBalancesReport{
+		Period:   toAccountingPeriod(period),
+		Balances: make([]*PeriodBalance, 0, len(balances)),
+	}
+	for _, balance := range balances {
+		body.Balances = append(body.Balances, &PeriodBalance{
+			AccountID:        balance.AccountID,
+			Currency:         balance.Currency,
+			Balance:          utility.MoneyString(balance.Currency, balance.Balance),
+			UnClearedBalance: utility.MoneyString(balance.Currency, balance.UnClearedBalance),
+			ReservedBalance:  utility.MoneyString(balance.Currency, balance.ReservedBalance),
+		})
+	}
+
+	writeJSON(w, r, body)
+}
+
+// CloseYear sweeps the income and expenses of a fiscal year into retained earnings.
+// A dry run answers with the closing transaction without posting it.
+func (h *PeriodsHandler) CloseYear(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	year, err := strconv.Atoi(query.Get(YearEndYearParam))
+	if err != nil {
+		http.Error(w, "invalid year: "+query.Get(YearEndYearParam), http.StatusBadRequest)
+		return
+	}
+
+	dryRun := false
+	if value := query.Get(YearEndDryRunParam); value != "" {
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "invalid dry_run: "+value, http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := h.YearEnd.CloseYear(r.Context(), year, query.Get(YearEndCurrencyParam),
+		query.Get(YearEndRetainedParam), dryRun)
+	if err != nil {
+		writePeriodError(w, r, err)
+		return
+	}
+
+	body := &YearEndCloseReport{
+		Year:                      result.Year,
+		Currency:                  result.Currency,
+		RetainedEarningsAccountID: result.RetainedEarningsAccountID,
+		ClosingAt:                 result.ClosingAt,
+		Lines:                     make([]*YearEndLine, 0, len(result.Lines)),
+		Entries:                   make([]*YearEndEntry, 0),
+		NetIncome:                 utility.MoneyString(result.Currency, result.NetIncome),
+		Posted:                    result.Posted,
+	}
+	for _, line := range result.Lines {
+		body.Lines = append(body.Lines, &YearEndLine{
+			AccountID:  line.AccountID,
+			LedgerType: line.LedgerType,
+			Balance:    utility.MoneyString(result.Currency, line.Balance),
+		})
+	}
+	for _, transaction := range result.Transactions {
+		body.TransactionIDs = append(body.TransactionIDs, transaction.GetID())
+		for _, entry := range transaction.Entries {
+			body.Entries = append(body.Entries, &YearEndEntry{
+				TransactionID: transaction.GetID(),
+				AccountID:     entry.AccountID,
+				Amount:        utility.MoneyString(result.Currency, entry.Amount.Decimal.Abs()),
+				Credit:        entry.Credit,
+			})
+		}
+	}
+
+	writeJSON(w, r, body)
+}
+
+func toAccountingPeriod(period *models.AccountingPeriod) *AccountingPeriod {
+	return &AccountingPeriod{
+		ID:       period.GetID(),
+		Name:     period.Name,
+		StartsAt: period.StartsAt,
+		EndsAt:   period.EndsAt,
+		ClosedAt: period.ClosedAt,
+		ClosedBy: period.ClosedBy,
+	}
+}
+
+func writePeriodError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, business.ErrPeriodNameInvalid), errors.Is(err, business.ErrPeriodNotEnded),
+		errors.Is(err, business.ErrYearEndCurrencyInvalid), errors.Is(err, business.ErrYearEndRetainedInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, business.ErrPeriodForbidden), errors.Is(err, apperrors.ErrPeriodClosed):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, apperrors.ErrPeriodNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, apperrors.ErrPeriodAlreadyClosed), errors.Is(err, apperrors.ErrTransactionIsConfilicting):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		util.Log(r.Context()).WithError(err).Error("could not process accounting period")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
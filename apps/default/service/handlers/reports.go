@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/pitabwire/util"
+	"github.com/shopspring/decimal"
+)
+
+// Report paths, e.g. /reports/trial-balance?currency=UGX&as_of=2026-10-31T23:59:59Z.
+const (
+	ReportsPath               = "/reports/"
+	TrialBalanceReportPath    = "/reports/trial-balance"
+	BalanceSheetReportPath    = "/reports/balance-sheet"
+	IncomeStatementReportPath = "/reports/income-statement"
+)
+
+// Report query parameters. Timestamps are RFC3339; as_of and to default to now, from is required.
+// With convert=true balances held in other currencies are converted to the report currency.
+const (
+	ReportCurrencyParam = "currency"
+	ReportAsOfParam     = "as_of"
+	ReportFromParam     = "from"
+	ReportToParam       = "to"
+	ReportConvertParam  = "convert"
+)
+
+// ReportLine is a ledger in a report with its amounts formatted in the report currency.
+type ReportLine struct {
+	LedgerID string        `json:"ledger_id"`
+	ParentID string        `json:"parent_id,omitempty"`
+	Type     string        `json:"type"`
+	Balance  string        `json:"balance"`
+	Total    string        `json:"total"`
+	Debit    string        `json:"debit,omitempty"`
+	Credit   string        `json:"credit,omitempty"`
+	Children []*ReportLine `json:"children,omitempty"`
+}
+
+// TrialBalanceReport is the JSON body of a trial balance.
+type TrialBalanceReport struct {
+	Currency     string            `json:"currency"`
+	AsOf         time.Time         `json:"as_of"`
+	Rates        map[string]string `json:"rates,omitempty"`
+	Lines        []*ReportLine     `json:"lines"`
+	TotalDebits  string            `json:"total_debits"`
+	TotalCredits string            `json:"total_credits"`
+	Balanced     bool              `json:"balanced"`
+	Imbalance    string            `json:"imbalance,omitempty"`
+}
+
+// BalanceSheetReport is the JSON body of a balance sheet.
+type BalanceSheetReport struct {
+	Currency         string            `json:"currency"`
+	AsOf             time.Time         `json:"as_of"`
+	Rates            map[string]string `json:"rates,omitempty"`
+	Assets           []*ReportLine     `json:"assets"`
+	Liabilities      []*ReportLine     `json:"liabilities"`
+	Capital          []*ReportLine     `json:"capital"`
+	TotalAssets      string            `json:"total_assets"`
+	TotalLiabilities string            `json:"total_liabilities"`
+	TotalCapital     string            `json:"total_capital"`
+	CurrentEarnings  string            `json:"current_earnings"`
+	Balanced         bool              `json:"balanced"`
+}
+
+// IncomeStatementReport is the JSON body of an income statement.
+type IncomeStatementReport struct {
+	Currency      string            `json:"currency"`
+	From          time.Time         `json:"from"`
+	To            time.Time         `json:"to"`
+	Rates         map[string]string `json:"rates,omitempty"`
+	Income        []*ReportLine     `json:"income"`
+	Expenses      []*ReportLine     `json:"expenses"`
+	TotalIncome   string            `json:"total_income"`
+	TotalExpenses string            `json:"total_expenses"`
+	NetIncome     string            `json:"net_income"`
+}
+
+// ReportsHandler serves financial reports over the ledger hierarchy as JSON.
+type ReportsHandler struct {
+	Report business.ReportBusiness
+	mux    *http.ServeMux
+}
+
+// NewReportsHandler creates a new ReportsHandler with injected dependencies.
+func NewReportsHandler(reportBusiness business.ReportBusiness) *ReportsHandler {
+	h := &ReportsHandler{
+		Report: reportBusiness,
+		mux:    http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("GET "+TrialBalanceReportPath, h.TrialBalance)
+	h.mux.HandleFunc("GET "+BalanceSheetReportPath, h.BalanceSheet)
+	h.mux.HandleFunc("GET "+IncomeStatementReportPath, h.IncomeStatement)
+	return h
+}
+
+func (h *ReportsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// TrialBalance reports the balance of every ledger as of the requested instant.
+// An imbalance is reported in the body rather than as an error so the corrupt figures can be inspected.
+func (h *ReportsHandler) TrialBalance(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	asOf, err := parseReportTime(query.Get(ReportAsOfParam))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts, err := parseReportOptions(query.Get(ReportConvertParam))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.Report.TrialBalance(r.Context(), query.Get(ReportCurrencyParam), asOf, opts...)
+	if err != nil {
+		writeReportError(w, r, err)
+		return
+	}
+
+	body := &TrialBalanceReport{
+		Currency:     report.Currency,
+		AsOf:         report.AsOf,
+		Rates:        toReportRates(report.Rates),
+		Lines:        toReportLines(report.Currency, report.Lines, true),
+		TotalDebits:  utility.MoneyString(report.Currency, report.TotalDebits),
+		TotalCredits: utility.MoneyString(report.Currency, report.TotalCredits),
+		Balanced:     report.Balanced,
+	}
+	if !report.Balanced {
+		body.Imbalance = utility.MoneyString(report.Currency, report.Imbalance())
+	}
+
+	writeJSON(w, r, body)
+}
+
+// BalanceSheet reports assets against liabilities and capital as of the requested instant.
+func (h *ReportsHandler) BalanceSheet(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	asOf, err := parseReportTime(query.Get(ReportAsOfParam))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts, err := parseReportOptions(query.Get(ReportConvertParam))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.Report.BalanceSheet(r.Context(), query.Get(ReportCurrencyParam), asOf, opts...)
+	if err != nil {
+		writeReportError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, &BalanceSheetReport{
+		Currency:         report.Currency,
+		AsOf:             report.AsOf,
+		Rates:            toReportRates(report.Rates),
+		Assets:           toReportLines(report.Currency, report.Assets, false),
+		Liabilities:      toReportLines(report.Currency, report.Liabilities, false),
+		Capital:          toReportLines(report.Currency, report.Capital, false),
+		TotalAssets:      utility.MoneyString(report.Currency, report.TotalAssets),
+		TotalLiabilities: utility.MoneyString(report.Currency, report.TotalLiabilities),
+		TotalCapital:     utility.MoneyString(report.Currency, report.TotalCapital),
+		CurrentEarnings:  utility.MoneyString(report.Currency, report.CurrentEarnings),
+		Balanced:         report.Balanced,
+	})
+}
+
+// IncomeStatement reports income minus expenses over the requested period.
+func (h *ReportsHandler) IncomeStatement(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	fromValue := query.Get(ReportFromParam)
+	if fromValue == "" {
+		http.Error(w, fmt.Sprintf("%s is required", ReportFromParam), http.StatusBadRequest)
+		return
+	}
+
+	from, err := parseReportTime(fromValue)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	to, err := parseReportTime(query.Get(ReportToParam))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts, err := parseReportOptions(query.Get(ReportConvertParam))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.Report.IncomeStatement(r.Context(), query.Get(ReportCurrencyParam), from, to, opts...)
+	if err != nil {
+		writeReportError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, &IncomeStatementReport{
+		Currency:      report.Currency,
+		From:          report.From,
+		To:            report.To,
+		Rates:         toReportRates(report.Rates),
+		Income:        toReportLines(report.Currency, report.Income, false),
+		Expenses:      toReportLines(report.Currency, report.Expenses, false),
+		TotalIncome:   utility.MoneyString(report.Currency, report.TotalIncome),
+		TotalExpenses: utility.MoneyString(report.Currency, report.TotalExpenses),
+		NetIncome:     utility.MoneyString(report.Currency, report.NetIncome),
+	})
+}
+
+func toReportLines(currency string, lines []*business.ReportLine, withSides bool) []*ReportLine {
+	result := make([]*ReportLine, 0, len(lines))
+	for _, line := range lines {
+		reportLine := &ReportLine{
+			LedgerID: line.LedgerID,
+			ParentID: line.ParentID,
+			Type:     line.Type,
+			Balance:  utility.MoneyString(currency, line.Balance),
+			Total:    utility.MoneyString(currency, line.Total),
+			Children: toReportLines(currency, line.Children, withSides),
+		}
+		if withSides {
+			reportLine.Debit = utility.MoneyString(currency, line.Debit())
+			reportLine.Credit = utility.MoneyString(currency, line.Credit())
+		}
+		result = append(result, reportLine)
+	}
+	return result
+}
+
+// toReportRates formats the rates a report was converted at, nil when it was not converted.
+func toReportRates(rates map[string]decimal.Decimal) map[string]string {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	result := make(map[string]string, len(rates))
+	for code, rate := range rates {
+		result[code] = rate.String()
+	}
+	return result
+}
+
+func parseReportOptions(convert string) ([]business.ReportOption, error) {
+	if convert == "" {
+		return nil, nil
+	}
+
+	enabled, err := strconv.ParseBool(convert)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", ReportConvertParam, convert, err)
+	}
+	if !enabled {
+		return nil, nil
+	}
+	return []business.ReportOption{business.ConvertCurrencies()}, nil
+}
+
+func parseReportTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Now().UTC(), nil
+	}
+
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid report time %q: %w", value, err)
+	}
+	return at, nil
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(body)
+	if err != nil {
+		util.Log(r.Context()).WithError(err).Error("could not write response body")
+	}
+}
+
+func writeReportError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, business.ErrReportCurrencyInvalid) || errors.Is(err, business.ErrReportPeriodInvalid) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if errors.Is(err, apperrors.ErrFXRateNotFound) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	util.Log(r.Context()).WithError(err).Error("could not generate report")
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
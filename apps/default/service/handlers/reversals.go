@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/pitabwire/util"
+	"github.com/shopspring/decimal"
+)
+
+// Reversal paths, e.g. POST /transactions/reversals/reverse with a JSON ReversalRequest body and
+// GET /transactions/reversals/reversible?id=txn-42.
+const (
+	ReversalsPath   = "/transactions/reversals/"
+	ReversePath     = "/transactions/reversals/reverse"
+	ReversiblePath  = "/transactions/reversals/reversible"
+	ReversalIDParam = "id"
+)
+
+// maxReversalRequestSize bounds the reversal read from a request body.
+const maxReversalRequestSize = 1 << 16
+
+// ReversalRequest is the JSON body of a reversal. Without an amount or entries all that remains of the
+// transaction is reversed, with them the reversal needs an id.
+type ReversalRequest struct {
+	ID            string           `json:"id,omitempty"`
+	TransactionID string           `json:"transaction_id"`
+	Amount        string           `json:"amount,omitempty"`
+	Entries       []*ReversalEntry `json:"entries,omitempty"`
+	Data          map[string]any   `json:"data,omitempty"`
+}
+
+// ReversalEntry names an entry of the transaction to reverse. Without an amount all that remains of the
+// entry is reversed.
+type ReversalEntry struct {
+	EntryID string `json:"entry_id"`
+	Amount  string `json:"amount,omitempty"`
+}
+
+// Reversible is the JSON body of what remains reversible of a transaction, formatted in its currency.
+type Reversible struct {
+	TransactionID string   `json:"transaction_id"`
+	Currency      string   `json:"currency"`
+	Amount        string   `json:"amount"`
+	Reversed      string   `json:"reversed"`
+	Remaining     string   `json:"remaining"`
+	Reversals     []string `json:"reversals,omitempty"`
+}
+
+// ReversalResponse is the JSON body answered for a reversal.
+type ReversalResponse struct {
+	Reversible    *Reversible `json:"reversible"`
+	TransactionID string      `json:"transaction_id"`
+}
+
+// ReversalsHandler reverses transactions in whole or in part.
+type ReversalsHandler struct {
+	Transaction business.TransactionBusiness
+	mux         *http.ServeMux
+}
+
+// NewReversalsHandler creates a new ReversalsHandler with injected dependencies.
+func NewReversalsHandler(transactionBusiness business.TransactionBusiness) *ReversalsHandler {
+	h := &ReversalsHandler{
+		Transaction: transactionBusiness,
+		mux:         http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("GET "+ReversiblePath, h.GetReversible)
+	h.mux.HandleFunc("POST "+ReversePath, h.Reverse)
+	return h
+}
+
+func (h *ReversalsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// GetReversible answers with what remains reversible of the named transaction.
+func (h *ReversalsHandler) GetReversible(w http.ResponseWriter, r *http.Request) {
+	reversible, err := h.Transaction.GetReversible(r.Context(), r.URL.Query().Get(ReversalIDParam))
+	if err != nil {
+		writeReversalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, toReversible(reversible))
+}
+
+// Reverse posts the reversal in the request body and answers with the reversal's id and what remains
+// reversible of the transaction.
+func (h *ReversalsHandler) Reverse(w http.ResponseWriter, r *http.Request) {
+	body := new(ReversalRequest)
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxReversalRequestSize)).Decode(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	request := &business.ReversalRequest{
+		ID:            body.ID,
+		TransactionID: body.TransactionID,
+		Data:          body.Data,
+	}
+	if body.Amount != "" {
+		request.Amount, err = decimal.NewFromString(body.Amount)
+		if err != nil {
+			http.Error(w, "invalid amount: "+body.Amount, http.StatusBadRequest)
+			return
+		}
+	}
+	for _, entry := range body.Entries {
+		line := &business.ReversalEntry{EntryID: entry.EntryID}
+		if entry.Amount != "" {
+			line.Amount, err = decimal.NewFromString(entry.Amount)
+			if err != nil {
+				http.Error(w, "invalid amount: "+entry.Amount, http.StatusBadRequest)
+				return
+			}
+		}
+		request.Entries = append(request.Entries, line)
+	}
+
+	reversal, err := h.Transaction.Reverse(r.Context(), request)
+	if err != nil {
+		writeReversalError(w, r, err)
+		return
+	}
+
+	reversible, err := h.Transaction.GetReversible(r.Context(), body.TransactionID)
+	if err != nil {
+		writeReversalError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, &ReversalResponse{Reversible: toReversible(reversible), TransactionID: reversal.GetID()})
+}
+
+func toReversible(reversible *business.Reversible) *Reversible {
+	return &Reversible{
+		TransactionID: reversible.TransactionID,
+		Currency:      reversible.Currency,
+		Amount:        utility.MoneyString(reversible.Currency, reversible.Amount),
+		Reversed:      utility.MoneyString(reversible.Currency, reversible.Reversed),
+		Remaining:     utility.MoneyString(reversible.Currency, reversible.Remaining),
+		Reversals:     reversible.Reversals,
+	}
+}
+
+func writeReversalError(w http.ResponseWriter, r *http.Request, err error) {
+	var appErr apperrors.ApplicationError
+	switch {
+	case errors.Is(err, business.ErrTransactionIDRequired), errors.Is(err, business.ErrReversalInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, apperrors.ErrTransactionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, apperrors.ErrTransactionReversalExceeded),
+		errors.Is(err, apperrors.ErrTransactionIsConfilicting):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, apperrors.ErrPeriodClosed):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.As(err, &appErr) && !errors.Is(err, apperrors.ErrSystemFailure):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		util.Log(r.Context()).WithError(err).Error("could not reverse transaction")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
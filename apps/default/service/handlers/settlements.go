@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/pitabwire/util"
+)
+
+// SettlementsPath settles a batch of pending transactions given as a JSON SettlementRequest body.
+const SettlementsPath = "/transactions/settlements"
+
+// maxSettlementRequestSize bounds the settlement read from a request body.
+const maxSettlementRequestSize = 1 << 22
+
+// SettlementRequest is the JSON body of a settlement. It names the transactions to settle in items, or
+// else selects the pending ones matching query. Action is CLEAR or FAIL, and reference applies to the
+// items that give none.
+type SettlementRequest struct {
+	Action    string            `json:"action"`
+	Reference string            `json:"reference,omitempty"`
+	ClearedAt time.Time         `json:"cleared_at"`
+	Query     string            `json:"query,omitempty"`
+	Items     []*SettlementItem `json:"items,omitempty"`
+}
+
+// SettlementItem is a pending transaction to settle with its settlement reference.
+type SettlementItem struct {
+	TransactionID string `json:"transaction_id"`
+	Reference     string `json:"reference,omitempty"`
+}
+
+// SettlementResponse is the JSON body answered for a settlement: the ids of the transactions cleared, of
+// the reversals posted for failed ones and of those an earlier run had already settled.
+type SettlementResponse struct {
+	Action    string   `json:"action"`
+	Settled   []string `json:"settled"`
+	Reversals []string `json:"reversals,omitempty"`
+	Skipped   []string `json:"skipped,omitempty"`
+}
+
+// SettlementsHandler clears and fails pending transactions in batches.
+type SettlementsHandler struct {
+	Transaction business.TransactionBusiness
+}
+
+// NewSettlementsHandler creates a new SettlementsHandler with injected dependencies.
+func NewSettlementsHandler(transactionBusiness business.TransactionBusiness) *SettlementsHandler {
+	return &SettlementsHandler{
+		Transaction: transactionBusiness,
+	}
+}
+
+// ServeHTTP settles the batch in the request body and answers with what it settled.
+func (h *SettlementsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	body := new(SettlementRequest)
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSettlementRequestSize)).Decode(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	request := &business.SettlementRequest{
+		Action:    body.Action,
+		Query:     body.Query,
+		Reference: body.Reference,
+		ClearedAt: body.ClearedAt,
+	}
+	for _, item := range body.Items {
+		request.Items = append(request.Items, &business.SettlementItem{
+			TransactionID: item.TransactionID,
+			Reference:     item.Reference,
+		})
+	}
+
+	settlement, err := h.Transaction.Settle(r.Context(), request)
+	if err != nil {
+		writeSettlementError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, &SettlementResponse{
+		Action:    settlement.Action,
+		Settled:   transactionIDs(settlement.Settled),
+		Reversals: transactionIDs(settlement.Reversals),
+		Skipped:   settlement.Skipped,
+	})
+}
+
+func transactionIDs(transactions []*models.Transaction) []string {
+	ids := make([]string, 0, len(transactions))
+	for _, transaction := range transactions {
+		ids = append(ids, transaction.GetID())
+	}
+	return ids
+}
+
+func writeSettlementError(w http.ResponseWriter, r *http.Request, err error) {
+	var appErr apperrors.ApplicationError
+	switch {
+	case errors.Is(err, business.ErrTransactionIDRequired), errors.Is(err, business.ErrSettlementActionInvalid),
+		errors.Is(err, business.ErrSettlementEmpty), errors.Is(err, business.ErrSettlementTooLarge):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, apperrors.ErrTransactionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, apperrors.ErrTransactionNotPending):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, apperrors.ErrPeriodClosed):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.As(err, &appErr) && !errors.Is(err, apperrors.ErrSystemFailure):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		util.Log(r.Context()).WithError(err).Error("could not settle transactions")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
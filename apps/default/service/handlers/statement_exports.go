@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/apps/default/service/export"
+	"github.com/pitabwire/util"
+)
+
+// StatementExportPath serves account statements as files, e.g.
+// /statements/export?account_id=acc&from=2026-09-30T23:59:59Z&to=2026-10-31T23:59:59Z&format=camt053.
+const StatementExportPath = "/statements/export"
+
+// Statement export query parameters.
+const (
+	StatementExportAccountParam = "account_id"
+	StatementExportFormatParam  = "format"
+)
+
+// StatementExportHandler streams account statements as CSV, OFX or camt.053 files.
+type StatementExportHandler struct {
+	Statement business.StatementBusiness
+}
+
+// NewStatementExportHandler creates a new StatementExportHandler with injected dependencies.
+func NewStatementExportHandler(statementBusiness business.StatementBusiness) *StatementExportHandler {
+	return &StatementExportHandler{
+		Statement: statementBusiness,
+	}
+}
+
+// ServeHTTP renders the requested statement, writing each batch of lines as soon as it is read.
+// Errors found before the first byte is written are returned with a matching status;
+// later ones can only abort the download.
+func (h *StatementExportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	from, to, err := parseStatementPeriod(query.Get(StatementFromKey), query.Get(StatementToKey))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writer, err := export.NewWriter(query.Get(StatementExportFormatParam), w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	opened := false
+	open := func(statement *business.Statement) error {
+		if opened {
+			return nil
+		}
+		opened = true
+
+		w.Header().Set("Content-Type", writer.ContentType())
+		w.Header().Set("Content-Disposition",
+			fmt.Sprintf("attachment; filename=%q", export.FileName(statement, writer)))
+		return writer.Open(statement)
+	}
+
+	statement, err := h.Statement.AccountStatement(ctx, query.Get(StatementExportAccountParam), from, to,
+		func(_ context.Context, statement *business.Statement, lines []*business.StatementLine) error {
+			openErr := open(statement)
+			if openErr != nil {
+				return openErr
+			}
+
+			writeErr := writer.Lines(statement, lines)
+			if writeErr != nil {
+				return writeErr
+			}
+
+			if flusher != nil {
+				flusher.Flush()
+			}
+			return nil
+		})
+	if err == nil {
+		err = open(statement)
+	}
+	if err == nil {
+		err = writer.Close(statement)
+	}
+
+	if err != nil {
+		if !opened {
+			http.Error(w, err.Error(), exportErrorStatus(err))
+			return
+		}
+		util.Log(ctx).WithError(err).Error("could not complete statement export")
+	}
+}
+
+func exportErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, business.ErrAccountNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, business.ErrAccountIDRequired), errors.Is(err, business.ErrStatementPeriodInvalid):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
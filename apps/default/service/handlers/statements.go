@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	commonv1 "buf.build/gen/go/antinvestor/common/protocolbuffers/go/common/v1"
+	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
+	"connectrpc.com/connect"
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/internal/utility"
+)
+
+// AccountStatementPath streams account statements over Connect. The published ledger API has no
+// statement RPC, so it is served under a path of this service like the plain HTTP endpoints.
+const AccountStatementPath = "/statements/account"
+
+// Statement summary metadata. The opening and closing balances are sent as response headers,
+// the debit and credit totals as trailers once every line has been streamed.
+const (
+	StatementOpeningBalanceHeader = "Statement-Opening-Balance"
+	StatementClosingBalanceHeader = "Statement-Closing-Balance"
+	StatementCurrencyHeader       = "Statement-Currency"
+	StatementTotalDebitsTrailer   = "Statement-Total-Debits"
+	StatementTotalCreditsTrailer  = "Statement-Total-Credits"
+	StatementEntryCountTrailer    = "Statement-Entry-Count"
+)
+
+// Statement request extras naming the period covered, as RFC3339 timestamps.
+const (
+	StatementFromKey = "from"
+	StatementToKey   = "to"
+)
+
+type StatementServer struct {
+	Statement business.StatementBusiness
+}
+
+// NewStatementServer creates a new StatementServer with injected dependencies.
+func NewStatementServer(statementBusiness business.StatementBusiness) *StatementServer {
+	return &StatementServer{
+		Statement: statementBusiness,
+	}
+}
+
+// NewStatementServiceHandler builds the HTTP handler for the statement stream and returns the path to mount it on.
+func NewStatementServiceHandler(server *StatementServer, opts ...connect.HandlerOption) (string, http.Handler) {
+	return AccountStatementPath, connect.NewServerStreamHandler(
+		AccountStatementPath, server.AccountStatement, opts...)
+}
+
+// AccountStatement streams the statement of the account in id_query for the period given by the
+// "from" and "to" extras. Each entry carries the account balance after it in acc_balance.
+func (statementSrv *StatementServer) AccountStatement(
+	ctx context.Context,
+	req *connect.Request[commonv1.SearchRequest],
+	stream *connect.ServerStream[ledgerv1.SearchTransactionEntriesResponse],
+) error {
+	from, to, err := statementPeriod(req.Msg)
+	if err != nil {
+		return err
+	}
+
+	headersSet := false
+	setHeaders := func(statement *business.Statement) {
+		if headersSet {
+			return
+		}
+		headersSet = true
+
+		header := stream.ResponseHeader()
+		header.Set(StatementCurrencyHeader, statement.Currency)
+		header.Set(StatementOpeningBalanceHeader, utility.MoneyString(statement.Currency, statement.OpeningBalance))
+		header.Set(StatementClosingBalanceHeader, utility.MoneyString(statement.Currency, statement.ClosingBalance))
+	}
+
+	statement, err := statementSrv.Statement.AccountStatement(ctx, req.Msg.GetIdQuery(), from, to,
+		func(_ context.Context, statement *business.Statement, lines []*business.StatementLine) error {
+			setHeaders(statement)
+
+			entries := make([]*ledgerv1.TransactionEntry, 0, len(lines))
+			for _, line := range lines {
+				entries = append(entries, line.ToAPI())
+			}
+
+			return stream.Send(&ledgerv1.SearchTransactionEntriesResponse{
+				Data: entries,
+			})
+		})
+	if err != nil {
+		return err
+	}
+
+	setHeaders(statement)
+
+	trailer := stream.ResponseTrailer()
+	trailer.Set(StatementTotalDebitsTrailer, utility.MoneyString(statement.Currency, statement.TotalDebits))
+	trailer.Set(StatementTotalCreditsTrailer, utility.MoneyString(statement.Currency, statement.TotalCredits))
+	trailer.Set(StatementEntryCountTrailer, strconv.Itoa(statement.EntryCount))
+
+	return nil
+}
+
+// statementPeriod reads the statement period from the request extras.
+func statementPeriod(req *commonv1.SearchRequest) (time.Time, time.Time, error) {
+	extras := req.GetExtras().GetFields()
+	return parseStatementPeriod(extras[StatementFromKey].GetStringValue(), extras[StatementToKey].GetStringValue())
+}
+
+// parseStatementPeriod parses the start and end of a statement period from RFC3339 timestamps.
+func parseStatementPeriod(fromValue, toValue string) (time.Time, time.Time, error) {
+	from, err := time.Parse(time.RFC3339, fromValue)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from %v", business.ErrStatementPeriodInvalid, err)
+	}
+
+	to, err := time.Parse(time.RFC3339, toValue)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: to %v", business.ErrStatementPeriodInvalid, err)
+	}
+
+	return from, to, nil
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/business"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/antinvestor/service-ledger/internal/utility"
+	"github.com/pitabwire/util"
+)
+
+// Posting template paths, e.g. GET /templates/template?name=p2p-with-fee, POST /templates/template with a
+// JSON models.PostingTemplate body and POST /templates/post with a JSON TemplatePostingRequest body.
+// A template is named by its id.
+const (
+	TemplatesPath     = "/templates/"
+	TemplatePath      = "/templates/template"
+	TemplatePostPath  = "/templates/post"
+	TemplateNameParam = "name"
+)
+
+// maxTemplateRequestSize bounds the template or posting read from a request body.
+const maxTemplateRequestSize = 1 << 16
+
+// TemplatePostingRequest is the JSON body of a templated posting. The id parameter, when given, is the id
+// of the transaction posted.
+type TemplatePostingRequest struct {
+	Template string            `json:"template"`
+	Params   map[string]string `json:"params"`
+	DryRun   bool              `json:"dry_run,omitempty"`
+}
+
+// TemplatePostingEntry is an entry of a templated posting with its amount formatted in its currency.
+type TemplatePostingEntry struct {
+	AccountID string `json:"account_id"`
+	Credit    bool   `json:"credit"`
+	Amount    string `json:"amount"`
+}
+
+// TemplatePostingResponse is the JSON body answered for a templated posting. Posted is false on a dry run,
+// which only expands the template.
+type TemplatePostingResponse struct {
+	TransactionID string                  `json:"transaction_id"`
+	Currency      string                  `json:"currency"`
+	Entries       []*TemplatePostingEntry `json:"entries"`
+	Posted        bool                    `json:"posted"`
+}
+
+// TemplatesHandler stores posting templates and posts transactions from them.
+type TemplatesHandler struct {
+	Template business.TemplateBusiness
+	mux      *http.ServeMux
+}
+
+// NewTemplatesHandler creates a new TemplatesHandler with injected dependencies.
+func NewTemplatesHandler(templateBusiness business.TemplateBusiness) *TemplatesHandler {
+	h := &TemplatesHandler{
+		Template: templateBusiness,
+		mux:      http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("GET "+TemplatePath, h.GetTemplate)
+	h.mux.HandleFunc("POST "+TemplatePath, h.SaveTemplate)
+	h.mux.HandleFunc("POST "+TemplatePostPath, h.PostTemplate)
+	return h
+}
+
+func (h *TemplatesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// GetTemplate answers with the named template.
+func (h *TemplatesHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	template, err := h.Template.GetTemplate(r.Context(), r.URL.Query().Get(TemplateNameParam))
+	if err != nil {
+		writeTemplateError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, template)
+}
+
+// SaveTemplate stores the template in the request body and answers with it.
+func (h *TemplatesHandler) SaveTemplate(w http.ResponseWriter, r *http.Request) {
+	template := new(models.PostingTemplate)
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxTemplateRequestSize)).Decode(template)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	template, err = h.Template.SaveTemplate(r.Context(), template)
+	if err != nil {
+		writeTemplateError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, template)
+}
+
+// PostTemplate posts, or on a dry run only expands, the templated posting in the request body and answers
+// with the transaction's entries.
+func (h *TemplatesHandler) PostTemplate(w http.ResponseWriter, r *http.Request) {
+	body := new(TemplatePostingRequest)
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxTemplateRequestSize)).Decode(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var transaction *models.Transaction
+	if body.DryRun {
+		transaction, err = h.Template.ExpandTemplate(r.Context(), body.Template, body.Params)
+	} else {
+		transaction, err = h.Template.PostTemplate(r.Context(), body.Template, body.Params)
+	}
+	if err != nil {
+		writeTemplateError(w, r, err)
+		return
+	}
+
+	response := &TemplatePostingResponse{
+		TransactionID: transaction.GetID(),
+		Currency:      transaction.Currency,
+		Posted:        !body.DryRun,
+	}
+	for _, entry := range transaction.Entries {
+		response.Entries = append(response.Entries, &TemplatePostingEntry{
+			AccountID: entry.AccountID,
+			Credit:    entry.Credit,
+			Amount:    utility.MoneyString(transaction.Currency, entry.Amount.Decimal.Abs()),
+		})
+	}
+
+	writeJSON(w, r, response)
+}
+
+func writeTemplateError(w http.ResponseWriter, r *http.Request, err error) {
+	var appErr apperrors.ApplicationError
+	switch {
+	case errors.Is(err, business.ErrPostingTemplateInvalid), errors.Is(err, business.ErrPostingTemplateParams):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, business.ErrPostingTemplateNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, apperrors.ErrTransactionIsConfilicting):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, apperrors.ErrPeriodClosed):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.As(err, &appErr) && !errors.Is(err, apperrors.ErrSystemFailure):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		util.Log(r.Context()).WithError(err).Error("could not process posting template")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
@@ -7,3 +7,40 @@ const (
 	LedgerTypeIncome    = "INCOME"
 	LedgerTypeCapital   = "CAPITAL"
 )
+
+// Account statuses control which entry directions an account accepts.
+const (
+	AccountStatusActive        = "ACTIVE"
+	AccountStatusFrozen        = "FROZEN"
+	AccountStatusDebitBlocked  = "DEBIT_BLOCKED"
+	AccountStatusCreditBlocked = "CREDIT_BLOCKED"
+)
+
+// IsValidAccountStatus reports whether status is one of the known account statuses.
+func IsValidAccountStatus(status string) bool {
+	switch status {
+	case AccountStatusActive, AccountStatusFrozen, AccountStatusDebitBlocked, AccountStatusCreditBlocked:
+		return true
+	default:
+		return false
+	}
+}
+
+// Ledger funds policies decide what the accounts of a ledger may spend. Under BALANCE, the default,
+// normal debits are held to the balance floor alone. Under AVAILABLE, debits and new reservations are
+// held to the available balance, so funds already reserved cannot be spent twice.
+const (
+	FundsPolicyBalance   = "BALANCE"
+	FundsPolicyAvailable = "AVAILABLE"
+)
+
+// IsValidFundsPolicy reports whether policy is one of the known funds policies.
+func IsValidFundsPolicy(policy string) bool {
+	return policy == FundsPolicyBalance || policy == FundsPolicyAvailable
+}
+
+// PeriodNameLayout formats the name of the monthly accounting period a time falls in, e.g. 2026-09.
+const PeriodNameLayout = "2006-01"
+
+// YearEndDataKey marks year-end closing transactions in their data with the fiscal year they close.
+const YearEndDataKey = "year_end"
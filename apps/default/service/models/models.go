@@ -2,6 +2,7 @@ package models
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
@@ -14,9 +15,11 @@ import (
 // Ledger represents the hierarchy for organising ledgers with information such as type, and JSON data.
 type Ledger struct {
 	data.BaseModel
-	Type     string       `gorm:"type:varchar(50)"                     json:"type"`
-	ParentID string       `gorm:"type:varchar(50)"                     json:"parent_id"`
-	Data     data.JSONMap `gorm:"type:jsonb;index:,gin:jsonb_path_ops" json:"data"`
+	Type         string              `gorm:"type:varchar(50)"                     json:"type"`
+	ParentID     string              `gorm:"type:varchar(50)"                     json:"parent_id"`
+	Data         data.JSONMap        `gorm:"type:jsonb;index:,gin:jsonb_path_ops" json:"data"`
+	BalanceFloor decimal.NullDecimal `gorm:"type:numeric(29,9)"                   json:"balance_floor"`
+	FundsPolicy  string              `gorm:"type:varchar(20);default:'BALANCE'"   json:"funds_policy"`
 }
 
 func FromLedgerType(raw ledgerv1.LedgerType) string {
@@ -33,16 +36,210 @@ func (lg *Ledger) ToAPI() *ledgerv1.Ledger {
 		Parent: lg.ParentID, Data: lg.Data.ToProtoStruct()}
 }
 
+// LedgerTreeNode is a ledger within a subtree of the ledger hierarchy. ChildCount and AccountCount count the
+// ledger's direct children and accounts, Balances sums the accounts of the whole subtree by currency.
+// Children is empty past the requested depth even when ChildCount is not.
+type LedgerTreeNode struct {
+	Ledger       *Ledger
+	Depth        int
+	ChildCount   int
+	AccountCount int
+	Balances     map[string]*LedgerTreeBalance
+	Children     []*LedgerTreeNode
+}
+
+// LedgerTreeBalance holds the summed balances of a ledger subtree in one currency.
+type LedgerTreeBalance struct {
+	Balance          decimal.Decimal
+	UnClearedBalance decimal.Decimal
+	ReservedBalance  decimal.Decimal
+}
+
 // Account represents the ledger account with information such as Reference, balance and JSON data.
 type Account struct {
 	data.BaseModel
-	Currency         string              `gorm:"type:varchar(10)"                     json:"currency"`
-	Balance          decimal.NullDecimal `gorm:"-"                                    json:"balance"`
-	UnClearedBalance decimal.NullDecimal `gorm:"-"                                    json:"un_cleared_balance"`
-	ReservedBalance  decimal.NullDecimal `gorm:"-"                                    json:"reserved_balance"`
-	LedgerID         string              `gorm:"type:varchar(50)"                     json:"ledger_id"`
-	Data             data.JSONMap        `gorm:"type:jsonb;index:,gin:jsonb_path_ops" json:"data"`
-	LedgerType       string              `gorm:"type:varchar(50)"                     json:"ledger_type"`
+	Currency          string              `gorm:"type:varchar(10)"                     json:"currency"`
+	Balance           decimal.NullDecimal `gorm:"-"                                    json:"balance"`
+	UnClearedBalance  decimal.NullDecimal `gorm:"-"                                    json:"un_cleared_balance"`
+	ReservedBalance   decimal.NullDecimal `gorm:"-"                                    json:"reserved_balance"`
+	UnClearedOutgoing decimal.NullDecimal `gorm:"-"                                    json:"uncleared_outgoing"`
+	ReservedOutgoing  decimal.NullDecimal `gorm:"-"                                    json:"reserved_outgoing"`
+	LedgerID          string              `gorm:"type:varchar(50)"                     json:"ledger_id"`
+	Data              data.JSONMap        `gorm:"type:jsonb;index:,gin:jsonb_path_ops" json:"data"`
+	LedgerType        string              `gorm:"type:varchar(50)"                     json:"ledger_type"`
+	ClosedAt          *time.Time          `gorm:"type:timestamp"                       json:"closed_at"`
+	ClosedReason      string              `gorm:"type:text"                            json:"closed_reason"`
+	Status            string              `gorm:"type:varchar(20);default:'ACTIVE'"    json:"status"`
+	BalanceFloor      decimal.NullDecimal `gorm:"type:numeric(29,9)"                   json:"balance_floor"`
+	LedgerFloor       decimal.NullDecimal `gorm:"-"                                    json:"ledger_floor"`
+	FundsPolicy       string              `gorm:"-"                                    json:"funds_policy"`
+	LastSequence      int64               `gorm:"-"                                    json:"last_sequence"`
+	BalancesAsOf      *time.Time          `gorm:"-"                                    json:"balances_as_of"`
+}
+
+// AccountBalance holds the running balances of an account, keyed by the account id.
+// It is updated in the same database transaction as every posting and clearing that affects the account.
+type AccountBalance struct {
+	data.BaseModel
+	Currency          string          `gorm:"type:varchar(10)"                                                json:"currency"`
+	Balance           decimal.Decimal `gorm:"type:numeric(29,9);not null;default:0"                           json:"balance"`
+	UnClearedBalance  decimal.Decimal `gorm:"column:uncleared_balance;type:numeric(29,9);not null;default:0"  json:"uncleared_balance"`
+	ReservedBalance   decimal.Decimal `gorm:"type:numeric(29,9);not null;default:0"                           json:"reserved_balance"`
+	UnClearedOutgoing decimal.Decimal `gorm:"column:uncleared_outgoing;type:numeric(29,9);not null;default:0" json:"uncleared_outgoing"`
+	ReservedOutgoing  decimal.Decimal `gorm:"type:numeric(29,9);not null;default:0"                           json:"reserved_outgoing"`
+	LastEntrySequence int64           `gorm:"not null;default:0"                                              json:"last_entry_sequence"`
+}
+
+// BalanceDiscrepancy records an account whose maintained balances did not match the sum of its entries
+// when it was last verified.
+type BalanceDiscrepancy struct {
+	data.BaseModel
+	AccountID                string          `gorm:"type:varchar(50);not null;index"       json:"account_id"`
+	Currency                 string          `gorm:"type:varchar(10)"                      json:"currency"`
+	CachedBalance            decimal.Decimal `gorm:"type:numeric(29,9);not null;default:0" json:"cached_balance"`
+	ComputedBalance          decimal.Decimal `gorm:"type:numeric(29,9);not null;default:0" json:"computed_balance"`
+	CachedUnClearedBalance   decimal.Decimal `gorm:"type:numeric(29,9);not null;default:0" json:"cached_uncleared_balance"`
+	ComputedUnClearedBalance decimal.Decimal `gorm:"type:numeric(29,9);not null;default:0" json:"computed_uncleared_balance"`
+	CachedReservedBalance    decimal.Decimal `gorm:"type:numeric(29,9);not null;default:0" json:"cached_reserved_balance"`
+	ComputedReservedBalance  decimal.Decimal `gorm:"type:numeric(29,9);not null;default:0" json:"computed_reserved_balance"`
+}
+
+// AccountingPeriod is a calendar month of postings, named like 2026-09 and covering [StartsAt, EndsAt) in UTC.
+// A period is only stored once it is closed, after which postings into it are adjustments.
+type AccountingPeriod struct {
+	data.BaseModel
+	Name     string    `gorm:"type:varchar(20);not null;uniqueIndex" json:"name"`
+	StartsAt time.Time `gorm:"type:timestamp;not null"               json:"starts_at"`
+	EndsAt   time.Time `gorm:"type:timestamp;not null"               json:"ends_at"`
+	ClosedAt time.Time `gorm:"type:timestamp;not null"               json:"closed_at"`
+	ClosedBy string    `gorm:"type:varchar(50)"                      json:"closed_by"`
+}
+
+// PeriodBalance is the balance of an account at the end of a period, as it stood when the period was closed.
+type PeriodBalance struct {
+	data.BaseModel
+	PeriodID         string          `gorm:"type:varchar(50);not null;index"                                json:"period_id"`
+	AccountID        string          `gorm:"type:varchar(50);not null;index"                                json:"account_id"`
+	Currency         string          `gorm:"type:varchar(10)"                                               json:"currency"`
+	Balance          decimal.Decimal `gorm:"type:numeric(29,9);not null;default:0"                          json:"balance"`
+	UnClearedBalance decimal.Decimal `gorm:"column:uncleared_balance;type:numeric(29,9);not null;default:0" json:"uncleared_balance"`
+	ReservedBalance  decimal.Decimal `gorm:"type:numeric(29,9);not null;default:0"                          json:"reserved_balance"`
+}
+
+// PeriodName returns the name of the accounting period that at falls in.
+func PeriodName(at time.Time) string {
+	return at.UTC().Format(PeriodNameLayout)
+}
+
+// FXRate is the amount of QuoteCurrency one unit of BaseCurrency buys from EffectiveAt on,
+// until a later rate for the same pair takes effect.
+type FXRate struct {
+	data.BaseModel
+	BaseCurrency  string          `gorm:"type:varchar(10);not null;uniqueIndex:idx_fx_rate_pair_at" json:"base_currency"`
+	QuoteCurrency string          `gorm:"type:varchar(10);not null;uniqueIndex:idx_fx_rate_pair_at" json:"quote_currency"`
+	EffectiveAt   time.Time       `gorm:"type:timestamp;not null;uniqueIndex:idx_fx_rate_pair_at"   json:"effective_at"`
+	Rate          decimal.Decimal `gorm:"type:numeric(29,9);not null"                               json:"rate"`
+	Source        string          `gorm:"type:varchar(100)"                                         json:"source"`
+}
+
+// FXRevaluation is the last revaluation of a foreign currency account, keyed by the account id.
+// BaseValue is Balance at Rate, the carrying value in the base currency the next revaluation starts from.
+type FXRevaluation struct {
+	data.BaseModel
+	Currency      string          `gorm:"type:varchar(10)"                      json:"currency"`
+	BaseCurrency  string          `gorm:"type:varchar(10)"                      json:"base_currency"`
+	Balance       decimal.Decimal `gorm:"type:numeric(29,9);not null;default:0" json:"balance"`
+	Rate          decimal.Decimal `gorm:"type:numeric(29,9);not null;default:0" json:"rate"`
+	BaseValue     decimal.Decimal `gorm:"type:numeric(29,9);not null;default:0" json:"base_value"`
+	RevaluedAt    time.Time       `gorm:"type:timestamp"                        json:"revalued_at"`
+	TransactionID string          `gorm:"type:varchar(50)"                      json:"transaction_id"`
+}
+
+// Hold statuses. A hold stays OPEN while any of its amount is reserved.
+const (
+	HoldStatusOpen     = "OPEN"
+	HoldStatusCaptured = "CAPTURED"
+	HoldStatusReleased = "RELEASED"
+	HoldStatusExpired  = "EXPIRED"
+)
+
+// Hold tracks the lifecycle of a RESERVATION transaction, keyed by its id. Amount is the positive amount
+// reserved by its single entry, whose direction Credit records. Captures and releases each post a
+// RESERVATION entry the other way, linked by HoldID, so reserved balances stay the sum of their entries.
+type Hold struct {
+	data.BaseModel
+	AccountID string          `gorm:"type:varchar(50);not null;index"       json:"account_id"`
+	Currency  string          `gorm:"type:varchar(10)"                      json:"currency"`
+	Amount    decimal.Decimal `gorm:"type:numeric(29,9);not null;default:0" json:"amount"`
+	Credit    bool            `gorm:"not null;default:false"                json:"credit"`
+	Captured  decimal.Decimal `gorm:"type:numeric(29,9);not null;default:0" json:"captured"`
+	Released  decimal.Decimal `gorm:"type:numeric(29,9);not null;default:0" json:"released"`
+	Status    string          `gorm:"type:varchar(20);not null;index"       json:"status"`
+	ExpiresAt *time.Time      `gorm:"type:timestamp;index"                  json:"expires_at"`
+}
+
+// Remaining returns the amount of the hold still reserved.
+func (h *Hold) Remaining() decimal.Decimal {
+	return h.Amount.Sub(h.Captured).Sub(h.Released)
+}
+
+// AccountStatusChange records who changed an account's status, when and why.
+type AccountStatusChange struct {
+	data.BaseModel
+	AccountID  string `gorm:"type:varchar(50);not null;index" json:"account_id"`
+	FromStatus string `gorm:"type:varchar(20)"                json:"from_status"`
+	ToStatus   string `gorm:"type:varchar(20);not null"       json:"to_status"`
+	Actor      string `gorm:"type:varchar(255)"               json:"actor"`
+	Reason     string `gorm:"type:text"                       json:"reason"`
+}
+
+// IsClosed reports whether the account has been closed and can no longer be posted to.
+func (acc *Account) IsClosed() bool {
+	return acc.ClosedAt != nil && !acc.ClosedAt.IsZero()
+}
+
+// CurrentStatus returns the account status, treating accounts created before statuses existed as active.
+func (acc *Account) CurrentStatus() string {
+	if acc.Status == "" {
+		return AccountStatusActive
+	}
+	return acc.Status
+}
+
+// AllowsDebit reports whether the account status permits debit entries.
+func (acc *Account) AllowsDebit() bool {
+	status := acc.CurrentStatus()
+	return status != AccountStatusFrozen && status != AccountStatusDebitBlocked
+}
+
+// AllowsCredit reports whether the account status permits credit entries.
+func (acc *Account) AllowsCredit() bool {
+	status := acc.CurrentStatus()
+	return status != AccountStatusFrozen && status != AccountStatusCreditBlocked
+}
+
+// EffectiveBalanceFloor returns the lowest balance the account may reach, preferring the account's own
+// floor over the one inherited from its ledger. An invalid result means the balance is unbounded.
+func (acc *Account) EffectiveBalanceFloor() decimal.NullDecimal {
+	if acc.BalanceFloor.Valid {
+		return acc.BalanceFloor
+	}
+	return acc.LedgerFloor
+}
+
+// AvailableBalance returns what the account can spend: its balance less pending debits and the funds
+// its holds reserve, which are the outgoing parts of its uncleared and reserved balances. Pending credits
+// and reservations adding to the account are not counted until they clear, so they never offset them.
+func (acc *Account) AvailableBalance() decimal.Decimal {
+	return acc.Balance.Decimal.
+		Add(acc.UnClearedOutgoing.Decimal).
+		Add(acc.ReservedOutgoing.Decimal)
+}
+
+// HoldsToAvailableBalance reports whether the account's ledger holds its debits and reservations to its
+// available balance rather than its balance alone.
+func (acc *Account) HoldsToAvailableBalance() bool {
+	return acc.FundsPolicy == FundsPolicyAvailable
 }
 
 func (acc *Account) ToAPI() *ledgerv1.Account {
@@ -71,6 +268,12 @@ func (acc *Account) ToAPI() *ledgerv1.Account {
 		Data: acc.Data.ToProtoStruct()}
 }
 
+// HasZeroBalances reports whether the cleared, uncleared and reserved balances are all zero.
+func (acc *Account) HasZeroBalances() bool {
+	return acc.Balance.Decimal.IsZero() && acc.UnClearedBalance.Decimal.IsZero() &&
+		acc.ReservedBalance.Decimal.IsZero()
+}
+
 func TransactionFromAPI(ctx context.Context, aTxn *ledgerv1.Transaction) *Transaction {
 	dataMap := &data.JSONMap{}
 	transaction := &Transaction{
@@ -159,12 +362,31 @@ func (te *TransactionEntry) ToAPI() *ledgerv1.TransactionEntry {
 // Transaction represents a transaction in a ledger.
 type Transaction struct {
 	data.BaseModel
-	Currency        string              `gorm:"type:varchar(10);not null"            json:"currency"`
-	TransactionType string              `gorm:"type:varchar(50)"                     json:"transaction_type"`
-	Data            data.JSONMap        `gorm:"type:jsonb;index:,gin:jsonb_path_ops" json:"data"`
-	ClearedAt       time.Time           `gorm:"type:timestamp"                       json:"cleared_at"`
-	TransactedAt    time.Time           `gorm:"type:timestamp"                       json:"transacted_at"`
-	Entries         []*TransactionEntry `gorm:"foreignKey:TransactionID"             json:"entries"`
+	Currency            string              `gorm:"type:varchar(10);not null"            json:"currency"`
+	TransactionType     string              `gorm:"type:varchar(50)"                     json:"transaction_type"`
+	Data                data.JSONMap        `gorm:"type:jsonb;index:,gin:jsonb_path_ops" json:"data"`
+	ClearedAt           time.Time           `gorm:"type:timestamp"                       json:"cleared_at"`
+	TransactedAt        time.Time           `gorm:"type:timestamp"                       json:"transacted_at"`
+	AdjustedPeriod      string              `gorm:"type:varchar(20)"                     json:"adjusted_period"`
+	Exchange            Exchange            `gorm:"embedded;embeddedPrefix:exchange_"    json:"exchange"`
+	HoldID              string              `gorm:"type:varchar(50);index"               json:"hold_id,omitempty"`
+	HoldExpiresAt       *time.Time          `gorm:"-"                                    json:"-"`
+	SettlementReference string              `gorm:"type:varchar(100);index"              json:"settlement_reference,omitempty"`
+	ReversalOf          string              `gorm:"type:varchar(50);index"               json:"reversal_of,omitempty"`
+	Entries             []*TransactionEntry `gorm:"foreignKey:TransactionID"             json:"entries"`
+}
+
+// Exchange describes the currency conversion a transaction is a leg of. Each currency of an exchange
+// is posted as its own balanced transaction and every leg carries the same details, linked by ID.
+// Rate is the amount of TargetCurrency bought by one unit of SourceCurrency.
+type Exchange struct {
+	ID             string              `gorm:"type:varchar(50);index" json:"id,omitempty"`
+	Rate           decimal.NullDecimal `gorm:"type:numeric(29,9)"     json:"rate"`
+	RateSource     string              `gorm:"type:varchar(100)"      json:"rate_source,omitempty"`
+	SourceCurrency string              `gorm:"type:varchar(10)"       json:"source_currency,omitempty"`
+	SourceAmount   decimal.NullDecimal `gorm:"type:numeric(29,9)"     json:"source_amount"`
+	TargetCurrency string              `gorm:"type:varchar(10)"       json:"target_currency,omitempty"`
+	TargetAmount   decimal.NullDecimal `gorm:"type:numeric(29,9)"     json:"target_amount"`
 }
 
 // TransactionEntry represents a transaction line in a ledger.
@@ -176,6 +398,7 @@ type TransactionEntry struct {
 	Amount        decimal.NullDecimal `gorm:"type:numeric(29,9)"              json:"amount"`
 	Credit        bool                `                                       json:"credit"`
 	Balance       decimal.NullDecimal `gorm:"type:numeric(29,9)"              json:"balance"`
+	Sequence      int64               `gorm:"type:bigint"                     json:"sequence"`
 	ClearedAt     time.Time           `gorm:"-"                               json:"cleared_at"`
 	TransactedAt  time.Time           `gorm:"-"                               json:"transacted_at"`
 }
@@ -198,6 +421,33 @@ func (tx *Transaction) IsZeroSum() bool {
 	return sum.IsZero()
 }
 
+// AccountIDs returns the distinct ids of the accounts touched by the transaction in ascending order.
+func (tx *Transaction) AccountIDs() []string {
+	accountIDSet := map[string]bool{}
+	accountIDs := make([]string, 0, len(tx.Entries))
+	for _, entry := range tx.Entries {
+		if !accountIDSet[entry.AccountID] {
+			accountIDSet[entry.AccountID] = true
+			accountIDs = append(accountIDs, entry.AccountID)
+		}
+	}
+	sort.Strings(accountIDs)
+	return accountIDs
+}
+
+// HasDrCr reports whether there is at least one debit and one credit entry.
+func (tx *Transaction) HasDrCr() bool {
+	hasCredit, hasDebit := false, false
+	for _, entry := range tx.Entries {
+		if entry.Credit {
+			hasCredit = true
+		} else {
+			hasDebit = true
+		}
+	}
+	return hasCredit && hasDebit
+}
+
 // IsTrueDrCr validates that there is one debit and at least one credit entry.
 func (tx *Transaction) IsTrueDrCr() bool {
 	crEntries := 0
@@ -212,3 +462,24 @@ func (tx *Transaction) IsTrueDrCr() bool {
 	}
 	return drEntries == 1 && crEntries >= 1
 }
+
+// PostingTemplate is a named rule for posting a NORMAL transaction from parameters, keyed by its name.
+// Each leg names its account with an expression such as "{payer}" or "fees-{currency}" and its amount
+// with a formula over the numeric parameters and the amounts of the legs named before it, e.g.
+// "amount * fee_rate%" or "amount - fee". Currency is an expression too, e.g. "{currency}" or "UGX".
+type PostingTemplate struct {
+	data.BaseModel
+	Description string        `gorm:"type:text"                  json:"description"`
+	Currency    string        `gorm:"type:varchar(50);not null"  json:"currency"`
+	Pending     bool          `gorm:"not null;default:false"     json:"pending"`
+	Legs        []*PostingLeg `gorm:"type:jsonb;serializer:json" json:"legs"`
+}
+
+// PostingLeg is one entry of a posting template. A leg whose amount works out to zero is left out, so an
+// optional fee leg needs no template of its own.
+type PostingLeg struct {
+	Name    string `json:"name,omitempty"`
+	Account string `json:"account"`
+	Credit  bool   `json:"credit"`
+	Amount  string `json:"amount"`
+}
@@ -2,8 +2,11 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/antinvestor/service-ledger/apps/default/service/models"
 	"github.com/antinvestor/service-ledger/internal/apperrors"
@@ -12,26 +15,18 @@ import (
 	"github.com/pitabwire/frame/datastore/pool"
 	"github.com/pitabwire/frame/workerpool"
 	"github.com/pitabwire/util"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
-const constAccountQuery = `WITH current_balance_summary AS (
-    SELECT 
-        e.account_id, 
-        t.currency,
-        COALESCE(SUM(CASE WHEN t.transaction_type IN ('NORMAL', 'REVERSAL') AND t.cleared_at IS NOT NULL AND t.cleared_at != '0001-01-01 00:00:00' THEN e.amount ELSE 0 END), 0) AS balance,
-        COALESCE(SUM(CASE WHEN t.transaction_type IN ('NORMAL', 'REVERSAL') AND (t.cleared_at IS NULL OR t.cleared_at = '0001-01-01 00:00:00') THEN e.amount ELSE 0 END), 0) AS uncleared_balance,
-        COALESCE(SUM(CASE WHEN t.transaction_type = 'RESERVATION' THEN e.amount ELSE 0 END), 0) AS reserved_balance
-    FROM transaction_entries e 
-    LEFT JOIN transactions t ON e.transaction_id = t.id
-    GROUP BY e.account_id, t.currency
-)
-SELECT 
+// constAccountQuery reads accounts with their maintained balances from account_balances.
+const constAccountQuery = `SELECT 
     a.id,
     a.currency,
     a.data,
-    COALESCE(bs.balance, 0) AS total_balance,
-    COALESCE(bs.uncleared_balance, 0) AS total_uncleared_balance,
-    COALESCE(bs.reserved_balance, 0) AS total_reserved_balance,
+    COALESCE(ab.balance, 0) AS total_balance,
+    COALESCE(ab.uncleared_balance, 0) AS total_uncleared_balance,
+    COALESCE(ab.reserved_balance, 0) AS total_reserved_balance,
     a.ledger_id,
     a.ledger_type,
     a.created_at,
@@ -40,14 +35,157 @@ SELECT
     a.tenant_id,
     a.partition_id,
     a.access_id,
-    a.deleted_at
+    a.deleted_at,
+    a.closed_at,
+    COALESCE(a.closed_reason, '') AS closed_reason,
+    COALESCE(a.status, 'ACTIVE') AS status,
+    a.balance_floor,
+    (SELECT l.balance_floor FROM ledgers l WHERE l.id = a.ledger_id) AS ledger_floor,
+    COALESCE((SELECT l.funds_policy FROM ledgers l WHERE l.id = a.ledger_id), 'BALANCE') AS funds_policy,
+    COALESCE(ab.last_entry_sequence, 0) AS last_sequence,
+    COALESCE(ab.uncleared_outgoing, 0) AS uncleared_outgoing,
+    COALESCE(ab.reserved_outgoing, 0) AS reserved_outgoing
 FROM accounts a
-LEFT JOIN current_balance_summary bs ON a.id = bs.account_id AND a.currency = bs.currency `
+LEFT JOIN (
+    SELECT id AS balance_account_id, balance, uncleared_balance, reserved_balance, last_entry_sequence,
+        uncleared_outgoing, reserved_outgoing
+    FROM account_balances
+) ab ON ab.balance_account_id = a.id `
+
+// constAccountBalanceAggregateQuery recomputes account balances from every entry.
+// It is too expensive for the posting path and is kept to verify the maintained account_balances.
+const constAccountBalanceAggregateQuery = `SELECT 
+    e.account_id,
+    t.currency,
+    COALESCE(SUM(CASE WHEN t.transaction_type IN ('NORMAL', 'REVERSAL') AND t.cleared_at IS NOT NULL AND t.cleared_at != '0001-01-01 00:00:00' THEN e.amount ELSE 0 END), 0) AS balance,
+    COALESCE(SUM(CASE WHEN t.transaction_type IN ('NORMAL', 'REVERSAL') AND (t.cleared_at IS NULL OR t.cleared_at = '0001-01-01 00:00:00') THEN e.amount ELSE 0 END), 0) AS uncleared_balance,
+    COALESCE(SUM(CASE WHEN t.transaction_type = 'RESERVATION' THEN e.amount ELSE 0 END), 0) AS reserved_balance,
+    COALESCE(MAX(e.sequence), 0) AS last_entry_sequence
+FROM transaction_entries e 
+JOIN transactions t ON e.transaction_id = t.id
+JOIN accounts a ON a.id = e.account_id AND a.currency = t.currency
+WHERE e.account_id IN ?
+GROUP BY e.account_id, t.currency`
+
+// constAccountBalanceAtQuery recomputes account balances from the entries of transactions made up to @at.
+// When @by_cleared is set a transaction only counts as cleared if it was cleared by @at,
+// otherwise its current clearing state is used.
+const constAccountBalanceAtQuery = `SELECT 
+    e.account_id,
+    t.currency,
+    COALESCE(SUM(CASE WHEN t.transaction_type IN ('NORMAL', 'REVERSAL') AND t.cleared_at IS NOT NULL AND t.cleared_at != '0001-01-01 00:00:00' AND (NOT @by_cleared OR t.cleared_at <= @at) THEN e.amount ELSE 0 END), 0) AS balance,
+    COALESCE(SUM(CASE WHEN t.transaction_type IN ('NORMAL', 'REVERSAL') AND NOT (t.cleared_at IS NOT NULL AND t.cleared_at != '0001-01-01 00:00:00' AND (NOT @by_cleared OR t.cleared_at <= @at)) THEN e.amount ELSE 0 END), 0) AS uncleared_balance,
+    COALESCE(SUM(CASE WHEN t.transaction_type = 'RESERVATION' THEN e.amount ELSE 0 END), 0) AS reserved_balance,
+    COALESCE(MAX(e.sequence), 0) AS last_entry_sequence
+FROM transaction_entries e 
+JOIN transactions t ON e.transaction_id = t.id
+JOIN accounts a ON a.id = e.account_id AND a.currency = t.currency
+WHERE e.account_id IN @ids AND t.transacted_at <= @at
+GROUP BY e.account_id, t.currency`
+
+// constNominalBalancesQuery sums the cleared postings to every INCOME and EXPENSE account in @currency that
+// were transacted up to @at, in each account's natural sign, leaving out the closing transactions of
+// @closing_year.
+const constNominalBalancesQuery = `SELECT 
+    a.id,
+    a.ledger_id,
+    a.ledger_type,
+    COALESCE(SUM(e.amount), 0) AS balance
+FROM transaction_entries e 
+JOIN transactions t ON e.transaction_id = t.id
+JOIN accounts a ON a.id = e.account_id AND a.currency = t.currency
+WHERE t.currency = @currency
+  AND a.ledger_type IN ('INCOME', 'EXPENSE')
+  AND t.transaction_type IN ('NORMAL', 'REVERSAL')
+  AND t.cleared_at IS NOT NULL AND t.cleared_at != '0001-01-01 00:00:00'
+  AND t.transacted_at <= @at
+  AND COALESCE(t.data ->> @year_end_key, '') != @closing_year
+GROUP BY a.id, a.ledger_id, a.ledger_type
+HAVING COALESCE(SUM(e.amount), 0) != 0
+ORDER BY a.id`
+
+// constLedgerBalanceQuery sums the cleared postings of every ledger in @currency that were transacted in
+// (@from, @to] and cleared by @to, in each account's natural sign. A NULL @from starts from the first posting.
+// Over a period, year-end closing transactions are left out so income and expenses show what was earned.
+const constLedgerBalanceQuery = `SELECT 
+    a.ledger_id,
+    COALESCE(SUM(e.amount), 0) AS balance
+FROM transaction_entries e 
+JOIN transactions t ON e.transaction_id = t.id
+JOIN accounts a ON a.id = e.account_id AND a.currency = t.currency
+WHERE t.currency = @currency
+  AND t.transaction_type IN ('NORMAL', 'REVERSAL')
+  AND t.cleared_at IS NOT NULL AND t.cleared_at != '0001-01-01 00:00:00' AND t.cleared_at <= @to
+  AND t.transacted_at <= @to
+  AND (CAST(@from AS timestamp) IS NULL OR (t.transacted_at > @from AND t.data -> @year_end_key IS NULL))
+GROUP BY a.ledger_id`
+
+// constBalanceDiscrepancyQuery compares the maintained account_balances with the entry sums in a single
+// statement, so both sides are read from the same snapshot and in-flight postings cannot show up as drift.
+const constBalanceDiscrepancyQuery = `SELECT 
+    a.id,
+    a.currency,
+    a.tenant_id,
+    a.partition_id,
+    a.access_id,
+    COALESCE(ab.balance, 0),
+    COALESCE(c.balance, 0),
+    COALESCE(ab.uncleared_balance, 0),
+    COALESCE(c.uncleared_balance, 0),
+    COALESCE(ab.reserved_balance, 0),
+    COALESCE(c.reserved_balance, 0)
+FROM accounts a
+LEFT JOIN account_balances ab ON ab.id = a.id
+LEFT JOIN (` + constAccountBalanceAggregateQuery + `) c ON c.account_id = a.id
+WHERE a.id IN ?
+  AND (COALESCE(ab.balance, 0) <> COALESCE(c.balance, 0)
+    OR COALESCE(ab.uncleared_balance, 0) <> COALESCE(c.uncleared_balance, 0)
+    OR COALESCE(ab.reserved_balance, 0) <> COALESCE(c.reserved_balance, 0))
+ORDER BY a.id`
+
+// constAccountViewQuery reads accounts with the balances captured in account_balances_view at its last refresh.
+const constAccountViewQuery = `SELECT 
+    v.account_id,
+    v.currency,
+    v.data,
+    v.balance,
+    v.uncleared_balance,
+    v.reserved_balance,
+    v.ledger_id,
+    v.ledger_type,
+    v.created_at,
+    v.modified_at,
+    v.version,
+    v.tenant_id,
+    v.partition_id,
+    v.access_id,
+    v.deleted_at,
+    (SELECT MAX(l.last_refresh) FROM account_balances_view_refresh_log l) AS balances_as_of
+FROM account_balances_view v
+WHERE v.deleted_at IS NULL AND v.account_id IN ?`
 
 type AccountRepository interface {
 	datastore.BaseRepository[*models.Account]
 	SearchAsESQ(ctx context.Context, query string) (workerpool.JobResultPipe[[]*models.Account], error)
 	ListByID(ctx context.Context, ids ...string) (map[string]*models.Account, error)
+	UpdateStatus(ctx context.Context, account *models.Account, change *models.AccountStatusChange) error
+	Close(ctx context.Context, id string, reason string, check func(account *models.Account) error) error
+	ListStatusChanges(ctx context.Context, accountID string) ([]*models.AccountStatusChange, error)
+	AggregateBalances(ctx context.Context, accountIDs ...string) (map[string]*models.AccountBalance, error)
+	AggregateBalancesAt(ctx context.Context, at time.Time, byClearedAt bool,
+		accountIDs ...string) (map[string]*models.AccountBalance, error)
+	ListIDsAfter(ctx context.Context, afterID string, limit int) ([]string, error)
+	FindBalanceDiscrepancies(ctx context.Context, accountIDs ...string) ([]*models.BalanceDiscrepancy, error)
+	SaveBalanceDiscrepancies(ctx context.Context, discrepancies []*models.BalanceDiscrepancy) error
+	ListBalanceDiscrepancies(ctx context.Context, accountID string) ([]*models.BalanceDiscrepancy, error)
+	LedgerBalances(ctx context.Context, currency string, from *time.Time, to time.Time,
+	) (map[string]decimal.Decimal, error)
+	ListNominalBalances(ctx context.Context, currency string, at time.Time,
+		closingYear int) ([]*models.Account, error)
+	ListForeignAccounts(ctx context.Context, baseCurrency string, ledgerTypes ...string) ([]*models.Account, error)
+	ListCurrencies(ctx context.Context) ([]string, error)
+	RefreshBalancesView(ctx context.Context) error
+	ListByIDFromView(ctx context.Context, ids ...string) (map[string]*models.Account, error)
 }
 
 // accountRepository provides all functions related to ledger account.
@@ -139,30 +277,468 @@ func (a *accountRepository) ListByID(
 	}
 }
 
+// queryAccounts runs constAccountQuery for live accounts matching condition on the supplied db handle,
+// which allows callers to read balances from within an open transaction.
+func queryAccounts(ctx context.Context, db *gorm.DB, condition string, args ...any) ([]*models.Account, error) {
+	rows, err := db.Raw(fmt.Sprintf(`%s WHERE a.deleted_at IS NULL AND %s`, constAccountQuery, condition), args...).
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+
+	defer util.CloseAndLogOnError(ctx, rows, "could not close account rows")
+
+	return scanAccounts(rows)
+}
+
+// UpdateStatus stores the account's new status and the audit record of the change in a single transaction.
+func (a *accountRepository) UpdateStatus(
+	ctx context.Context,
+	account *models.Account,
+	change *models.AccountStatusChange,
+) error {
+	err := a.Pool().DB(ctx, false).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(account).
+			Where("id = ? AND version = ?", account.ID, account.Version).
+			Select("status", "modified_at", "version").
+			Updates(account)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("account %s was modified concurrently", account.ID)
+		}
+
+		return tx.Create(change).Error
+	})
+	if err != nil {
+		return apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return nil
+}
+
+// Close marks the account id closed for reason once check passes on the account and its balances as read
+// under the row lock postings take, so that no posting can change them before the closure commits.
+// Create inserts account, holding a share lock on its ledger until it is committed so that the ledger
+// cannot be archived from under it.
+func (a *accountRepository) Create(ctx context.Context, account *models.Account) error {
+	return a.Pool().DB(ctx, false).Transaction(func(tx *gorm.DB) error {
+		txErr := lockLedger(tx, account.LedgerID, apperrors.ErrLedgerNotFound)
+		if txErr != nil {
+			return txErr
+		}
+
+		return tx.Create(account).Error
+	})
+}
+
+// An account already closed is left as it is.
+func (a *accountRepository) Close(
+	ctx context.Context,
+	id string,
+	reason string,
+	check func(account *models.Account) error,
+) error {
+	return a.Pool().DB(ctx, false).Transaction(func(tx *gorm.DB) error {
+		accounts, err := lockedAccounts(ctx, tx, []string{id})
+		if err != nil {
+			return err
+		}
+
+		account, ok := accounts[id]
+		if !ok {
+			return apperrors.ErrAccountNotFound.Extend(id)
+		}
+		if account.IsClosed() {
+			return nil
+		}
+
+		err = check(account)
+		if err != nil {
+			return err
+		}
+
+		closedAt := time.Now()
+		err = tx.Model(&models.Account{}).Where("id = ?", id).Updates(map[string]any{
+			"closed_at":     closedAt,
+			"closed_reason": reason,
+			"modified_at":   closedAt,
+			"version":       gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return apperrors.ErrSystemFailure.Override(err)
+		}
+
+		return nil
+	})
+}
+
+// ListStatusChanges returns the status history of an account, oldest first.
+func (a *accountRepository) ListStatusChanges(
+	ctx context.Context,
+	accountID string,
+) ([]*models.AccountStatusChange, error) {
+	var changes []*models.AccountStatusChange
+	err := a.Pool().DB(ctx, true).
+		Where("account_id = ?", accountID).
+		Order("created_at ASC").
+		Find(&changes).Error
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return changes, nil
+}
+
+// AggregateBalances recomputes the balances of the given accounts by summing all their entries,
+// for comparison against the maintained account_balances.
+func (a *accountRepository) AggregateBalances(
+	ctx context.Context,
+	accountIDs ...string,
+) (map[string]*models.AccountBalance, error) {
+	balances := make(map[string]*models.AccountBalance, len(accountIDs))
+	if len(accountIDs) == 0 {
+		return balances, nil
+	}
+
+	rows, err := a.Pool().DB(ctx, true).Raw(constAccountBalanceAggregateQuery, accountIDs).Rows()
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	defer util.CloseAndLogOnError(ctx, rows, "could not close account balance rows")
+
+	return scanAccountBalances(rows, balances)
+}
+
+// AggregateBalancesAt recomputes the balances the given accounts held at the instant at,
+// from the entries of transactions transacted up to then. With byClearedAt a transaction is only
+// treated as cleared if it had been cleared by at, which reproduces the balances as they were reported then.
+func (a *accountRepository) AggregateBalancesAt(
+	ctx context.Context,
+	at time.Time,
+	byClearedAt bool,
+	accountIDs ...string,
+) (map[string]*models.AccountBalance, error) {
+	balances := make(map[string]*models.AccountBalance, len(accountIDs))
+	if len(accountIDs) == 0 {
+		return balances, nil
+	}
+
+	rows, err := a.Pool().DB(ctx, true).Raw(constAccountBalanceAtQuery, map[string]any{
+		"ids":        accountIDs,
+		"at":         at,
+		"by_cleared": byClearedAt,
+	}).Rows()
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	defer util.CloseAndLogOnError(ctx, rows, "could not close account balance rows")
+
+	return scanAccountBalances(rows, balances)
+}
+
+// scanAccountBalances reads rows of the balance aggregate queries into balances, keyed by account id.
+func scanAccountBalances(
+	rows *sql.Rows,
+	balances map[string]*models.AccountBalance,
+) (map[string]*models.AccountBalance, error) {
+	for rows.Next() {
+		balance := &models.AccountBalance{}
+		err := rows.Scan(&balance.ID, &balance.Currency, &balance.Balance, &balance.UnClearedBalance,
+			&balance.ReservedBalance, &balance.LastEntrySequence)
+		if err != nil {
+			return nil, apperrors.ErrSystemFailure.Override(err)
+		}
+		balances[balance.ID] = balance
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return balances, nil
+}
+
+// LedgerBalances returns the cleared balances held by the accounts of each ledger in currency, keyed by
+// ledger id. With a from time only postings after it are summed, giving the movement over (from, to]
+// before any year-end close.
+func (a *accountRepository) LedgerBalances(
+	ctx context.Context,
+	currency string,
+	from *time.Time,
+	to time.Time,
+) (map[string]decimal.Decimal, error) {
+	var rows []struct {
+		LedgerID string
+		Balance  decimal.Decimal
+	}
+
+	err := a.Pool().DB(ctx, true).Raw(constLedgerBalanceQuery, map[string]any{
+		"currency":     currency,
+		"from":         from,
+		"to":           to,
+		"year_end_key": models.YearEndDataKey,
+	}).Scan(&rows).Error
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	balances := make(map[string]decimal.Decimal, len(rows))
+	for _, row := range rows {
+		balances[row.LedgerID] = row.Balance
+	}
+
+	return balances, nil
+}
+
+// ListNominalBalances returns the INCOME and EXPENSE accounts in currency with a non-zero cleared balance
+// at the given instant, ignoring the closing transactions of closingYear.
+func (a *accountRepository) ListNominalBalances(
+	ctx context.Context,
+	currency string,
+	at time.Time,
+	closingYear int,
+) ([]*models.Account, error) {
+	var rows []struct {
+		ID         string
+		LedgerID   string
+		LedgerType string
+		Balance    decimal.Decimal
+	}
+
+	err := a.Pool().DB(ctx, true).Raw(constNominalBalancesQuery, map[string]any{
+		"currency":     currency,
+		"at":           at,
+		"year_end_key": models.YearEndDataKey,
+		"closing_year": strconv.Itoa(closingYear),
+	}).Scan(&rows).Error
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	accounts := make([]*models.Account, 0, len(rows))
+	for _, row := range rows {
+		account := &models.Account{
+			Currency:   currency,
+			LedgerID:   row.LedgerID,
+			LedgerType: row.LedgerType,
+			Balance:    decimal.NewNullDecimal(row.Balance),
+		}
+		account.ID = row.ID
+		accounts = append(accounts, account)
+	}
+
+	return accounts, nil
+}
+
+// ListForeignAccounts returns the accounts of ledgerTypes held in any currency but baseCurrency,
+// with their maintained balances, ordered by id.
+func (a *accountRepository) ListForeignAccounts(
+	ctx context.Context,
+	baseCurrency string,
+	ledgerTypes ...string,
+) ([]*models.Account, error) {
+	// Clauses chained onto a raw query are dropped, so the account query is ordered as a subquery.
+	db := a.Pool().DB(ctx, true)
+	rows, err := db.Table("(?) AS a", db.Raw(constAccountQuery+` WHERE a.deleted_at IS NULL`)).
+		Where("a.currency != ? AND a.ledger_type IN ?", baseCurrency, ledgerTypes).
+		Order("a.id").
+		Rows()
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	defer util.CloseAndLogOnError(ctx, rows, "could not close account rows")
+
+	accounts, err := scanAccounts(rows)
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return accounts, nil
+}
+
+// ListCurrencies returns every currency accounts are held in.
+func (a *accountRepository) ListCurrencies(ctx context.Context) ([]string, error) {
+	var currencies []string
+	err := a.Pool().DB(ctx, true).Raw(
+		`SELECT DISTINCT currency FROM accounts WHERE deleted_at IS NULL ORDER BY currency`).
+		Scan(&currencies).Error
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return currencies, nil
+}
+
+// ListIDsAfter returns up to limit account ids that sort after afterID, allowing callers to walk
+// every account in stable windows.
+func (a *accountRepository) ListIDsAfter(ctx context.Context, afterID string, limit int) ([]string, error) {
+	var ids []string
+	err := a.Pool().DB(ctx, true).Raw(
+		`SELECT id FROM accounts WHERE deleted_at IS NULL AND id > ? ORDER BY id LIMIT ?`, afterID, limit).
+		Scan(&ids).Error
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return ids, nil
+}
+
+// FindBalanceDiscrepancies returns the given accounts whose maintained balances differ from their entry sums.
+func (a *accountRepository) FindBalanceDiscrepancies(
+	ctx context.Context,
+	accountIDs ...string,
+) ([]*models.BalanceDiscrepancy, error) {
+	var discrepancies []*models.BalanceDiscrepancy
+	if len(accountIDs) == 0 {
+		return discrepancies, nil
+	}
+
+	rows, err := a.Pool().DB(ctx, true).Raw(constBalanceDiscrepancyQuery, accountIDs, accountIDs).Rows()
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	defer util.CloseAndLogOnError(ctx, rows, "could not close balance discrepancy rows")
+
+	for rows.Next() {
+		d := &models.BalanceDiscrepancy{}
+		err = rows.Scan(&d.AccountID, &d.Currency, &d.TenantID, &d.PartitionID, &d.AccessID,
+			&d.CachedBalance, &d.ComputedBalance, &d.CachedUnClearedBalance, &d.ComputedUnClearedBalance,
+			&d.CachedReservedBalance, &d.ComputedReservedBalance)
+		if err != nil {
+			return nil, apperrors.ErrSystemFailure.Override(err)
+		}
+		discrepancies = append(discrepancies, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return discrepancies, nil
+}
+
+// SaveBalanceDiscrepancies persists the discrepancies found by a verification run.
+func (a *accountRepository) SaveBalanceDiscrepancies(
+	ctx context.Context,
+	discrepancies []*models.BalanceDiscrepancy,
+) error {
+	if len(discrepancies) == 0 {
+		return nil
+	}
+
+	err := a.Pool().DB(ctx, false).Create(&discrepancies).Error
+	if err != nil {
+		return apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return nil
+}
+
+// ListBalanceDiscrepancies returns the discrepancies recorded against an account, oldest first.
+func (a *accountRepository) ListBalanceDiscrepancies(
+	ctx context.Context,
+	accountID string,
+) ([]*models.BalanceDiscrepancy, error) {
+	var discrepancies []*models.BalanceDiscrepancy
+	err := a.Pool().DB(ctx, true).
+		Where("account_id = ?", accountID).
+		Order("created_at ASC").
+		Find(&discrepancies).Error
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return discrepancies, nil
+}
+
+// RefreshBalancesView recomputes account_balances_view and records the refresh time.
+// A refresh already running on another instance is left to finish on its own.
+func (a *accountRepository) RefreshBalancesView(ctx context.Context) error {
+	err := a.Pool().DB(ctx, false).Exec(`SELECT refresh_account_balances_view()`).Error
+	if err != nil {
+		return apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return nil
+}
+
+// ListByIDFromView returns accounts with the balances held in account_balances_view.
+// The balances may lag the ledger by up to the refresh interval; BalancesAsOf carries the time of
+// the refresh they were read from, and is nil if the view has not been refreshed by the service yet.
+func (a *accountRepository) ListByIDFromView(
+	ctx context.Context,
+	ids ...string,
+) (map[string]*models.Account, error) {
+	if len(ids) == 0 {
+		return nil, apperrors.ErrAccountsNotFound.Extend("No Accounts were specified")
+	}
+
+	rows, err := a.Pool().DB(ctx, true).Raw(constAccountViewQuery, ids).Rows()
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	defer util.CloseAndLogOnError(ctx, rows, "could not close account view rows")
+
+	accountsMap := make(map[string]*models.Account, len(ids))
+	for rows.Next() {
+		acc := &models.Account{}
+		err = rows.Scan(
+			&acc.ID, &acc.Currency, &acc.Data, &acc.Balance, &acc.UnClearedBalance, &acc.ReservedBalance,
+			&acc.LedgerID, &acc.LedgerType, &acc.CreatedAt, &acc.ModifiedAt, &acc.Version, &acc.TenantID,
+			&acc.PartitionID, &acc.AccessID, &acc.DeletedAt, &acc.BalancesAsOf)
+		if err != nil {
+			return nil, apperrors.ErrSystemFailure.Override(err)
+		}
+		accountsMap[acc.ID] = acc
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return accountsMap, nil
+}
+
 func (a *accountRepository) searchAccounts(ctx context.Context, sqlQuery *SearchSQLQuery) ([]*models.Account, error) {
 	rows, err := a.Pool().DB(ctx, true).
 		Offset(sqlQuery.offset).Limit(sqlQuery.batchSize).
-		Raw(fmt.Sprintf(`%s WHERE %s`, constAccountQuery, sqlQuery.sql), sqlQuery.args...).Rows()
+		Raw(fmt.Sprintf(`%s WHERE a.deleted_at IS NULL AND %s`, constAccountQuery, sqlQuery.sql), sqlQuery.args...).
+		Rows()
 	if err != nil {
 		return nil, err
 	}
 
 	defer util.CloseAndLogOnError(ctx, rows, "could not close account rows")
 
+	return scanAccounts(rows)
+}
+
+// scanAccounts reads account rows produced by constAccountQuery.
+func scanAccounts(rows *sql.Rows) ([]*models.Account, error) {
 	var accountList []*models.Account
 	for rows.Next() {
 		acc := models.Account{}
-		err = rows.Scan(
+		err := rows.Scan(
 			&acc.ID, &acc.Currency, &acc.Data, &acc.Balance, &acc.UnClearedBalance, &acc.ReservedBalance,
 			&acc.LedgerID, &acc.LedgerType, &acc.CreatedAt, &acc.ModifiedAt, &acc.Version, &acc.TenantID,
-			&acc.PartitionID, &acc.AccessID, &acc.DeletedAt)
+			&acc.PartitionID, &acc.AccessID, &acc.DeletedAt, &acc.ClosedAt, &acc.ClosedReason, &acc.Status,
+			&acc.BalanceFloor, &acc.LedgerFloor, &acc.FundsPolicy, &acc.LastSequence,
+			&acc.UnClearedOutgoing, &acc.ReservedOutgoing)
 		if err != nil {
 			return accountList, err
 		}
 		accountList = append(accountList, &acc)
 	}
 
-	return accountList, nil
+	return accountList, rows.Err()
 }
 
 func (a *accountRepository) SearchAsESQ(
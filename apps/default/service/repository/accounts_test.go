@@ -51,3 +51,26 @@ func (as *AccountsSuite) TestAccountsInfoAPI() {
 		assert.True(t, account.Balance.Valid && account.Balance.Decimal.IsZero(), "Invalid account balance")
 	})
 }
+
+func (as *AccountsSuite) TestListByIDFromView() {
+	as.WithTestDependencies(as.T(), func(t *testing.T, dep *definition.DependencyOption) {
+		ctx, _, resources := as.CreateService(t, dep)
+		as.setupFixtures(ctx, resources)
+
+		accountsDB := resources.AccountRepository
+
+		accounts, err := accountsDB.ListByIDFromView(ctx, "100")
+		require.NoError(t, err)
+		assert.NotContains(t, accounts, "100", "Accounts created after the last refresh should not be in the view")
+
+		err = accountsDB.RefreshBalancesView(ctx)
+		require.NoError(t, err)
+
+		accounts, err = accountsDB.ListByIDFromView(ctx, "100")
+		require.NoError(t, err)
+		require.Contains(t, accounts, "100")
+		assert.True(t, accounts["100"].Balance.Decimal.IsZero(), "Invalid account balance")
+		require.NotNil(t, accounts["100"].BalancesAsOf, "Balances should carry the refresh time")
+		assert.False(t, accounts["100"].BalancesAsOf.IsZero())
+	})
+}
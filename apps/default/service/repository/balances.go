@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"time"
+
+	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const constUpsertAccountBalance = `INSERT INTO account_balances (
+    id, currency, balance, uncleared_balance, reserved_balance, uncleared_outgoing, reserved_outgoing,
+    last_entry_sequence, created_at, modified_at, version, tenant_id, partition_id, access_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET
+    balance = account_balances.balance + EXCLUDED.balance,
+    uncleared_balance = account_balances.uncleared_balance + EXCLUDED.uncleared_balance,
+    reserved_balance = account_balances.reserved_balance + EXCLUDED.reserved_balance,
+    uncleared_outgoing = account_balances.uncleared_outgoing + EXCLUDED.uncleared_outgoing,
+    reserved_outgoing = account_balances.reserved_outgoing + EXCLUDED.reserved_outgoing,
+    last_entry_sequence = GREATEST(account_balances.last_entry_sequence, EXCLUDED.last_entry_sequence),
+    modified_at = EXCLUDED.modified_at,
+    version = account_balances.version + 1`
+
+// assignEntrySequences numbers the entries of a transaction per account, continuing from the
+// last sequence recorded for each account and advancing it, so transactions posted together
+// number on from each other. Accounts must be locked by the caller.
+func assignEntrySequences(transaction *models.Transaction, accounts map[string]*models.Account) {
+	for _, entry := range transaction.Entries {
+		account, ok := accounts[entry.AccountID]
+		if !ok {
+			continue
+		}
+
+		account.LastSequence++
+		entry.Sequence = account.LastSequence
+	}
+}
+
+// postingDeltas sums the signed entry amounts of a new transaction into the balance bucket they affect.
+// Amounts taking funds off an account are also summed into the outgoing part of a pending or reserved
+// bucket, and so are the entries settling a hold that took funds off it, which unwind that part.
+func postingDeltas(transaction *models.Transaction) map[string]*models.AccountBalance {
+	deltas := map[string]*models.AccountBalance{}
+	for _, entry := range transaction.Entries {
+		delta, ok := deltas[entry.AccountID]
+		if !ok {
+			delta = &models.AccountBalance{Currency: transaction.Currency}
+			delta.ID = entry.AccountID
+			deltas[entry.AccountID] = delta
+		}
+
+		amount := entry.Amount.Decimal
+		switch {
+		case transaction.TransactionType == ledgerv1.TransactionType_RESERVATION.String():
+			delta.ReservedBalance = delta.ReservedBalance.Add(amount)
+			if amount.IsNegative() == (transaction.HoldID == "") {
+				delta.ReservedOutgoing = delta.ReservedOutgoing.Add(amount)
+			}
+		case transaction.ClearedAt.IsZero():
+			delta.UnClearedBalance = delta.UnClearedBalance.Add(amount)
+			delta.UnClearedOutgoing = delta.UnClearedOutgoing.Add(decimal.Min(amount, decimal.Zero))
+		default:
+			delta.Balance = delta.Balance.Add(amount)
+		}
+
+		if entry.Sequence > delta.LastEntrySequence {
+			delta.LastEntrySequence = entry.Sequence
+		}
+	}
+
+	return deltas
+}
+
+// clearingDeltas moves the entry amounts of a pending transaction from the uncleared to the cleared balance.
+func clearingDeltas(transaction *models.Transaction) map[string]*models.AccountBalance {
+	deltas := map[string]*models.AccountBalance{}
+	if transaction.TransactionType == ledgerv1.TransactionType_RESERVATION.String() {
+		return deltas
+	}
+
+	for _, entry := range transaction.Entries {
+		delta, ok := deltas[entry.AccountID]
+		if !ok {
+			delta = &models.AccountBalance{Currency: transaction.Currency}
+			delta.ID = entry.AccountID
+			deltas[entry.AccountID] = delta
+		}
+
+		delta.Balance = delta.Balance.Add(entry.Amount.Decimal)
+		delta.UnClearedBalance = delta.UnClearedBalance.Sub(entry.Amount.Decimal)
+		delta.UnClearedOutgoing = delta.UnClearedOutgoing.Sub(decimal.Min(entry.Amount.Decimal, decimal.Zero))
+	}
+
+	return deltas
+}
+
+// applyBalanceDeltas adds the deltas to account_balances, creating missing rows with the
+// tenancy of their account. It must run inside the transaction holding the account locks.
+func applyBalanceDeltas(
+	tx *gorm.DB,
+	deltas map[string]*models.AccountBalance,
+	accounts map[string]*models.Account,
+) error {
+	now := time.Now()
+	for accountID, delta := range deltas {
+		account, ok := accounts[accountID]
+		if !ok {
+			continue
+		}
+
+		err := tx.Exec(constUpsertAccountBalance,
+			accountID, account.Currency, delta.Balance, delta.UnClearedBalance, delta.ReservedBalance,
+			delta.UnClearedOutgoing, delta.ReservedOutgoing, delta.LastEntrySequence, now, now,
+			account.TenantID, account.PartitionID, account.AccessID).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/pitabwire/frame/datastore"
+	"github.com/pitabwire/frame/datastore/pool"
+	"github.com/pitabwire/frame/workerpool"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FXRateRepository interface {
+	datastore.BaseRepository[*models.FXRate]
+	SaveRates(ctx context.Context, rates []*models.FXRate) error
+	RateAt(ctx context.Context, baseCurrency, quoteCurrency string, at time.Time) (*models.FXRate, error)
+	PostRevaluation(ctx context.Context, baseCurrency string, accountIDs []string,
+		build func(previous map[string]*models.FXRevaluation) ([]*models.Transaction, []*models.FXRevaluation, error),
+		check func(accounts map[string]*models.Account, closedPeriod string) error) ([]*models.Transaction, error)
+}
+
+// constRevaluationLock serialises revaluations into the same base currency, so that runs started together,
+// e.g. by the job on every replica, each see the revaluations recorded by the one before.
+const constRevaluationLock = `SELECT pg_advisory_xact_lock(hashtext('fx_revaluation:' || ?))`
+
+// fxRateRepository provides all functions related to exchange rates and revaluations.
+type fxRateRepository struct {
+	datastore.BaseRepository[*models.FXRate]
+}
+
+// NewFXRateRepository provides instance of `FXRateRepository`.
+func NewFXRateRepository(ctx context.Context, dbPool pool.Pool, workMan workerpool.Manager) FXRateRepository {
+	return &fxRateRepository{
+		BaseRepository: datastore.NewBaseRepository[*models.FXRate](
+			ctx, dbPool, workMan, func() *models.FXRate { return &models.FXRate{} },
+		),
+	}
+}
+
+// SaveRates stores rates, replacing the rate and source of any pair already rated at the same instant,
+// so importing a rate file again corrects it rather than failing.
+func (r *fxRateRepository) SaveRates(ctx context.Context, rates []*models.FXRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	err := r.Pool().DB(ctx, false).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "base_currency"}, {Name: "quote_currency"}, {Name: "effective_at"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "modified_at"}),
+	}).CreateInBatches(rates, SystemBatchSize).Error
+	if err != nil {
+		return apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return nil
+}
+
+// RateAt returns the rate of the pair in effect at the given instant, the latest one effective by then.
+func (r *fxRateRepository) RateAt(
+	ctx context.Context,
+	baseCurrency, quoteCurrency string,
+	at time.Time,
+) (*models.FXRate, error) {
+	rate := new(models.FXRate)
+	err := r.Pool().DB(ctx, true).
+		Where("base_currency = ? AND quote_currency = ? AND effective_at <= ?", baseCurrency, quoteCurrency, at).
+		Order("effective_at DESC").First(rate).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+// PostRevaluation records a revaluation into baseCurrency of the accounts accountIDs in one database
+// transaction, under a lock taken for the base currency. build is given the last revaluation of each of
+// the accounts read under the lock, and returns the transactions to post and the revaluations to record
+// as the last of their accounts, which are stored together with the transactions or not at all.
+func (r *fxRateRepository) PostRevaluation(
+	ctx context.Context,
+	baseCurrency string,
+	accountIDs []string,
+	build func(previous map[string]*models.FXRevaluation) ([]*models.Transaction, []*models.FXRevaluation, error),
+	check func(accounts map[string]*models.Account, closedPeriod string) error,
+) ([]*models.Transaction, error) {
+	var transactions []*models.Transaction
+	err := r.Pool().DB(ctx, false).Transaction(func(tx *gorm.DB) error {
+		txErr := tx.Exec(constRevaluationLock, baseCurrency).Error
+		if txErr != nil {
+			return apperrors.ErrSystemFailure.Override(txErr)
+		}
+
+		previous := make(map[string]*models.FXRevaluation, len(accountIDs))
+		if len(accountIDs) > 0 {
+			var rows []*models.FXRevaluation
+			txErr = tx.Where("id IN ?", accountIDs).Find(&rows).Error
+			if txErr != nil {
+				return apperrors.ErrSystemFailure.Override(txErr)
+			}
+
+			for _, row := range rows {
+				previous[row.GetID()] = row
+			}
+		}
+
+		var revaluations []*models.FXRevaluation
+		transactions, revaluations, txErr = build(previous)
+		if txErr != nil {
+			return txErr
+		}
+
+		if len(transactions) > 0 {
+			txErr = postLinked(ctx, tx, transactions, check)
+			if txErr != nil {
+				return txErr
+			}
+		}
+
+		if len(revaluations) == 0 {
+			return nil
+		}
+
+		txErr = tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"balance", "rate", "base_value", "revalued_at", "transaction_id", "modified_at",
+			}),
+		}).CreateInBatches(revaluations, SystemBatchSize).Error
+		if txErr != nil {
+			return apperrors.ErrSystemFailure.Override(txErr)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	ledgerv1 "buf.build/gen/go/antinvestor/ledger/protocolbuffers/go/ledger/v1"
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/pitabwire/frame/data"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetHold returns the hold opened by the reservation with the given id.
+func (t *transactionRepository) GetHold(ctx context.Context, id string) (*models.Hold, error) {
+	if id == "" {
+		return nil, apperrors.ErrUnspecifiedID
+	}
+
+	hold := new(models.Hold)
+	err := t.Pool().DB(ctx, true).Where("id = ?", id).First(hold).Error
+	if err != nil {
+		if data.ErrorIsNoRows(err) {
+			return nil, apperrors.ErrHoldNotFound.Extend(id)
+		}
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return hold, nil
+}
+
+// ListExpiredHolds returns up to limit open holds whose expiry is not after at, oldest expiry first.
+func (t *transactionRepository) ListExpiredHolds(
+	ctx context.Context,
+	at time.Time,
+	limit int,
+) ([]*models.Hold, error) {
+	holds := make([]*models.Hold, 0)
+	err := t.Pool().DB(ctx, true).
+		Where("status = ? AND expires_at IS NOT NULL AND expires_at <= ?", models.HoldStatusOpen, at).
+		Order("expires_at, id").Limit(limit).Find(&holds).Error
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return holds, nil
+}
+
+// SettleHold locks a hold and lets settle update it and build the transactions that settle it, which are
+// then posted as by PostLinked. The hold is saved with the transactions, so concurrent captures and releases
+// of one hold apply one after the other and can never settle more than it reserves.
+func (t *transactionRepository) SettleHold(
+	ctx context.Context,
+	holdID string,
+	settle func(hold *models.Hold) ([]*models.Transaction, error),
+	check func(accounts map[string]*models.Account, closedPeriod string) error,
+) (*models.Hold, error) {
+	hold := new(models.Hold)
+	err := t.Pool().DB(ctx, false).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", holdID).First(hold).Error
+		if err != nil {
+			if data.ErrorIsNoRows(err) {
+				return apperrors.ErrHoldNotFound.Extend(holdID)
+			}
+			return apperrors.ErrSystemFailure.Override(err)
+		}
+
+		transactions, err := settle(hold)
+		if err != nil {
+			return err
+		}
+
+		err = postLinked(ctx, tx, transactions, check)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(hold).Select("captured", "released", "status", "modified_at", "version").Updates(hold).Error
+		if err != nil {
+			return apperrors.ErrSystemFailure.Override(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+// openHold records the hold a new reservation places. Reservations that settle a hold open none.
+func openHold(ctx context.Context, tx *gorm.DB, transaction *models.Transaction) error {
+	if transaction.TransactionType != ledgerv1.TransactionType_RESERVATION.String() ||
+		transaction.HoldID != "" || len(transaction.Entries) != 1 {
+		return nil
+	}
+
+	entry := transaction.Entries[0]
+	hold := &models.Hold{
+		AccountID: entry.AccountID,
+		Currency:  transaction.Currency,
+		Amount:    entry.Amount.Decimal.Abs(),
+		Credit:    entry.Credit,
+		Status:    models.HoldStatusOpen,
+		ExpiresAt: transaction.HoldExpiresAt,
+	}
+	hold.GenID(ctx)
+	hold.ID = transaction.GetID()
+	hold.CopyPartitionInfo(&transaction.BaseModel)
+
+	err := tx.Create(hold).Error
+	if err != nil {
+		return apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return nil
+}
@@ -3,6 +3,8 @@ package repository
 import (
 	"context"
 	"fmt"
+	"math"
+	"time"
 
 	"github.com/antinvestor/service-ledger/apps/default/service/models"
 	"github.com/antinvestor/service-ledger/internal/apperrors"
@@ -11,11 +13,20 @@ import (
 	"github.com/pitabwire/frame/datastore/pool"
 	"github.com/pitabwire/frame/workerpool"
 	"github.com/pitabwire/util"
+	"gorm.io/gorm"
 )
 
 type LedgerRepository interface {
 	datastore.BaseRepository[*models.Ledger]
 	SearchAsESQ(ctx context.Context, query string) (workerpool.JobResultPipe[[]*models.Ledger], error)
+	ListAll(ctx context.Context) ([]*models.Ledger, error)
+	GetLedgerTree(ctx context.Context, rootID string, depth int) (*models.LedgerTreeNode, error)
+	MoveLedger(ctx context.Context, id string, parentID string) error
+	Archive(
+		ctx context.Context,
+		id string,
+		check func(ledgerIDs []string, accounts map[string]*models.Account) error,
+	) error
 }
 
 // LedgerRepository provides all functions related to ledger Ledger.
@@ -33,12 +44,72 @@ func NewLedgerRepository(ctx context.Context, dbPool pool.Pool, workMan workerpo
 }
 
 // Query constants for ledger repository.
-const constLedgerQuery = `SELECT id, parent_id, data FROM ledgers`
+const constLedgerQuery = `SELECT id, type, parent_id, data FROM ledgers`
+
+// constLedgerSubtreeQuery walks the live subtree under @root_id. The path of ids from the root lets every
+// ledger be matched with its ancestors and stops the walk should the hierarchy contain a cycle.
+const constLedgerSubtreeQuery = `WITH RECURSIVE subtree AS (
+    SELECT id, parent_id, 0 AS depth, ARRAY[id]::varchar[] AS path
+    FROM ledgers
+    WHERE id = @root_id AND deleted_at IS NULL
+    UNION ALL
+    SELECT l.id, l.parent_id, s.depth + 1, s.path || l.id
+    FROM ledgers l
+    JOIN subtree s ON l.parent_id = s.id
+    WHERE l.deleted_at IS NULL AND NOT l.id = ANY(s.path)
+) `
+
+// constLedgerHierarchyLock is held while a ledger is re-parented so that two concurrent moves
+// cannot each pass the cycle check and close a loop between them.
+const constLedgerHierarchyLock = `SELECT pg_advisory_xact_lock(hashtext('ledger_hierarchy'))`
+
+// constLedgerIsAncestorQuery reports whether @id is @parent_id or one of its ancestors.
+const constLedgerIsAncestorQuery = `WITH RECURSIVE ancestors AS (
+    SELECT id, parent_id, ARRAY[id]::varchar[] AS path
+    FROM ledgers
+    WHERE id = @parent_id AND deleted_at IS NULL
+    UNION ALL
+    SELECT l.id, l.parent_id, a.path || l.id
+    FROM ledgers l
+    JOIN ancestors a ON l.id = a.parent_id
+    WHERE l.deleted_at IS NULL AND NOT l.id = ANY(a.path)
+)
+SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = @id)`
+
+// constLedgerTreeQuery reads the ledgers of the subtree down to @depth with their direct child and account counts.
+const constLedgerTreeQuery = constLedgerSubtreeQuery + `SELECT 
+    l.id,
+    l.type,
+    l.parent_id,
+    l.data,
+    l.balance_floor,
+    s.depth,
+    (SELECT COUNT(*) FROM ledgers c WHERE c.parent_id = l.id AND c.deleted_at IS NULL AND c.id != l.id),
+    (SELECT COUNT(*) FROM accounts a WHERE a.ledger_id = l.id AND a.deleted_at IS NULL)
+FROM subtree s
+JOIN ledgers l ON l.id = s.id
+WHERE s.depth <= @depth
+ORDER BY s.depth, l.id`
+
+// constLedgerTreeBalanceQuery sums the maintained balances of every account in the subtree of each ledger
+// down to @depth, per currency. Accounts deeper than @depth still count towards their ancestors.
+const constLedgerTreeBalanceQuery = constLedgerSubtreeQuery + `SELECT 
+    n.id,
+    a.currency,
+    COALESCE(SUM(ab.balance), 0),
+    COALESCE(SUM(ab.uncleared_balance), 0),
+    COALESCE(SUM(ab.reserved_balance), 0)
+FROM subtree n
+JOIN subtree d ON n.id = ANY(d.path)
+JOIN accounts a ON a.ledger_id = d.id AND a.deleted_at IS NULL
+LEFT JOIN account_balances ab ON ab.id = a.id
+WHERE n.depth <= @depth
+GROUP BY n.id, a.currency`
 
 func (l *ledgerRepository) searchLedgers(ctx context.Context, sqlQuery *SearchSQLQuery) ([]*models.Ledger, error) {
 	rows, err := l.Pool().DB(ctx, true).
 		Offset(sqlQuery.offset).Limit(sqlQuery.batchSize).
-		Raw(fmt.Sprintf(`%s WHERE %s`, constLedgerQuery, sqlQuery.sql), sqlQuery.args...).Rows()
+		Raw(fmt.Sprintf(`%s WHERE deleted_at IS NULL AND %s`, constLedgerQuery, sqlQuery.sql), sqlQuery.args...).Rows()
 	if err != nil {
 		return nil, err
 	}
@@ -48,7 +119,7 @@ func (l *ledgerRepository) searchLedgers(ctx context.Context, sqlQuery *SearchSQ
 	ledgerList := make([]*models.Ledger, 0)
 	for rows.Next() {
 		ledger := new(models.Ledger)
-		errR := rows.Scan(&ledger.ID, &ledger.ParentID, &ledger.Data)
+		errR := rows.Scan(&ledger.ID, &ledger.Type, &ledger.ParentID, &ledger.Data)
 		if errR != nil {
 			return ledgerList, errR
 		}
@@ -99,3 +170,257 @@ func (l *ledgerRepository) SearchAsESQ(
 
 	return job, nil
 }
+
+// ListAll returns every live ledger, for reports that cover the whole chart of accounts.
+func (l *ledgerRepository) ListAll(ctx context.Context) ([]*models.Ledger, error) {
+	ledgerList := make([]*models.Ledger, 0)
+
+	err := l.Pool().DB(ctx, true).Order("id").Find(&ledgerList).Error
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return ledgerList, nil
+}
+
+// GetLedgerTree returns the ledger rootID with its descendants down to depth levels below it in two queries,
+// whatever the size of the subtree. A depth below one returns the whole subtree.
+func (l *ledgerRepository) GetLedgerTree(
+	ctx context.Context,
+	rootID string,
+	depth int,
+) (*models.LedgerTreeNode, error) {
+	if depth < 1 {
+		depth = math.MaxInt32
+	}
+
+	args := map[string]any{"root_id": rootID, "depth": depth}
+	db := l.Pool().DB(ctx, true)
+
+	rows, err := db.Raw(constLedgerTreeQuery, args).Rows()
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+	defer util.CloseAndLogOnError(ctx, rows, "could not close ledger tree rows")
+
+	var root *models.LedgerTreeNode
+	nodes := make(map[string]*models.LedgerTreeNode)
+	for rows.Next() {
+		node := &models.LedgerTreeNode{
+			Ledger:   new(models.Ledger),
+			Balances: make(map[string]*models.LedgerTreeBalance),
+		}
+		err = rows.Scan(&node.Ledger.ID, &node.Ledger.Type, &node.Ledger.ParentID, &node.Ledger.Data,
+			&node.Ledger.BalanceFloor, &node.Depth, &node.ChildCount, &node.AccountCount)
+		if err != nil {
+			return nil, apperrors.ErrSystemFailure.Override(err)
+		}
+
+		nodes[node.Ledger.ID] = node
+		if node.Depth == 0 {
+			root = node
+			continue
+		}
+
+		// Rows are ordered by depth so the parent has always been read already.
+		parent := nodes[node.Ledger.ParentID]
+		parent.Children = append(parent.Children, node)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	if root == nil {
+		return nil, apperrors.ErrLedgerNotFound.Extend(rootID)
+	}
+
+	balanceRows, err := db.Raw(constLedgerTreeBalanceQuery, args).Rows()
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+	defer util.CloseAndLogOnError(ctx, balanceRows, "could not close ledger tree balance rows")
+
+	for balanceRows.Next() {
+		var ledgerID, currency string
+		balance := new(models.LedgerTreeBalance)
+		err = balanceRows.Scan(&ledgerID, &currency,
+			&balance.Balance, &balance.UnClearedBalance, &balance.ReservedBalance)
+		if err != nil {
+			return nil, apperrors.ErrSystemFailure.Override(err)
+		}
+
+		if node, ok := nodes[ledgerID]; ok {
+			node.Balances[currency] = balance
+		}
+	}
+	if err = balanceRows.Err(); err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return root, nil
+}
+
+// Create inserts ledger, holding a share lock on its parent until it is committed so that the parent
+// cannot be archived from under it.
+func (l *ledgerRepository) Create(ctx context.Context, ledger *models.Ledger) error {
+	if ledger.ParentID == "" {
+		return l.BaseRepository.Create(ctx, ledger)
+	}
+
+	return l.Pool().DB(ctx, false).Transaction(func(tx *gorm.DB) error {
+		txErr := lockLedger(tx, ledger.ParentID, apperrors.ErrLedgerParentNotFound)
+		if txErr != nil {
+			return txErr
+		}
+
+		return tx.Create(ledger).Error
+	})
+}
+
+// MoveLedger places the ledger id and its subtree under parentID, or at the top of the hierarchy when
+// parentID is empty. The move is refused if parentID is the ledger itself or one of its descendants.
+func (l *ledgerRepository) MoveLedger(ctx context.Context, id string, parentID string) error {
+	return l.Pool().DB(ctx, false).Transaction(func(tx *gorm.DB) error {
+		txErr := tx.Exec(constLedgerHierarchyLock).Error
+		if txErr != nil {
+			return apperrors.ErrSystemFailure.Override(txErr)
+		}
+
+		if parentID != "" {
+			txErr = lockLedger(tx, parentID, apperrors.ErrLedgerParentNotFound)
+			if txErr != nil {
+				return txErr
+			}
+
+			var cycle bool
+			txErr = tx.Raw(constLedgerIsAncestorQuery, map[string]any{"id": id, "parent_id": parentID}).
+				Scan(&cycle).Error
+			if txErr != nil {
+				return apperrors.ErrSystemFailure.Override(txErr)
+			}
+			if cycle {
+				return apperrors.ErrLedgerHierarchyCycle.Extend(fmt.Sprintf("%s under %s", id, parentID))
+			}
+		}
+
+		result := tx.Model(&models.Ledger{}).Where("id = ?", id).Updates(map[string]any{
+			"parent_id":   parentID,
+			"modified_at": time.Now(),
+			"version":     gorm.Expr("version + 1"),
+		})
+		if result.Error != nil {
+			return apperrors.ErrSystemFailure.Override(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.ErrLedgerNotFound.Extend(id)
+		}
+
+		return nil
+	})
+}
+
+// Archive soft deletes the ledger id and its subtree together with their accounts in a single transaction.
+// The subtree is walked and its ledgers and accounts locked first, so check is given balances no posting
+// can change and a subtree no ledger can be added to or moved out of before they are archived.
+func (l *ledgerRepository) Archive(
+	ctx context.Context,
+	id string,
+	check func(ledgerIDs []string, accounts map[string]*models.Account) error,
+) error {
+	return l.Pool().DB(ctx, false).Transaction(func(tx *gorm.DB) error {
+		txErr := tx.Exec(constLedgerHierarchyLock).Error
+		if txErr != nil {
+			return apperrors.ErrSystemFailure.Override(txErr)
+		}
+
+		ledgerIDs, txErr := lockedSubtree(tx, id)
+		if txErr != nil {
+			return txErr
+		}
+
+		var accountIDs []string
+		txErr = tx.Raw(`SELECT id FROM accounts WHERE ledger_id IN ? AND deleted_at IS NULL ORDER BY id`, ledgerIDs).
+			Scan(&accountIDs).Error
+		if txErr != nil {
+			return apperrors.ErrSystemFailure.Override(txErr)
+		}
+
+		accounts := map[string]*models.Account{}
+		if len(accountIDs) > 0 {
+			accounts, txErr = lockedAccounts(ctx, tx, accountIDs)
+			if txErr != nil {
+				return txErr
+			}
+		}
+
+		txErr = check(ledgerIDs, accounts)
+		if txErr != nil {
+			return txErr
+		}
+
+		txErr = tx.Where("ledger_id IN ?", ledgerIDs).Delete(&models.Account{}).Error
+		if txErr != nil {
+			return apperrors.ErrSystemFailure.Override(txErr)
+		}
+
+		txErr = tx.Where("id IN ?", ledgerIDs).Delete(&models.Ledger{}).Error
+		if txErr != nil {
+			return apperrors.ErrSystemFailure.Override(txErr)
+		}
+
+		return nil
+	})
+}
+
+// lockLedger takes a share lock on the live ledger id, which archiving it waits on,
+// and returns notFound when there is no such ledger.
+func lockLedger(tx *gorm.DB, id string, notFound apperrors.ApplicationError) error {
+	var lockedIDs []string
+	err := tx.Raw(`SELECT id FROM ledgers WHERE id = ? AND deleted_at IS NULL FOR SHARE`, id).
+		Scan(&lockedIDs).Error
+	if err != nil {
+		return apperrors.ErrSystemFailure.Override(err)
+	}
+	if len(lockedIDs) == 0 {
+		return notFound.Extend(id)
+	}
+
+	return nil
+}
+
+// lockedSubtree locks the live ledgers of the subtree under id and returns their ids, the root first.
+// A ledger is created under a parent it holds a share lock on, so the subtree is walked again until no
+// ledger was added under one not yet locked.
+func lockedSubtree(tx *gorm.DB, id string) ([]string, error) {
+	locked := map[string]bool{}
+	for {
+		var ledgerIDs []string
+		err := tx.Raw(constLedgerSubtreeQuery+`SELECT id FROM subtree ORDER BY depth, id`,
+			map[string]any{"root_id": id}).Scan(&ledgerIDs).Error
+		if err != nil {
+			return nil, apperrors.ErrSystemFailure.Override(err)
+		}
+		if len(ledgerIDs) == 0 {
+			return nil, apperrors.ErrLedgerNotFound.Extend(id)
+		}
+
+		var unlocked []string
+		for _, ledgerID := range ledgerIDs {
+			if !locked[ledgerID] {
+				unlocked = append(unlocked, ledgerID)
+			}
+		}
+		if len(unlocked) == 0 {
+			return ledgerIDs, nil
+		}
+
+		var lockedIDs []string
+		err = tx.Raw(`SELECT id FROM ledgers WHERE id IN ? ORDER BY id FOR UPDATE`, unlocked).Scan(&lockedIDs).Error
+		if err != nil {
+			return nil, apperrors.ErrSystemFailure.Override(err)
+		}
+		for _, ledgerID := range unlocked {
+			locked[ledgerID] = true
+		}
+	}
+}
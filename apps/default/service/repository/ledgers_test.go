@@ -7,8 +7,10 @@ import (
 	models "github.com/antinvestor/service-ledger/apps/default/service/models"
 	"github.com/antinvestor/service-ledger/apps/default/service/repository"
 	"github.com/antinvestor/service-ledger/apps/default/tests"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
 	_ "github.com/lib/pq"
 	"github.com/pitabwire/frame"
+	"github.com/pitabwire/frame/data"
 	"github.com/pitabwire/frame/datastore"
 	"github.com/pitabwire/frame/frametests/definition"
 	"github.com/stretchr/testify/assert"
@@ -49,6 +51,57 @@ func (ls *LedgersSuite) TestLedgersInfoAPI() {
 	})
 }
 
+func (ls *LedgersSuite) TestGetLedgerTree() {
+	ls.WithTestDependencies(ls.T(), func(t *testing.T, dep *definition.DependencyOption) {
+		ctx, _, resources := ls.CreateService(t, dep)
+
+		ledgersDB := resources.LedgerRepository
+		for _, lg := range []*models.Ledger{
+			{BaseModel: data.BaseModel{ID: "tree-root"}, Type: models.LedgerTypeAsset},
+			{BaseModel: data.BaseModel{ID: "tree-bank"}, Type: models.LedgerTypeAsset, ParentID: "tree-root"},
+			{BaseModel: data.BaseModel{ID: "tree-cash"}, Type: models.LedgerTypeAsset, ParentID: "tree-root"},
+			{BaseModel: data.BaseModel{ID: "tree-branch"}, Type: models.LedgerTypeAsset, ParentID: "tree-bank"},
+		} {
+			require.NoError(t, ledgersDB.Create(ctx, lg))
+		}
+
+		for _, account := range []*models.Account{
+			{BaseModel: data.BaseModel{ID: "tree-bank-ugx"}, LedgerID: "tree-bank", Currency: "UGX"},
+			{BaseModel: data.BaseModel{ID: "tree-branch-ugx"}, LedgerID: "tree-branch", Currency: "UGX"},
+			{BaseModel: data.BaseModel{ID: "tree-branch-usd"}, LedgerID: "tree-branch", Currency: "USD"},
+		} {
+			account.LedgerType = models.LedgerTypeAsset
+			require.NoError(t, resources.AccountRepository.Create(ctx, account))
+		}
+
+		tree, err := ledgersDB.GetLedgerTree(ctx, "tree-root", 1)
+		require.NoError(t, err)
+
+		assert.Equal(t, "tree-root", tree.Ledger.ID)
+		assert.Equal(t, models.LedgerTypeAsset, tree.Ledger.Type)
+		assert.Equal(t, 2, tree.ChildCount)
+		assert.Equal(t, 0, tree.AccountCount)
+		assert.Len(t, tree.Balances, 2, "Accounts below the depth limit should still be rolled up")
+		require.Len(t, tree.Children, 2)
+
+		bank := tree.Children[0]
+		assert.Equal(t, "tree-bank", bank.Ledger.ID)
+		assert.Equal(t, 1, bank.Depth)
+		assert.Equal(t, 1, bank.ChildCount)
+		assert.Equal(t, 1, bank.AccountCount)
+		assert.Empty(t, bank.Children, "Ledgers below the depth limit should not be returned")
+		assert.Contains(t, bank.Balances, "USD")
+
+		full, err := ledgersDB.GetLedgerTree(ctx, "tree-root", 0)
+		require.NoError(t, err)
+		require.Len(t, full.Children[0].Children, 1)
+		assert.Equal(t, 2, full.Children[0].Children[0].AccountCount)
+
+		_, err = ledgersDB.GetLedgerTree(ctx, "tree-missing", 0)
+		require.ErrorIs(t, err, apperrors.ErrLedgerNotFound)
+	})
+}
+
 func TestLedgersSuite(t *testing.T) {
 	suite.Run(t, new(LedgersSuite))
 }
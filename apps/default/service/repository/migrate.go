@@ -12,5 +12,7 @@ func Migrate(ctx context.Context, dbManager datastore.Manager, migrationPath str
 
 	return dbManager.Migrate(ctx, dbPool, migrationPath,
 		&models.Ledger{}, &models.Account{}, &models.Transaction{},
-		&models.TransactionEntry{})
+		&models.TransactionEntry{}, &models.AccountStatusChange{}, &models.AccountBalance{},
+		&models.BalanceDiscrepancy{}, &models.AccountingPeriod{}, &models.PeriodBalance{},
+		&models.FXRate{}, &models.FXRevaluation{}, &models.Hold{}, &models.PostingTemplate{})
 }
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/pitabwire/frame/datastore"
+	"github.com/pitabwire/frame/datastore/pool"
+	"github.com/pitabwire/frame/workerpool"
+	"gorm.io/gorm"
+)
+
+type PeriodRepository interface {
+	datastore.BaseRepository[*models.AccountingPeriod]
+	GetByName(ctx context.Context, name string) (*models.AccountingPeriod, error)
+	Close(ctx context.Context, period *models.AccountingPeriod) error
+	ListBalances(ctx context.Context, periodID string) ([]*models.PeriodBalance, error)
+}
+
+// periodRepository provides all functions related to accounting periods.
+type periodRepository struct {
+	datastore.BaseRepository[*models.AccountingPeriod]
+}
+
+// NewPeriodRepository provides instance of `PeriodRepository`.
+func NewPeriodRepository(ctx context.Context, dbPool pool.Pool, workMan workerpool.Manager) PeriodRepository {
+	return &periodRepository{
+		BaseRepository: datastore.NewBaseRepository[*models.AccountingPeriod](
+			ctx, dbPool, workMan, func() *models.AccountingPeriod { return &models.AccountingPeriod{} },
+		),
+	}
+}
+
+// constPeriodLock serialises closing a period against postings into it. Postings take the lock shared,
+// so they only wait while the period is being closed, and a close waits for postings already in flight.
+const (
+	constPeriodLock       = `SELECT pg_advisory_xact_lock(hashtext('accounting_period:' || ?))`
+	constPeriodSharedLock = `SELECT pg_advisory_xact_lock_shared(hashtext('accounting_period:' || ?))`
+)
+
+// constSnapshotPeriodBalancesQuery stores the balance of every account at the end of @period_id from the
+// entries of the transactions made before @ends_at, counting them as cleared if they are cleared by now.
+const constSnapshotPeriodBalancesQuery = `INSERT INTO period_balances (
+    id, created_at, modified_at, version, tenant_id, partition_id, access_id,
+    period_id, account_id, currency, balance, uncleared_balance, reserved_balance
+)
+SELECT
+    @period_id || '_' || a.id, NOW(), NOW(), 1, a.tenant_id, a.partition_id, a.access_id,
+    @period_id, a.id, a.currency,
+    COALESCE(SUM(CASE WHEN t.transaction_type IN ('NORMAL', 'REVERSAL') AND t.cleared_at IS NOT NULL AND t.cleared_at != '0001-01-01 00:00:00' THEN e.amount END), 0),
+    COALESCE(SUM(CASE WHEN t.transaction_type IN ('NORMAL', 'REVERSAL') AND (t.cleared_at IS NULL OR t.cleared_at = '0001-01-01 00:00:00') THEN e.amount END), 0),
+    COALESCE(SUM(CASE WHEN t.transaction_type = 'RESERVATION' THEN e.amount END), 0)
+FROM accounts a
+LEFT JOIN transaction_entries e ON e.account_id = a.id
+LEFT JOIN transactions t ON t.id = e.transaction_id AND t.currency = a.currency AND t.transacted_at < @ends_at
+WHERE a.deleted_at IS NULL
+GROUP BY a.id, a.currency, a.tenant_id, a.partition_id, a.access_id`
+
+// GetByName returns the closed period with the given name. Periods that were never closed are not found.
+func (p *periodRepository) GetByName(ctx context.Context, name string) (*models.AccountingPeriod, error) {
+	period := new(models.AccountingPeriod)
+	err := p.Pool().DB(ctx, true).Where("name = ?", name).First(period).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return period, nil
+}
+
+// Close stores period as closed and snapshots the closing balance of every account in one transaction,
+// so the snapshot matches exactly what postings the period holds when it closes.
+func (p *periodRepository) Close(ctx context.Context, period *models.AccountingPeriod) error {
+	return p.Pool().DB(ctx, false).Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(constPeriodLock, period.Name).Error
+		if err != nil {
+			return apperrors.ErrSystemFailure.Override(err)
+		}
+
+		var existing int64
+		err = tx.Model(&models.AccountingPeriod{}).Where("name = ?", period.Name).Count(&existing).Error
+		if err != nil {
+			return apperrors.ErrSystemFailure.Override(err)
+		}
+		if existing > 0 {
+			return apperrors.ErrPeriodAlreadyClosed.Extend(period.Name)
+		}
+
+		err = tx.Create(period).Error
+		if err != nil {
+			return apperrors.ErrSystemFailure.Override(err)
+		}
+
+		err = tx.Exec(constSnapshotPeriodBalancesQuery, map[string]any{
+			"period_id": period.GetID(),
+			"ends_at":   period.EndsAt,
+		}).Error
+		if err != nil {
+			return apperrors.ErrSystemFailure.Override(err)
+		}
+
+		return nil
+	})
+}
+
+// ListBalances returns the closing balances snapshotted for a period, by account id.
+func (p *periodRepository) ListBalances(ctx context.Context, periodID string) ([]*models.PeriodBalance, error) {
+	balances := make([]*models.PeriodBalance, 0)
+
+	err := p.Pool().DB(ctx, true).Where("period_id = ?", periodID).Order("account_id").Find(&balances).Error
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return balances, nil
+}
+
+// lockPeriod takes the shared lock of the named period, held until tx ends, and reports whether it is closed.
+func lockPeriod(tx *gorm.DB, name string) (bool, error) {
+	err := tx.Exec(constPeriodSharedLock, name).Error
+	if err != nil {
+		return false, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	var closed int64
+	err = tx.Model(&models.AccountingPeriod{}).Where("name = ?", name).Count(&closed).Error
+	if err != nil {
+		return false, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return closed > 0, nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/pitabwire/frame/data"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ListReversals returns the reversals posted against the transaction with the given id, with their
+// entries, oldest first.
+func (t *transactionRepository) ListReversals(ctx context.Context, id string) ([]*models.Transaction, error) {
+	if id == "" {
+		return nil, apperrors.ErrUnspecifiedID
+	}
+
+	return listReversals(t.Pool().DB(ctx, true), id)
+}
+
+// PostReversal locks the transaction with the given id and lets build make its next reversal from it and
+// the reversals already posted against it, which is then posted as by PostLinked. Reversals of one
+// transaction so apply one after the other and together can never reverse more than it holds.
+func (t *transactionRepository) PostReversal(
+	ctx context.Context,
+	id string,
+	build func(original *models.Transaction, reversals []*models.Transaction) (*models.Transaction, error),
+	check func(accounts map[string]*models.Account, closedPeriod string) error,
+) (*models.Transaction, error) {
+	var reversal *models.Transaction
+	err := t.Pool().DB(ctx, false).Transaction(func(tx *gorm.DB) error {
+		original := new(models.Transaction)
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Entries").
+			Where("id = ?", id).First(original).Error
+		if err != nil {
+			if data.ErrorIsNoRows(err) {
+				return apperrors.ErrTransactionNotFound.Extend(id)
+			}
+			return apperrors.ErrSystemFailure.Override(err)
+		}
+
+		reversals, err := listReversals(tx, id)
+		if err != nil {
+			return err
+		}
+
+		reversal, err = build(original, reversals)
+		if err != nil {
+			return err
+		}
+
+		return postLinked(ctx, tx, []*models.Transaction{reversal}, check)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reversal, nil
+}
+
+func listReversals(db *gorm.DB, id string) ([]*models.Transaction, error) {
+	reversals := make([]*models.Transaction, 0)
+	err := db.Preload("Entries").Where("reversal_of = ?", id).Order("created_at, id").Find(&reversals).Error
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return reversals, nil
+}
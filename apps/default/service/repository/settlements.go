@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SettleBatch clears the pending transactions named by settlements, each recording the settlement
+// reference it maps to, all or none. reverse builds from the transactions cleared those to be posted with
+// them, e.g. the reversals of failed ones, which are checked by check as by PostLinked. A transaction
+// already cleared with the same reference is left as it is, so that a settlement file can be replayed,
+// and the transactions cleared are returned.
+func (t *transactionRepository) SettleBatch(
+	ctx context.Context,
+	settlements map[string]string,
+	clearedAt time.Time,
+	reverse func(pending []*models.Transaction) ([]*models.Transaction, error),
+	check func(accounts map[string]*models.Account, closedPeriod string) error,
+) ([]*models.Transaction, error) {
+	var pending []*models.Transaction
+	err := t.Pool().DB(ctx, false).Transaction(func(tx *gorm.DB) error {
+		var err error
+		pending, err = lockPendingTransactions(tx, settlements)
+		if err != nil || len(pending) == 0 {
+			return err
+		}
+
+		var reversals []*models.Transaction
+		if reverse != nil {
+			reversals, err = reverse(pending)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Periods are locked ahead of the accounts, in the order postLinked takes them.
+		periodSet := map[string]bool{}
+		for _, reversal := range reversals {
+			periodSet[models.PeriodName(reversal.TransactedAt)] = true
+		}
+		for _, periodName := range slices.Sorted(maps.Keys(periodSet)) {
+			_, err = lockPeriod(tx, periodName)
+			if err != nil {
+				return err
+			}
+		}
+
+		accountIDSet := map[string]bool{}
+		for _, transaction := range pending {
+			for _, accountID := range transaction.AccountIDs() {
+				accountIDSet[accountID] = true
+			}
+		}
+
+		accounts, err := lockedAccounts(ctx, tx, slices.Sorted(maps.Keys(accountIDSet)))
+		if err != nil {
+			return err
+		}
+
+		for _, transaction := range pending {
+			_, err = clearLocked(tx, transaction, clearedAt, settlements[transaction.GetID()], accounts)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(reversals) == 0 {
+			return nil
+		}
+
+		return postLinked(ctx, tx, reversals, check)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+// lockPendingTransactions locks the transactions named by settlements and returns those still pending
+// with their entries, in id order. Every transaction must exist, and one already cleared must have been
+// settled with the same reference.
+func lockPendingTransactions(tx *gorm.DB, settlements map[string]string) ([]*models.Transaction, error) {
+	ids := slices.Sorted(maps.Keys(settlements))
+
+	var transactions []*models.Transaction
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", ids).Order("id").
+		Find(&transactions).Error
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	if len(transactions) != len(ids) {
+		found := make(map[string]bool, len(transactions))
+		for _, transaction := range transactions {
+			found[transaction.GetID()] = true
+		}
+
+		var missing []string
+		for _, id := range ids {
+			if !found[id] {
+				missing = append(missing, id)
+			}
+		}
+		return nil, apperrors.ErrTransactionNotFound.Extend(strings.Join(missing, ", "))
+	}
+
+	pending := make([]*models.Transaction, 0, len(transactions))
+	pendingIDs := make([]string, 0, len(transactions))
+	for _, transaction := range transactions {
+		if transaction.ClearedAt.IsZero() {
+			pending = append(pending, transaction)
+			pendingIDs = append(pendingIDs, transaction.GetID())
+			continue
+		}
+
+		if transaction.SettlementReference != settlements[transaction.GetID()] {
+			return nil, apperrors.ErrTransactionNotPending.Extend(
+				fmt.Sprintf("transaction %s was cleared at %s", transaction.GetID(),
+					transaction.ClearedAt.Format(time.RFC3339)),
+			)
+		}
+	}
+
+	if len(pending) == 0 {
+		return pending, nil
+	}
+
+	var entries []*models.TransactionEntry
+	err = tx.Where("transaction_id IN ?", pendingIDs).Order("id").Find(&entries).Error
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	entriesByTransaction := map[string][]*models.TransactionEntry{}
+	for _, entry := range entries {
+		entriesByTransaction[entry.TransactionID] = append(entriesByTransaction[entry.TransactionID], entry)
+	}
+	for _, transaction := range pending {
+		transaction.Entries = entriesByTransaction[transaction.GetID()]
+	}
+
+	return pending, nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/antinvestor/service-ledger/apps/default/service/models"
+	"github.com/antinvestor/service-ledger/internal/apperrors"
+	"github.com/pitabwire/frame/datastore"
+	"github.com/pitabwire/frame/datastore/pool"
+	"github.com/pitabwire/frame/workerpool"
+	"gorm.io/gorm/clause"
+)
+
+type PostingTemplateRepository interface {
+	datastore.BaseRepository[*models.PostingTemplate]
+	SaveTemplate(ctx context.Context, template *models.PostingTemplate) error
+}
+
+// postingTemplateRepository provides all functions related to posting templates.
+type postingTemplateRepository struct {
+	datastore.BaseRepository[*models.PostingTemplate]
+}
+
+// NewPostingTemplateRepository provides instance of `PostingTemplateRepository`.
+func NewPostingTemplateRepository(
+	ctx context.Context,
+	dbPool pool.Pool,
+	workMan workerpool.Manager,
+) PostingTemplateRepository {
+	return &postingTemplateRepository{
+		BaseRepository: datastore.NewBaseRepository[*models.PostingTemplate](
+			ctx, dbPool, workMan, func() *models.PostingTemplate { return &models.PostingTemplate{} },
+		),
+	}
+}
+
+// SaveTemplate stores template, replacing any template already saved under its name.
+func (r *postingTemplateRepository) SaveTemplate(ctx context.Context, template *models.PostingTemplate) error {
+	err := r.Pool().DB(ctx, false).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"description", "currency", "pending", "legs", "modified_at"}),
+	}).Create(template).Error
+	if err != nil {
+		return apperrors.ErrSystemFailure.Override(err)
+	}
+
+	return nil
+}
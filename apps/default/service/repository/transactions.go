@@ -2,8 +2,12 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
+	"time"
 
 	"github.com/antinvestor/service-ledger/apps/default/service/models"
 	"github.com/antinvestor/service-ledger/internal/apperrors"
@@ -11,6 +15,8 @@ import (
 	"github.com/pitabwire/frame/datastore/pool"
 	"github.com/pitabwire/frame/workerpool"
 	"github.com/pitabwire/util"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 type TransactionRepository interface {
@@ -19,8 +25,51 @@ type TransactionRepository interface {
 	) (workerpool.JobResultPipe[[]*models.Transaction], error)
 	SearchEntries(ctx context.Context, query string,
 	) (workerpool.JobResultPipe[[]*models.TransactionEntry], error)
+	Post(ctx context.Context, transaction *models.Transaction,
+		check func(accounts map[string]*models.Account, closedPeriod string) error) error
+	PostLinked(ctx context.Context, transactions []*models.Transaction,
+		check func(accounts map[string]*models.Account, closedPeriod string) error) error
+	UpdateAndClear(ctx context.Context, transaction *models.Transaction, clearedAt time.Time) error
+	ListReversals(ctx context.Context, id string) ([]*models.Transaction, error)
+	PostReversal(ctx context.Context, id string,
+		build func(original *models.Transaction, reversals []*models.Transaction) (*models.Transaction, error),
+		check func(accounts map[string]*models.Account, closedPeriod string) error) (*models.Transaction, error)
+	SettleBatch(ctx context.Context, settlements map[string]string, clearedAt time.Time,
+		reverse func(pending []*models.Transaction) ([]*models.Transaction, error),
+		check func(accounts map[string]*models.Account, closedPeriod string) error) ([]*models.Transaction, error)
+	GetHold(ctx context.Context, id string) (*models.Hold, error)
+	ListExpiredHolds(ctx context.Context, at time.Time, limit int) ([]*models.Hold, error)
+	SettleHold(ctx context.Context, holdID string,
+		settle func(hold *models.Hold) ([]*models.Transaction, error),
+		check func(accounts map[string]*models.Account, closedPeriod string) error) (*models.Hold, error)
+	StatementEntries(ctx context.Context, accountID string, from, to time.Time,
+	) (workerpool.JobResultPipe[[]*models.TransactionEntry], error)
 }
 
+// constStatementEntriesQuery reads the posted entries of an account transacted in (from, to], in the
+// order they appear on a statement. Paging is by (transacted_at, id) so entries posted while a statement
+// is streamed cannot shift later batches.
+const constStatementEntriesQuery = `SELECT 
+    e.id,
+    e.account_id,
+    e.transaction_id,
+    t.currency,
+    e.amount,
+    e.credit,
+    COALESCE(e.sequence, 0),
+    e.created_at,
+    t.cleared_at,
+    t.transacted_at
+FROM transaction_entries e
+JOIN transactions t ON t.id = e.transaction_id
+JOIN accounts a ON a.id = e.account_id AND a.currency = t.currency
+WHERE e.account_id = @account_id
+  AND t.transaction_type IN ('NORMAL', 'REVERSAL')
+  AND t.transacted_at > @from AND t.transacted_at <= @to
+  AND (t.transacted_at, e.id) > (@after_at, @after_id)
+ORDER BY t.transacted_at, e.id
+LIMIT @limit`
+
 // transactionRepository is the interface to all transaction operations.
 type transactionRepository struct {
 	accountRepo AccountRepository
@@ -42,6 +91,317 @@ func NewTransactionRepository(
 	}
 }
 
+// Post stores a transaction while holding row locks on every account it touches.
+// Accounts are locked in id order so concurrent postings cannot deadlock, and their balances are
+// re-read under the lock before check is given the chance to reject the posting.
+// The accounting period of the transaction is locked against closing as well, and check is told
+// its name when it is already closed. The maintained account balances are updated in the same
+// database transaction.
+func (t *transactionRepository) Post(
+	ctx context.Context,
+	transaction *models.Transaction,
+	check func(accounts map[string]*models.Account, closedPeriod string) error,
+) error {
+	return t.PostLinked(ctx, []*models.Transaction{transaction}, check)
+}
+
+// PostLinked stores transactions that must all be posted or none, such as the currency legs of an
+// exchange, under the same locks as Post. The transactions are expected to fall in one accounting
+// period; check is told the name of the first one found closed.
+func (t *transactionRepository) PostLinked(
+	ctx context.Context,
+	transactions []*models.Transaction,
+	check func(accounts map[string]*models.Account, closedPeriod string) error,
+) error {
+	return t.Pool().DB(ctx, false).Transaction(func(tx *gorm.DB) error {
+		return postLinked(ctx, tx, transactions, check)
+	})
+}
+
+// postLinked posts transactions within tx, opening a hold for every new reservation.
+func postLinked(
+	ctx context.Context,
+	tx *gorm.DB,
+	transactions []*models.Transaction,
+	check func(accounts map[string]*models.Account, closedPeriod string) error,
+) error {
+	accountIDSet := map[string]bool{}
+	periodSet := map[string]bool{}
+	for _, transaction := range transactions {
+		for _, accountID := range transaction.AccountIDs() {
+			accountIDSet[accountID] = true
+		}
+		periodSet[models.PeriodName(transaction.TransactedAt)] = true
+	}
+	accountIDs := slices.Sorted(maps.Keys(accountIDSet))
+	periodNames := slices.Sorted(maps.Keys(periodSet))
+
+	closedPeriod := ""
+	for _, periodName := range periodNames {
+		closed, err := lockPeriod(tx, periodName)
+		if err != nil {
+			return err
+		}
+		if closed && closedPeriod == "" {
+			closedPeriod = periodName
+		}
+	}
+
+	accounts, err := lockedAccounts(ctx, tx, accountIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, transaction := range transactions {
+		var existing int64
+		err = tx.Model(&models.Transaction{}).Where("id = ?", transaction.GetID()).Count(&existing).Error
+		if err != nil {
+			return apperrors.ErrSystemFailure.Override(err)
+		}
+
+		if existing > 0 {
+			return apperrors.ErrTransactionAlreadyExists
+		}
+	}
+
+	err = check(accounts, closedPeriod)
+	if err != nil {
+		return err
+	}
+
+	for _, transaction := range transactions {
+		assignEntrySequences(transaction, accounts)
+
+		err = tx.Create(transaction).Error
+		if err != nil {
+			return err
+		}
+
+		err = applyBalanceDeltas(tx, postingDeltas(transaction), accounts)
+		if err != nil {
+			return err
+		}
+
+		err = openHold(ctx, tx, transaction)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateAndClear stores the data of transaction and, unless clearedAt is zero, marks it cleared and moves
+// its amounts from the uncleared to the cleared balances of its accounts, refreshing the entry balance
+// snapshots as it goes. Both apply in one database transaction, so a failed clearing stores nothing.
+// Clearing an already cleared transaction leaves it unchanged.
+func (t *transactionRepository) UpdateAndClear(
+	ctx context.Context,
+	transaction *models.Transaction,
+	clearedAt time.Time,
+) error {
+	return t.Pool().DB(ctx, false).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Transaction{}).Where("id = ?", transaction.GetID()).Updates(map[string]any{
+			"data":        transaction.Data,
+			"modified_at": time.Now(),
+			"version":     gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return apperrors.ErrSystemFailure.Override(err)
+		}
+
+		if clearedAt.IsZero() {
+			return nil
+		}
+
+		var entries []*models.TransactionEntry
+		err = tx.Where("transaction_id = ?", transaction.GetID()).Order("id").Find(&entries).Error
+		if err != nil {
+			return apperrors.ErrSystemFailure.Override(err)
+		}
+
+		transaction.Entries = entries
+		accounts, err := lockedAccounts(ctx, tx, transaction.AccountIDs())
+		if err != nil {
+			return err
+		}
+
+		_, err = clearLocked(tx, transaction, clearedAt, "", accounts)
+		return err
+	})
+}
+
+// clearLocked clears a pending transaction, with its entries loaded, against accounts locked by tx and
+// reports whether it was still pending. The accounts' balances are advanced past the transaction, so
+// transactions cleared one after the other in tx snapshot the balances each leaves behind.
+func clearLocked(
+	tx *gorm.DB,
+	transaction *models.Transaction,
+	clearedAt time.Time,
+	settlementReference string,
+	accounts map[string]*models.Account,
+) (bool, error) {
+	updates := map[string]any{
+		"cleared_at":  clearedAt,
+		"modified_at": time.Now(),
+		"version":     gorm.Expr("version + 1"),
+	}
+	if settlementReference != "" {
+		updates["settlement_reference"] = settlementReference
+	}
+
+	result := tx.Model(&models.Transaction{}).
+		Where("id = ? AND (cleared_at IS NULL OR cleared_at = ?)", transaction.GetID(), time.Time{}).
+		Updates(updates)
+	if result.Error != nil {
+		return false, apperrors.ErrSystemFailure.Override(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	transaction.ClearedAt = clearedAt
+	if settlementReference != "" {
+		transaction.SettlementReference = settlementReference
+	}
+
+	running := make(map[string]decimal.Decimal, len(accounts))
+	for accountID, account := range accounts {
+		running[accountID] = account.Balance.Decimal
+	}
+
+	for _, entry := range transaction.Entries {
+		entry.Balance = decimal.NewNullDecimal(running[entry.AccountID])
+		running[entry.AccountID] = running[entry.AccountID].Add(entry.Amount.Decimal)
+
+		err := tx.Model(&models.TransactionEntry{}).Where("id = ?", entry.GetID()).
+			Update("balance", entry.Balance).Error
+		if err != nil {
+			return false, apperrors.ErrSystemFailure.Override(err)
+		}
+	}
+
+	deltas := clearingDeltas(transaction)
+	err := applyBalanceDeltas(tx, deltas, accounts)
+	if err != nil {
+		return false, err
+	}
+
+	for accountID, delta := range deltas {
+		account, ok := accounts[accountID]
+		if !ok {
+			continue
+		}
+		account.Balance = decimal.NewNullDecimal(account.Balance.Decimal.Add(delta.Balance))
+		account.UnClearedBalance = decimal.NewNullDecimal(account.UnClearedBalance.Decimal.Add(delta.UnClearedBalance))
+		account.UnClearedOutgoing = decimal.NewNullDecimal(
+			account.UnClearedOutgoing.Decimal.Add(delta.UnClearedOutgoing))
+	}
+
+	return true, nil
+}
+
+// StatementEntries streams the posted entries of an account transacted after from and up to to,
+// ordered by transacted_at, with their transaction's currency and timestamps filled in.
+func (t *transactionRepository) StatementEntries(
+	ctx context.Context,
+	accountID string,
+	from, to time.Time,
+) (workerpool.JobResultPipe[[]*models.TransactionEntry], error) {
+	job := workerpool.NewJob(
+		func(ctx context.Context, jobResult workerpool.JobResultPipe[[]*models.TransactionEntry]) error {
+			afterAt, afterID := from, ""
+
+			for {
+				entries, err := t.statementEntriesBatch(ctx, accountID, from, to, afterAt, afterID)
+				if err != nil {
+					return jobResult.WriteError(ctx, apperrors.ErrSystemFailure.Override(err))
+				}
+
+				if len(entries) > 0 {
+					err = jobResult.WriteResult(ctx, entries)
+					if err != nil {
+						return err
+					}
+
+					last := entries[len(entries)-1]
+					afterAt, afterID = last.TransactedAt, last.ID
+				}
+
+				if len(entries) < SystemBatchSize {
+					return nil
+				}
+			}
+		},
+	)
+
+	err := workerpool.SubmitJob(ctx, t.WorkManager(), job)
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (t *transactionRepository) statementEntriesBatch(
+	ctx context.Context,
+	accountID string,
+	from, to, afterAt time.Time,
+	afterID string,
+) ([]*models.TransactionEntry, error) {
+	rows, err := t.Pool().DB(ctx, true).Raw(constStatementEntriesQuery, map[string]any{
+		"account_id": accountID,
+		"from":       from,
+		"to":         to,
+		"after_at":   afterAt,
+		"after_id":   afterID,
+		"limit":      SystemBatchSize,
+	}).Rows()
+	if err != nil {
+		return nil, err
+	}
+
+	defer util.CloseAndLogOnError(ctx, rows, "could not close statement entry rows")
+
+	var entries []*models.TransactionEntry
+	for rows.Next() {
+		entry := &models.TransactionEntry{}
+		var clearedAt sql.NullTime
+		err = rows.Scan(&entry.ID, &entry.AccountID, &entry.TransactionID, &entry.Currency, &entry.Amount,
+			&entry.Credit, &entry.Sequence, &entry.CreatedAt, &clearedAt, &entry.TransactedAt)
+		if err != nil {
+			return nil, err
+		}
+		entry.ClearedAt = clearedAt.Time
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// lockedAccounts takes row locks on the accounts in id order and reads them back with their balances.
+func lockedAccounts(ctx context.Context, tx *gorm.DB, accountIDs []string) (map[string]*models.Account, error) {
+	var lockedIDs []string
+	err := tx.Raw(`SELECT id FROM accounts WHERE id IN ? ORDER BY id FOR UPDATE`, accountIDs).
+		Scan(&lockedIDs).Error
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	accountList, err := queryAccounts(ctx, tx, "a.id IN ?", accountIDs)
+	if err != nil {
+		return nil, apperrors.ErrSystemFailure.Override(err)
+	}
+
+	accounts := make(map[string]*models.Account, len(accountList))
+	for _, acc := range accountList {
+		accounts[acc.ID] = acc
+	}
+
+	return accounts, nil
+}
+
 func (t *transactionRepository) searchTransactions(
 	ctx context.Context,
 	sqlQuery *SearchSQLQuery,
@@ -28,9 +28,20 @@ type ServiceResources struct {
 	LedgerRepository      repository.LedgerRepository
 	AccountRepository     repository.AccountRepository
 	TransactionRepository repository.TransactionRepository
+	PeriodRepository      repository.PeriodRepository
+	FXRateRepository      repository.FXRateRepository
+	TemplateRepository    repository.PostingTemplateRepository
 	LedgerBusiness        business.LedgerBusiness
 	AccountBusiness       business.AccountBusiness
 	TransactionBusiness   business.TransactionBusiness
+	StatementBusiness     business.StatementBusiness
+	ReportBusiness        business.ReportBusiness
+	ChartBusiness         business.ChartBusiness
+	PeriodBusiness        business.PeriodBusiness
+	YearEndBusiness       business.YearEndBusiness
+	FXRateBusiness        business.FXRateBusiness
+	RevaluationBusiness   business.RevaluationBusiness
+	TemplateBusiness      business.TemplateBusiness
 }
 
 type BaseTestSuite struct {
@@ -100,17 +111,44 @@ func (bs *BaseTestSuite) CreateService(
 	ledgerRepo := repository.NewLedgerRepository(ctx, dbPool, workMan)
 	accountRepo := repository.NewAccountRepository(ctx, dbPool, workMan)
 	transactionRepo := repository.NewTransactionRepository(ctx, dbPool, workMan, accountRepo)
-	ledgerBusiness := business.NewLedgerBusiness(workMan, ledgerRepo)
+	periodRepo := repository.NewPeriodRepository(ctx, dbPool, workMan)
+	fxRateRepo := repository.NewFXRateRepository(ctx, dbPool, workMan)
+	templateRepo := repository.NewPostingTemplateRepository(ctx, dbPool, workMan)
+	ledgerBusiness := business.NewLedgerBusiness(workMan, ledgerRepo, accountRepo, cfg.GetLedgerChildTypes())
 	accountBusiness := business.NewAccountBusiness(workMan, ledgerRepo, accountRepo)
-	transactionBusiness := business.NewTransactionBusiness(workMan, accountRepo, transactionRepo)
+	transactionBusiness := business.NewTransactionBusiness(
+		workMan, accountRepo, transactionRepo, cfg.GetAdjustmentRole(), cfg.GetFXPositionAccounts(), cfg.GetHoldTTL())
+	statementBusiness := business.NewStatementBusiness(accountRepo, transactionRepo)
+	fxRateBusiness := business.NewFXRateBusiness(fxRateRepo)
+	reportBusiness := business.NewReportBusiness(ledgerRepo, accountRepo, fxRateBusiness)
+	chartBusiness := business.NewChartBusiness(ledgerRepo, accountRepo, cfg.GetLedgerChildTypes())
+	periodBusiness := business.NewPeriodBusiness(periodRepo, cfg.GetPeriodAdminRole())
+	yearEndBusiness := business.NewYearEndBusiness(accountRepo, transactionBusiness, cfg.GetPeriodAdminRole())
+	revaluationBusiness := business.NewRevaluationBusiness(business.RevaluationConfig{
+		BaseCurrency: cfg.GetFXBaseCurrency(),
+		GainLedgerID: cfg.GetFXGainLedger(),
+		LossLedgerID: cfg.GetFXLossLedger(),
+	}, ledgerRepo, accountRepo, fxRateRepo, fxRateBusiness, transactionBusiness)
+	templateBusiness := business.NewTemplateBusiness(templateRepo, transactionBusiness)
 
 	resources := &ServiceResources{
 		LedgerRepository:      ledgerRepo,
 		AccountRepository:     accountRepo,
 		TransactionRepository: transactionRepo,
+		PeriodRepository:      periodRepo,
+		FXRateRepository:      fxRateRepo,
+		TemplateRepository:    templateRepo,
 		LedgerBusiness:        ledgerBusiness,
 		AccountBusiness:       accountBusiness,
 		TransactionBusiness:   transactionBusiness,
+		StatementBusiness:     statementBusiness,
+		ReportBusiness:        reportBusiness,
+		ChartBusiness:         chartBusiness,
+		PeriodBusiness:        periodBusiness,
+		YearEndBusiness:       yearEndBusiness,
+		FXRateBusiness:        fxRateBusiness,
+		RevaluationBusiness:   revaluationBusiness,
+		TemplateBusiness:      templateBusiness,
 	}
 
 	err = repository.Migrate(ctx, dbManager, "../../migrations/0001")
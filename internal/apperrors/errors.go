@@ -1,6 +1,7 @@
 package apperrors
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 )
@@ -14,13 +15,21 @@ const (
 	ErrorCodeBadDataSupplied      = 4
 
 	// Ledger error codes (11-20).
-	ErrorCodeLedgerNotFound = 11
+	ErrorCodeLedgerNotFound       = 11
+	ErrorCodeLedgerParentNotFound = 12
+	ErrorCodeLedgerHierarchyCycle = 13
+	ErrorCodeLedgerTypeMismatch   = 14
 
 	// Account error codes (21-30).
 	ErrorCodeAccountNotFound            = 21
 	ErrorCodeAccountsNotFound           = 22
 	ErrorCodeAccountsCurrencyUnknown    = 23
 	ErrorCodeAccountWithReferenceExists = 24
+	ErrorCodeAccountClosed              = 25
+	ErrorCodeAccountDebitBlocked        = 26
+	ErrorCodeAccountCreditBlocked       = 27
+	ErrorCodeAccountBalanceBelowFloor   = 28
+	ErrorCodeAccountFundsUnavailable    = 29
 
 	// Transaction error codes (31-60).
 	ErrorCodeTransactionNotFound               = 31
@@ -32,12 +41,28 @@ const (
 	ErrorCodeTransactionHasInvalidDrCrEntry    = 37
 	ErrorCodeTransactionIsConflicting          = 38
 	ErrorCodeTransactionTypeNotReversible      = 39
+	ErrorCodeTransactionNotPending             = 40
+	ErrorCodeTransactionReversalExceeded       = 41
 
 	// Search error codes (61-70).
 	ErrorCodeSearchNamespaceUnknown       = 61
 	ErrorCodeSearchQueryHasInvalidFormat  = 62
 	ErrorCodeSearchQueryHasInvalidKeys    = 63
 	ErrorCodeSearchQueryResultsNotCasting = 64
+
+	// Period error codes (71-80).
+	ErrorCodePeriodClosed        = 71
+	ErrorCodePeriodAlreadyClosed = 72
+	ErrorCodePeriodNotFound      = 73
+
+	// FX error codes (81-90).
+	ErrorCodeFXRateNotFound = 81
+
+	// Hold error codes (91-100).
+	ErrorCodeHoldNotFound       = 91
+	ErrorCodeHoldNotOpen        = 92
+	ErrorCodeHoldAmountExceeded = 93
+	ErrorCodeHoldExpiryInvalid  = 94
 )
 
 type ApplicationError interface {
@@ -76,6 +101,16 @@ func (e applicationLedgerError) String() string {
 	return e.Error()
 }
 
+// Is reports whether target is an application error with the same code,
+// so extended or overridden errors still match their base error.
+func (e applicationLedgerError) Is(target error) bool {
+	var appErr ApplicationError
+	if !errors.As(target, &appErr) {
+		return false
+	}
+	return appErr.ErrorCode() == e.ErrorCode()
+}
+
 // Extend default Message.
 func (e applicationLedgerError) Extend(message string) ApplicationError {
 	return &applicationLedgerError{e.Code, e.CodeOffset, e.Message, message}
@@ -97,7 +132,19 @@ var (
 	ErrUnspecifiedReference = NewApplicationError(ErrorCodeUnspecifiedReference, "No reference was supplied")
 	ErrBadDataSupplied      = NewApplicationError(ErrorCodeBadDataSupplied, "Invalid data format was supplied")
 
-	ErrLedgerNotFound = NewApplicationError(ErrorCodeLedgerNotFound, "Ledger with reference/id not found")
+	ErrLedgerNotFound       = NewApplicationError(ErrorCodeLedgerNotFound, "Ledger with reference/id not found")
+	ErrLedgerParentNotFound = NewApplicationError(
+		ErrorCodeLedgerParentNotFound,
+		"Parent ledger with reference/id not found",
+	)
+	ErrLedgerHierarchyCycle = NewApplicationError(
+		ErrorCodeLedgerHierarchyCycle,
+		"Ledger cannot be placed under itself or one of its descendants",
+	)
+	ErrLedgerTypeMismatch = NewApplicationError(
+		ErrorCodeLedgerTypeMismatch,
+		"Ledger type is not allowed under the parent ledger type",
+	)
 
 	ErrAccountNotFound  = NewApplicationError(ErrorCodeAccountNotFound, "Account with reference/id not found")
 	ErrAccountsNotFound = NewApplicationError(
@@ -112,6 +159,26 @@ var (
 		ErrorCodeAccountWithReferenceExists,
 		"An account with the given reference exists",
 	)
+	ErrAccountClosed = NewApplicationError(
+		ErrorCodeAccountClosed,
+		"Account is closed and cannot be posted to",
+	)
+	ErrAccountDebitBlocked = NewApplicationError(
+		ErrorCodeAccountDebitBlocked,
+		"Account status does not allow debits",
+	)
+	ErrAccountCreditBlocked = NewApplicationError(
+		ErrorCodeAccountCreditBlocked,
+		"Account status does not allow credits",
+	)
+	ErrAccountBalanceBelowFloor = NewApplicationError(
+		ErrorCodeAccountBalanceBelowFloor,
+		"Insufficient funds, posting would take the account below its balance floor",
+	)
+	ErrAccountFundsUnavailable = NewApplicationError(
+		ErrorCodeAccountFundsUnavailable,
+		"Insufficient funds, posting would exceed the account's available balance",
+	)
 
 	ErrTransactionNotFound = NewApplicationError(
 		ErrorCodeTransactionNotFound,
@@ -149,6 +216,14 @@ var (
 		ErrorCodeTransactionTypeNotReversible,
 		"Transaction type is not reversible",
 	)
+	ErrTransactionNotPending = NewApplicationError(
+		ErrorCodeTransactionNotPending,
+		"Transaction is no longer pending settlement",
+	)
+	ErrTransactionReversalExceeded = NewApplicationError(
+		ErrorCodeTransactionReversalExceeded,
+		"Reversal exceeds what remains of the transaction",
+	)
 
 	ErrSearchNamespaceUnknown = NewApplicationError(
 		ErrorCodeSearchNamespaceUnknown,
@@ -166,4 +241,39 @@ var (
 		ErrorCodeSearchQueryResultsNotCasting,
 		"Search query results not casting",
 	)
+
+	ErrPeriodClosed = NewApplicationError(
+		ErrorCodePeriodClosed,
+		"Accounting period is closed to postings",
+	)
+	ErrPeriodAlreadyClosed = NewApplicationError(
+		ErrorCodePeriodAlreadyClosed,
+		"Accounting period is already closed",
+	)
+	ErrPeriodNotFound = NewApplicationError(
+		ErrorCodePeriodNotFound,
+		"Accounting period not found",
+	)
+
+	ErrFXRateNotFound = NewApplicationError(
+		ErrorCodeFXRateNotFound,
+		"No exchange rate is in effect for the currency pair",
+	)
+
+	ErrHoldNotFound = NewApplicationError(
+		ErrorCodeHoldNotFound,
+		"Hold with reference/id not found",
+	)
+	ErrHoldNotOpen = NewApplicationError(
+		ErrorCodeHoldNotOpen,
+		"Hold is no longer open",
+	)
+	ErrHoldAmountExceeded = NewApplicationError(
+		ErrorCodeHoldAmountExceeded,
+		"Amount exceeds what remains of the hold",
+	)
+	ErrHoldExpiryInvalid = NewApplicationError(
+		ErrorCodeHoldExpiryInvalid,
+		"Hold expiry is not a valid RFC3339 time",
+	)
 )
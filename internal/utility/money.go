@@ -56,6 +56,13 @@ func FromMoney(m *money.Money) decimal.Decimal {
 	return units.Add(nanos)
 }
 
+// MoneyString renders an amount as text at the precision it has once converted with ToMoney,
+// so amounts outside the API agree with the amounts in it.
+func MoneyString(currency string, amount decimal.Decimal) string {
+	m := ToMoney(currency, amount)
+	return FromMoney(&m).String()
+}
+
 func CompareMoney(a, b *money.Money) bool {
 	if a.GetCurrencyCode() != b.GetCurrencyCode() {
 		return false